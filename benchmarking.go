@@ -176,11 +176,13 @@ func (c *Comparision) record() {
 			sum.UniqueStates += cov.UniqueStates
 			sum.UniqueStateTraces += cov.UniqueStateTraces
 			sum.UniqueTraces += cov.UniqueTraces
+			sum.PredicateValuations += cov.PredicateValuations
 		}
 		avg := CoverageStats{
-			UniqueStates:      sum.UniqueStates / len(coverages),
-			UniqueStateTraces: sum.UniqueStateTraces / len(coverages),
-			UniqueTraces:      sum.UniqueTraces / len(coverages),
+			UniqueStates:        sum.UniqueStates / len(coverages),
+			UniqueStateTraces:   sum.UniqueStateTraces / len(coverages),
+			UniqueTraces:        sum.UniqueTraces / len(coverages),
+			PredicateValuations: sum.PredicateValuations / len(coverages),
 		}
 		recordData[name]["average_coverage"] = avg
 		recordData[name]["coverages"] = coverages
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Bisector searches a git range of the system under test for the first commit
+// on which a bundle reproduces its violation. Every candidate is built in a
+// scratch worktree of the harness at HEAD, with only SUTPath checked out at the
+// candidate commit, and the bundle is replayed by the built binary.
+type Bisector struct {
+	Repo    string
+	SUTPath string
+	Good    string
+	Bad     string
+	Bundle  string
+}
+
+// exitCheckerViolated is the exit code of replay when the bundle reproduces its
+// violation. Any other non-zero exit means the replay itself failed, so the
+// bisector cannot tell whether the candidate commit is good or bad.
+const exitCheckerViolated = 3
+
+var errCheckerViolated = errors.New("checker violated")
+
+type bisectVerdict int
+
+const (
+	verdictGood bisectVerdict = iota
+	verdictBad
+	verdictSkip
+)
+
+func (b *Bisector) git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Run returns the first bad commit of the range
+func (b *Bisector) Run() (string, error) {
+	bundle, err := filepath.Abs(b.Bundle)
+	if err != nil {
+		return "", err
+	}
+	b.Bundle = bundle
+
+	out, err := b.git(b.Repo, "rev-list", "--reverse", b.Good+".."+b.Bad, "--", b.SUTPath)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", errors.New("no commits touching the system under test in range")
+	}
+	commits := strings.Split(out, "\n")
+
+	// Invariant: the commit before lo is good, commits[hi] is bad
+	lo, hi := 0, len(commits)-1
+	skipped := make(map[int]bool)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		for skipped[mid] && mid < hi {
+			mid++
+		}
+		if mid == hi {
+			break
+		}
+		verdict, err := b.test(commits[mid])
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("%s: %s\n", commits[mid][:12], []string{"good", "bad", "skip"}[verdict])
+		switch verdict {
+		case verdictGood:
+			lo = mid + 1
+		case verdictBad:
+			hi = mid
+		case verdictSkip:
+			skipped[mid] = true
+		}
+	}
+	return commits[hi], nil
+}
+
+func (b *Bisector) test(commit string) (bisectVerdict, error) {
+	dir, err := os.MkdirTemp("", "fuzz-bisect-")
+	if err != nil {
+		return verdictSkip, err
+	}
+	defer os.RemoveAll(dir)
+	worktree := filepath.Join(dir, "src")
+	if _, err := b.git(b.Repo, "worktree", "add", "--detach", worktree, "HEAD"); err != nil {
+		return verdictSkip, err
+	}
+	defer b.git(b.Repo, "worktree", "remove", "--force", worktree)
+
+	if _, err := b.git(worktree, "checkout", commit, "--", b.SUTPath); err != nil {
+		return verdictSkip, err
+	}
+	binary := filepath.Join(dir, "fuzzer")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Dir = worktree
+	if out, err := build.CombinedOutput(); err != nil {
+		fmt.Printf("build failed for %s: %s\n", commit, strings.TrimSpace(string(out)))
+		return verdictSkip, nil
+	}
+
+	replay := exec.Command(binary, "replay", "--bundle", b.Bundle)
+	err = replay.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return verdictGood, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == exitCheckerViolated:
+		return verdictBad, nil
+	default:
+		return verdictSkip, nil
+	}
+}
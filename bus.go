@@ -0,0 +1,65 @@
+package main
+
+import "sync"
+
+var (
+	ScheduleStarted   CampaignEventType = "ScheduleStarted"
+	ScheduleCompleted CampaignEventType = "ScheduleCompleted"
+	FaultInjected     CampaignEventType = "FaultInjected"
+	InvariantViolated CampaignEventType = "InvariantViolated"
+	ScheduleStalled   CampaignEventType = "ScheduleStalled"
+	ScheduleCancelled CampaignEventType = "ScheduleCancelled"
+	ConfigChanged     CampaignEventType = "ConfigChanged"
+)
+
+type CampaignEventType string
+
+// CampaignEvent is a harness lifecycle event published on the EventBus
+type CampaignEvent struct {
+	Type      CampaignEventType
+	Iteration string
+	Step      int
+	Node      uint64
+	Params    map[string]interface{}
+}
+
+// EventBus dispatches campaign events to the harness components subscribed to them.
+// Handlers run synchronously in the publishing goroutine, in subscription order.
+type EventBus struct {
+	handlers    map[CampaignEventType][]func(*CampaignEvent)
+	allHandlers []func(*CampaignEvent)
+	lock        *sync.RWMutex
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers:    make(map[CampaignEventType][]func(*CampaignEvent)),
+		allHandlers: make([]func(*CampaignEvent), 0),
+		lock:        new(sync.RWMutex),
+	}
+}
+
+// Subscribe registers handler for events of the given type
+func (b *EventBus) Subscribe(t CampaignEventType, handler func(*CampaignEvent)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.handlers[t] = append(b.handlers[t], handler)
+}
+
+// SubscribeAll registers handler for events of every type
+func (b *EventBus) SubscribeAll(handler func(*CampaignEvent)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.allHandlers = append(b.allHandlers, handler)
+}
+
+func (b *EventBus) Publish(e *CampaignEvent) {
+	b.lock.RLock()
+	handlers := append(make([]func(*CampaignEvent), 0), b.handlers[e.Type]...)
+	handlers = append(handlers, b.allHandlers...)
+	b.lock.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
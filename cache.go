@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// ScheduleHash identifies the execution of a schedule: schedules with the same
+// canonical form run on the same SUT version with the same campaign config hash
+// the same
+func ScheduleHash(schedule *List[*SchedulingChoice], manifest CorpusManifest) string {
+	bs, _ := json.Marshal(struct {
+		Manifest CorpusManifest
+		Schedule *List[*SchedulingChoice]
+	}{manifest, Normalize(schedule)})
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedResult is the outcome of an executed schedule
+type cachedResult struct {
+	Iteration string
+	NewStates int
+}
+
+// resultCache remembers the executed schedules by hash, so that a schedule
+// produced again by another mutation is not re-executed
+type resultCache struct {
+	lock       *sync.Mutex
+	sutVersion string
+	results    map[string]cachedResult
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{
+		lock:       new(sync.Mutex),
+		sutVersion: sutVersion("raft"),
+		results:    make(map[string]cachedResult),
+	}
+}
+
+// key hashes schedule along with the current config of the campaign, which can
+// change at runtime
+func (c *resultCache) key(schedule *List[*SchedulingChoice], config *FuzzerConfig) string {
+	return ScheduleHash(schedule, newCorpusManifest(config, c.sutVersion))
+}
+
+func (c *resultCache) get(key string) (cachedResult, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	r, ok := c.results[key]
+	return r, ok
+}
+
+func (c *resultCache) put(key string, r cachedResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.results[key] = r
+}
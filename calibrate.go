@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ds-testing-user/etcd-fuzzing/pubsub"
+)
+
+// calibrationSamples is the number of workload round trips measured to calibrate
+const calibrationSamples = 5
+
+// roundTripper is implemented by workloads talking to an external service, whose
+// round trip time is then part of the calibration
+type roundTripper interface {
+	RoundTrip() (time.Duration, error)
+}
+
+// Calibrate measures the startup time of the raft environment and the median
+// round trip time of the workload, if it talks to an external service, and
+// derives the factor by which the campaign timeouts are scaled
+func Calibrate(config *FuzzerConfig) (pubsub.Calibration, error) {
+	start := time.Now()
+	NewRaftEnvironment(config.RaftEnvironmentConfig)
+	startup := time.Since(start)
+
+	var rtt time.Duration
+	if w, ok := config.Workload.(roundTripper); ok {
+		samples := make([]time.Duration, 0, calibrationSamples)
+		for i := 0; i < calibrationSamples; i++ {
+			d, err := w.RoundTrip()
+			if err != nil {
+				return pubsub.Calibration{}, err
+			}
+			samples = append(samples, d)
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		rtt = samples[len(samples)/2]
+	}
+	return pubsub.NewCalibration(rtt, startup), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCalibrateRedisWorkload(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			// PING is sent as *1, $4 and PING lines
+			for i := 0; i < 3; i++ {
+				if _, err := reader.ReadString('\n'); err != nil {
+					return
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			conn.Write([]byte("+PONG\r\n"))
+		}
+	}()
+
+	workload := NewRedisWorkload(listener.Addr().String(), 10, 1)
+	defer workload.Close()
+	config := &FuzzerConfig{
+		RaftEnvironmentConfig: RaftEnvironmentConfig{
+			Replicas:      3,
+			ElectionTick:  20,
+			HeartbeatTick: 2,
+			TicksPerStep:  2,
+		},
+		Workload: workload,
+	}
+	calibration, err := Calibrate(config)
+	if err != nil {
+		t.Fatalf("Failed to calibrate: %s", err)
+	}
+	if calibration.BrokerRTT < 50*time.Millisecond {
+		t.Errorf("Expected a round trip time of at least 50ms, got %s", calibration.BrokerRTT)
+	}
+	if calibration.Factor < 2.5 {
+		t.Errorf("Expected a factor of at least 2.5, got %.2f", calibration.Factor)
+	}
+	if got := calibration.Scale(redisTimeout); got <= redisTimeout {
+		t.Errorf("Expected the redis timeout to be scaled up, got %s", got)
+	}
+}
+
+func TestCalibrateWithoutWorkload(t *testing.T) {
+	config := &FuzzerConfig{
+		Steps: 10,
+		RaftEnvironmentConfig: RaftEnvironmentConfig{
+			Replicas:      3,
+			ElectionTick:  20,
+			HeartbeatTick: 2,
+			TicksPerStep:  2,
+		},
+	}
+	manifest := newCorpusManifest(config, "v1")
+	calibrated := manifest
+	calibration, err := Calibrate(config)
+	if err != nil {
+		t.Fatalf("Failed to calibrate: %s", err)
+	}
+	if calibration.BrokerRTT != 0 {
+		t.Errorf("Expected no round trip time without a workload, got %s", calibration.BrokerRTT)
+	}
+	calibrated.Calibration = &calibration
+	if _, err := manifest.Check(calibrated); err != nil {
+		t.Errorf("Expected a calibrated manifest to be compatible: %s", err)
+	}
+}
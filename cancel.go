@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sync"
+)
+
+// cancellations holds the iterations a coordinator cancelled, with the reason
+type cancellations struct {
+	lock    *sync.Mutex
+	reasons map[string]string
+}
+
+func newCancellations() *cancellations {
+	return &cancellations{
+		lock:    new(sync.Mutex),
+		reasons: make(map[string]string),
+	}
+}
+
+func (c *cancellations) add(iteration, reason string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reasons[iteration] = reason
+}
+
+func (c *cancellations) get(iteration string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	reason, ok := c.reasons[iteration]
+	return reason, ok
+}
+
+// Cancel stops the iteration at its next step, for instance when a minimized
+// schedule supersedes it. The SUT is torn down and ScheduleCancelled is
+// published instead of running the checker, so the cancellation is not mistaken
+// for a failure. Iterations that have not started yet are cancelled when they do.
+func (f *Fuzzer) Cancel(iteration, reason string) {
+	f.cancellations.add(iteration, reason)
+}
+
+func (f *Fuzzer) recordCancelStats(e *CampaignEvent) {
+	f.stats["cancelled_executions"] = f.stats["cancelled_executions"].(int) + 1
+}
+
+// teardown resets the environment of a cancelled iteration and reports it
+func (f *Fuzzer) teardown(iteration string, step int, reason string) {
+	for _, q := range f.messageQueues {
+		q.Reset()
+	}
+	f.network.Reset()
+	f.raftEnvironment.Reset(&FuzzContext{})
+	f.bus.Publish(&CampaignEvent{
+		Type:      ScheduleCancelled,
+		Iteration: iteration,
+		Step:      step,
+		Params: map[string]interface{}{
+			"reason": reason,
+		},
+	})
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// checkpointFormatVersion is bumped whenever the checkpoint layout changes
+const checkpointFormatVersion = 1
+
+// Checkpointable is implemented by the strategies and guiders whose learned state
+// can be persisted and restored
+type Checkpointable interface {
+	Checkpoint() (json.RawMessage, error)
+	Restore(json.RawMessage) error
+}
+
+// StrategyCheckpoint is the guidance learned by a campaign. It bootstraps another
+// campaign with --checkpoint-in and can be inspected offline.
+type StrategyCheckpoint struct {
+	FormatVersion int
+	Strategy      json.RawMessage `json:",omitempty"`
+	Guider        json.RawMessage `json:",omitempty"`
+	// Sidecar is the address of the policy service holding the RL policy weights,
+	// which are not part of the checkpoint
+	Sidecar string `json:",omitempty"`
+	// Pending are the mutated schedules that were not executed yet
+	Pending []*List[*SchedulingChoice]
+}
+
+// Checkpoint captures the learned state of the strategy and guider of the fuzzer
+func (f *Fuzzer) Checkpoint() (*StrategyCheckpoint, error) {
+	c := &StrategyCheckpoint{
+		FormatVersion: checkpointFormatVersion,
+		Pending:       make([]*List[*SchedulingChoice], 0, f.mutatedTracesQueue.Size()),
+	}
+	if s, ok := f.config.Strategy.(Checkpointable); ok {
+		state, err := s.Checkpoint()
+		if err != nil {
+			return nil, fmt.Errorf("error checkpointing strategy: %s", err)
+		}
+		c.Strategy = state
+	}
+	if g, ok := f.config.Guider.(Checkpointable); ok {
+		state, err := g.Checkpoint()
+		if err != nil {
+			return nil, fmt.Errorf("error checkpointing guider: %s", err)
+		}
+		c.Guider = state
+	}
+	if f.config.Sidecar != nil {
+		c.Sidecar = f.config.Sidecar.Addr
+	}
+	c.Pending = append(c.Pending, f.mutatedTracesQueue.q...)
+	return c, nil
+}
+
+// Restore loads a checkpoint into the strategy and guider of the fuzzer. The
+// pending schedules are seeded into the campaign.
+func (f *Fuzzer) Restore(c *StrategyCheckpoint) error {
+	if c.FormatVersion != checkpointFormatVersion {
+		return fmt.Errorf("unsupported checkpoint format %d, expected %d", c.FormatVersion, checkpointFormatVersion)
+	}
+	if c.Strategy != nil {
+		s, ok := f.config.Strategy.(Checkpointable)
+		if !ok {
+			return fmt.Errorf("strategy %T cannot restore a checkpoint", f.config.Strategy)
+		}
+		if err := s.Restore(c.Strategy); err != nil {
+			return fmt.Errorf("error restoring strategy: %s", err)
+		}
+	}
+	if c.Guider != nil {
+		g, ok := f.config.Guider.(Checkpointable)
+		if !ok {
+			return fmt.Errorf("guider %T cannot restore a checkpoint", f.config.Guider)
+		}
+		if err := g.Restore(c.Guider); err != nil {
+			return fmt.Errorf("error restoring guider: %s", err)
+		}
+	}
+	f.config.SeedSchedules = append(f.config.SeedSchedules, c.Pending...)
+	return nil
+}
+
+func SaveCheckpoint(filePath string, c *StrategyCheckpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return fmt.Errorf("error writing checkpoint: %s", err)
+	}
+	return nil
+}
+
+func LoadCheckpoint(filePath string) (*StrategyCheckpoint, error) {
+	c := &StrategyCheckpoint{}
+	if err := readJSON(filePath, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type roundRobinCheckpoint struct {
+	NumNodes int
+	CurNode  uint64
+}
+
+func (r *RoundRobinStrategy) Checkpoint() (json.RawMessage, error) {
+	return json.Marshal(roundRobinCheckpoint{NumNodes: r.NumNodes, CurNode: r.curNode})
+}
+
+func (r *RoundRobinStrategy) Restore(data json.RawMessage) error {
+	var c roundRobinCheckpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	if c.NumNodes != r.NumNodes {
+		return fmt.Errorf("checkpoint of %d nodes, strategy has %d", c.NumNodes, r.NumNodes)
+	}
+	r.curNode = c.CurNode
+	return nil
+}
+
+type tlcStateCheckpoint struct {
+	States      []int64
+	Traces      []string
+	StateTraces []string
+	Graph       *VisitGraph
+}
+
+func (t *TLCStateGuider) Checkpoint() (json.RawMessage, error) {
+	c := tlcStateCheckpoint{
+		States:      make([]int64, 0, len(t.statesMap)),
+		Traces:      make([]string, 0, len(t.tracesMap)),
+		StateTraces: make([]string, 0, len(t.stateTracesMap)),
+		Graph:       t.graph,
+	}
+	for s := range t.statesMap {
+		c.States = append(c.States, s)
+	}
+	for tr := range t.tracesMap {
+		c.Traces = append(c.Traces, tr)
+	}
+	for tr := range t.stateTracesMap {
+		c.StateTraces = append(c.StateTraces, tr)
+	}
+	return json.Marshal(c)
+}
+
+func (t *TLCStateGuider) Restore(data json.RawMessage) error {
+	c := tlcStateCheckpoint{Graph: NewVisitGraph()}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	for _, s := range c.States {
+		t.statesMap[s] = true
+	}
+	for _, tr := range c.Traces {
+		t.tracesMap[tr] = true
+	}
+	for _, tr := range c.StateTraces {
+		t.stateTracesMap[tr] = true
+	}
+	t.Import(c.Graph)
+	return nil
+}
+
+type traceCoverageCheckpoint struct {
+	Traces   []string
+	TLCState json.RawMessage
+}
+
+func (t *TraceCoverageGuider) Checkpoint() (json.RawMessage, error) {
+	state, err := t.TLCStateGuider.Checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	c := traceCoverageCheckpoint{Traces: make([]string, 0, len(t.traces)), TLCState: state}
+	for tr := range t.traces {
+		c.Traces = append(c.Traces, tr)
+	}
+	return json.Marshal(c)
+}
+
+func (t *TraceCoverageGuider) Restore(data json.RawMessage) error {
+	var c traceCoverageCheckpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	for _, tr := range c.Traces {
+		t.traces[tr] = true
+	}
+	return t.TLCStateGuider.Restore(c.TLCState)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// defaultClusterThreshold is the abstract-state prefix similarity above which
+// failures are considered to share a root cause
+const defaultClusterThreshold = 0.8
+
+// Failure is a violating iteration of a campaign
+type Failure struct {
+	Campaign    string
+	SUTVersion  string
+	Fingerprint string
+	Bundle      string
+	// States is the abstract state after every step of the iteration
+	States []string `json:"-"`
+}
+
+// FailureCluster groups failures of likely the same root cause: they violate the
+// same invariants and reach similar abstract states
+type FailureCluster struct {
+	Fingerprint   string
+	CheckerFailed bool     `json:",omitempty"`
+	Invariants    []string `json:",omitempty"`
+	// Representative is the bundle of the failure with the shortest trace
+	Representative string
+	Failures       []*Failure
+	SUTVersions    []string
+	Campaigns      []string
+}
+
+// LoadFailures reads the failures of the campaign summarized at summaryPath.
+// Relative bundle paths missing from the working directory are resolved
+// relative to the directory of the summary. The
+// abstract states of bundles recorded without them are obtained by re-executing
+// their schedule, see VerifyBundle.
+func LoadFailures(summaryPath string) ([]*Failure, map[string]*BugSummary, error) {
+	summary, err := LoadSummary(summaryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	failures := make([]*Failure, 0)
+	bugs := make(map[string]*BugSummary)
+	for _, bug := range summary.Bugs {
+		bugs[bug.Fingerprint] = bug
+		bundles := bug.Bundles
+		if len(bundles) == 0 && bug.Bundle != "" {
+			bundles = []string{bug.Bundle}
+		}
+		for _, b := range bundles {
+			if _, err := os.Stat(b); err != nil && !filepath.IsAbs(b) {
+				b = filepath.Join(filepath.Dir(summaryPath), b)
+			}
+			bundle, err := LoadBundle(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			states := bundle.States
+			if len(states) == 0 {
+				states = VerifyBundle(bundle, DefaultInvariants(), SerializabilityChecker()).States
+			}
+			failures = append(failures, &Failure{
+				Campaign:    summaryPath,
+				SUTVersion:  summary.SUTVersion,
+				Fingerprint: bug.Fingerprint,
+				Bundle:      b,
+				States:      states,
+			})
+		}
+	}
+	return failures, bugs, nil
+}
+
+// prefixSimilarity is the length of the common prefix of a and b relative to the
+// longest of them
+func prefixSimilarity(a, b []string) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+	common := 0
+	for common < len(a) && common < len(b) && a[common] == b[common] {
+		common++
+	}
+	return float64(common) / float64(longest)
+}
+
+// ClusterFailures groups failures sharing a fingerprint whose abstract-state
+// prefix similarity reaches threshold, transitively. Clusters are sorted by
+// decreasing size.
+func ClusterFailures(failures []*Failure, bugs map[string]*BugSummary, threshold float64) []*FailureCluster {
+	clusters := make([]*FailureCluster, 0)
+	for _, failure := range failures {
+		var joined *FailureCluster
+		for i := 0; i < len(clusters); i++ {
+			c := clusters[i]
+			if c.Fingerprint != failure.Fingerprint || !c.similar(failure, threshold) {
+				continue
+			}
+			if joined == nil {
+				c.Failures = append(c.Failures, failure)
+				joined = c
+				continue
+			}
+			// The failure links two clusters
+			joined.Failures = append(joined.Failures, c.Failures...)
+			clusters = append(clusters[:i], clusters[i+1:]...)
+			i--
+		}
+		if joined == nil {
+			c := &FailureCluster{Fingerprint: failure.Fingerprint, Failures: []*Failure{failure}}
+			if bug, ok := bugs[failure.Fingerprint]; ok {
+				c.CheckerFailed = bug.CheckerFailed
+				c.Invariants = bug.Invariants
+			}
+			clusters = append(clusters, c)
+		}
+	}
+	for _, c := range clusters {
+		c.summarize()
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].Failures) > len(clusters[j].Failures) })
+	return clusters
+}
+
+func (c *FailureCluster) similar(failure *Failure, threshold float64) bool {
+	for _, f := range c.Failures {
+		if prefixSimilarity(f.States, failure.States) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize fills in the representative, SUT versions and campaigns of the cluster
+func (c *FailureCluster) summarize() {
+	versions := make(map[string]bool)
+	campaigns := make(map[string]bool)
+	shortest := -1
+	for _, f := range c.Failures {
+		versions[f.SUTVersion] = true
+		campaigns[f.Campaign] = true
+		if shortest < 0 || len(f.States) < shortest {
+			shortest = len(f.States)
+			c.Representative = f.Bundle
+		}
+	}
+	c.SUTVersions = sortedStrings(versions)
+	c.Campaigns = sortedStrings(campaigns)
+}
+
+func sortedStrings(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func SaveClusterReport(filePath string, clusters []*FailureCluster) error {
+	data, err := json.MarshalIndent(clusters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling cluster report: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return fmt.Errorf("error writing cluster report: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/ds-testing-user/etcd-fuzzing/pubsub"
+)
+
+// corpusFormatVersion is bumped on incompatible changes to the corpus layout
+const corpusFormatVersion = 1
+
+// CorpusManifest describes the campaign a corpus was produced by
+type CorpusManifest struct {
+	FormatVersion int
+	// SUTVersion is the last commit touching the system under test
+	SUTVersion  string
+	Replicas    int
+	Steps       int
+	MaxMessages int
+	// Environment and Network the schedules were recorded with, used to re-run them
+	Environment RaftEnvironmentConfig
+	Network     NetworkConfig
+	// Calibration the campaign timeouts were scaled with, nil when not calibrated
+	Calibration *pubsub.Calibration `json:",omitempty"`
+}
+
+// Corpus holds the schedules that discovered new states in a campaign along
+// with the explored state graph, so that later campaigns can start from it
+type Corpus struct {
+	Manifest  CorpusManifest
+	Schedules []*List[*SchedulingChoice]
+	// States are the abstract states recorded at every step of the schedule of the
+	// same index, nil when not recorded
+	States [][]string
+	Graph  *VisitGraph
+}
+
+func sutVersion(sutPath string) string {
+	out, err := exec.Command("git", "log", "-1", "--format=%H", "--", sutPath).Output()
+	if err != nil || len(out) == 0 {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func NewCorpusManifest(config *FuzzerConfig) CorpusManifest {
+	return newCorpusManifest(config, sutVersion("raft"))
+}
+
+func newCorpusManifest(config *FuzzerConfig, version string) CorpusManifest {
+	return CorpusManifest{
+		FormatVersion: corpusFormatVersion,
+		SUTVersion:    version,
+		Replicas:      config.RaftEnvironmentConfig.Replicas,
+		Steps:         config.Steps,
+		MaxMessages:   config.MaxMessages,
+		Environment:   config.RaftEnvironmentConfig,
+		Network:       config.Network,
+	}
+}
+
+// Check returns an error if the corpus cannot be used by a campaign with the
+// given manifest and a list of warnings for differences that are tolerated
+func (m CorpusManifest) Check(current CorpusManifest) ([]string, error) {
+	if m.FormatVersion != current.FormatVersion {
+		return nil, fmt.Errorf("corpus format version %d is not supported, expected %d", m.FormatVersion, current.FormatVersion)
+	}
+	if m.Replicas != current.Replicas {
+		return nil, fmt.Errorf("corpus was produced with %d replicas, campaign uses %d", m.Replicas, current.Replicas)
+	}
+	warnings := make([]string, 0)
+	if m.SUTVersion != current.SUTVersion {
+		warnings = append(warnings, fmt.Sprintf("corpus was produced on SUT version %s, campaign runs %s", m.SUTVersion, current.SUTVersion))
+	}
+	if m.Steps != current.Steps {
+		warnings = append(warnings, fmt.Sprintf("corpus schedules have %d steps, campaign uses %d", m.Steps, current.Steps))
+	}
+	return warnings, nil
+}
+
+func SaveCorpus(dir string, corpus *Corpus, compress bool) error {
+	for _, d := range []string{"schedules", "states"} {
+		if err := os.MkdirAll(path.Join(dir, d), 0777); err != nil {
+			return fmt.Errorf("error creating corpus directory: %s", err)
+		}
+	}
+	files := map[string]interface{}{
+		"manifest.json": corpus.Manifest,
+		"graph.json":    corpus.Graph,
+	}
+	for i, s := range corpus.Schedules {
+		files[path.Join("schedules", fmt.Sprintf("%d.json", i))] = s
+		if i < len(corpus.States) && corpus.States[i] != nil {
+			files[path.Join("states", fmt.Sprintf("%d.json", i))] = corpus.States[i]
+		}
+	}
+	for name, v := range files {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("error marshalling %s: %s", name, err)
+		}
+		if err := writeArtifact(artifactPath(path.Join(dir, name), compress), data); err != nil {
+			return fmt.Errorf("error writing %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func LoadCorpus(dir string) (*Corpus, error) {
+	corpus := &Corpus{
+		Schedules: make([]*List[*SchedulingChoice], 0),
+		Graph:     NewVisitGraph(),
+	}
+	if err := readJSON(path.Join(dir, "manifest.json"), &corpus.Manifest); err != nil {
+		return nil, err
+	}
+	if artifactExists(path.Join(dir, "graph.json")) {
+		if err := readJSON(path.Join(dir, "graph.json"), corpus.Graph); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := os.ReadDir(path.Join(dir, "schedules"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error listing corpus schedules: %s", err)
+	}
+	for _, e := range entries {
+		schedule := NewList[*SchedulingChoice]()
+		if err := readJSON(path.Join(dir, "schedules", e.Name()), schedule); err != nil {
+			return nil, err
+		}
+		corpus.Schedules = append(corpus.Schedules, schedule)
+
+		var states []string
+		if statesPath := path.Join(dir, "states", e.Name()); artifactExists(statesPath) {
+			if err := readJSON(statesPath, &states); err != nil {
+				return nil, err
+			}
+		}
+		corpus.States = append(corpus.States, states)
+	}
+	return corpus, nil
+}
+
+func readJSON(filePath string, v interface{}) error {
+	r, err := openArtifact(filePath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %s", filePath, err)
+	}
+	defer r.Close()
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("error parsing %s: %s", filePath, err)
+	}
+	return nil
+}
+
+// Corpus returns the schedules of the campaign that discovered new states
+func (f *Fuzzer) Corpus() []*List[*SchedulingChoice] {
+	return f.corpus
+}
+
+// CorpusStates returns the abstract states recorded for the schedules of Corpus
+func (f *Fuzzer) CorpusStates() [][]string {
+	return f.corpusStates
+}
+
+// Import marks the states of a previous campaign's graph as covered, so that
+// they are not rediscovered as new
+func (t *TLCStateGuider) Import(g *VisitGraph) {
+	for key, n := range g.Nodes {
+		t.statesMap[key] = true
+		if _, ok := t.graph.Nodes[key]; !ok {
+			t.graph.Nodes[key] = n
+		}
+	}
+}
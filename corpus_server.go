@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// namespacePattern starts with an alphanumeric so that "." and ".." are rejected
+var namespacePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// CorpusServer shares schedules between independent campaigns. Schedules are
+// stored as artifacts under Dir (see storage.go), namespaced per SUT version so
+// that campaigns only exchange schedules that are meaningful for the version
+// they run.
+//
+//	GET  /corpus/<namespace>  returns the schedules of the namespace
+//	POST /corpus/<namespace>  adds a schedule, duplicates are ignored
+type CorpusServer struct {
+	Dir  string
+	lock *sync.Mutex
+}
+
+func NewCorpusServer(dir string) *CorpusServer {
+	return &CorpusServer{
+		Dir:  dir,
+		lock: new(sync.Mutex),
+	}
+}
+
+func (s *CorpusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := strings.TrimPrefix(r.URL.Path, "/corpus/")
+	if namespace == r.URL.Path {
+		http.Error(w, "invalid namespace", http.StatusNotFound)
+		return
+	}
+	dir, err := s.namespaceDir(namespace)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		schedules, err := s.list(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(schedules)
+	case http.MethodPost:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		schedule := NewList[*SchedulingChoice]()
+		if err := json.Unmarshal(data, schedule); err != nil {
+			http.Error(w, fmt.Sprintf("invalid schedule: %s", err), http.StatusBadRequest)
+			return
+		}
+		added, err := s.add(dir, schedule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"added": added})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// namespaceDir returns the directory of namespace, refusing namespaces that
+// would resolve outside of Dir
+func (s *CorpusServer) namespaceDir(namespace string) (string, error) {
+	if !namespacePattern.MatchString(namespace) {
+		return "", fmt.Errorf("invalid namespace")
+	}
+	dir := filepath.Join(s.Dir, namespace)
+	rel, err := filepath.Rel(s.Dir, dir)
+	if err != nil || rel != namespace {
+		return "", fmt.Errorf("invalid namespace")
+	}
+	return dir, nil
+}
+
+func (s *CorpusServer) list(dir string) ([]*List[*SchedulingChoice], error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	schedules := make([]*List[*SchedulingChoice], 0)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return schedules, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error listing namespace: %s", err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, e := range entries {
+		schedule := NewList[*SchedulingChoice]()
+		if err := readJSON(filepath.Join(dir, e.Name()), schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (s *CorpusServer) add(dir string, schedule *List[*SchedulingChoice]) (bool, error) {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(data)
+	filePath := filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if artifactExists(filePath) {
+		return false, nil
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return false, fmt.Errorf("error creating namespace: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return false, fmt.Errorf("error storing schedule: %s", err)
+	}
+	return true, nil
+}
+
+// CorpusClient pulls schedules from and contributes schedules to a CorpusServer
+type CorpusClient struct {
+	Addr string
+}
+
+func NewCorpusClient(addr string) *CorpusClient {
+	return &CorpusClient{
+		Addr: addr,
+	}
+}
+
+func (c *CorpusClient) url(namespace string) string {
+	return "http://" + c.Addr + "/corpus/" + url.PathEscape(namespace)
+}
+
+// Pull returns all schedules of the namespace
+func (c *CorpusClient) Pull(namespace string) ([]*List[*SchedulingChoice], error) {
+	res, err := http.Get(c.url(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to corpus server: %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("corpus server returned %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	schedules := make([]*List[*SchedulingChoice], 0)
+	if err := json.NewDecoder(res.Body).Decode(&schedules); err != nil {
+		return nil, fmt.Errorf("error parsing corpus: %s", err)
+	}
+	return schedules, nil
+}
+
+// Push contributes a schedule to the namespace and returns true if it was new
+func (c *CorpusClient) Push(namespace string, schedule *List[*SchedulingChoice]) (bool, error) {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return false, fmt.Errorf("error marshalling schedule: %s", err)
+	}
+	res, err := http.Post(c.url(namespace), "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return false, fmt.Errorf("error connecting to corpus server: %s", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return false, fmt.Errorf("corpus server returned %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	result := map[string]bool{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("error parsing corpus server response: %s", err)
+	}
+	return result["added"], nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCorpusServerNamespaces(t *testing.T) {
+	root := t.TempDir()
+	server := httptest.NewServer(NewCorpusServer(filepath.Join(root, "corpus")))
+	defer server.Close()
+	client := NewCorpusClient(strings.TrimPrefix(server.URL, "http://"))
+
+	schedule := NewList[*SchedulingChoice]()
+	schedule.Append(&SchedulingChoice{Type: Node, From: 1, To: 2, MaxMessages: 1})
+	if added, err := client.Push("v1", schedule); err != nil || !added {
+		t.Fatalf("Expected the schedule to be added, got %v, %v", added, err)
+	}
+	if added, err := client.Push("v1", schedule); err != nil || added {
+		t.Errorf("Expected the duplicate schedule to be ignored, got %v, %v", added, err)
+	}
+	schedules, err := client.Pull("v1")
+	if err != nil || len(schedules) != 1 {
+		t.Fatalf("Expected to pull 1 schedule, got %d, %v", len(schedules), err)
+	}
+
+	for _, namespace := range []string{".", "..", ".hidden", "a/b"} {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			req, _ := http.NewRequest(method, server.URL+"/corpus/"+namespace, strings.NewReader("[]"))
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to send request: %s", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusNotFound {
+				t.Errorf("Expected %s of namespace %q to be refused, got %s", method, namespace, res.Status)
+			}
+		}
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("Failed to list %s: %s", root, err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the corpus directory to be written to, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// CoverageDiff compares the coverage of two campaigns, e.g. before and after a
+// patch of the SUT. States reached by the schedules of the before campaign only
+// are the ones a fix is expected to close.
+type CoverageDiff struct {
+	BeforeStates int
+	AfterStates  int
+	// NewStates are only reached by the after campaign
+	NewStates []*VisitGraphNode
+	// LostStates are only reached by the before campaign, with the schedule that
+	// first reached them
+	LostStates []*VisitGraphNode
+	// Predicates compares whether each predicate held in either campaign, when
+	// both summaries are available
+	Predicates []PredicateDiff `json:",omitempty"`
+}
+
+// PredicateDiff tells whether a predicate held at least once in each campaign
+type PredicateDiff struct {
+	Name   string
+	Before bool
+	After  bool
+}
+
+// DiffCoverage compares the states of the visit graphs of two campaigns
+func DiffCoverage(before, after *VisitGraph) *CoverageDiff {
+	d := &CoverageDiff{
+		BeforeStates: len(before.Nodes),
+		AfterStates:  len(after.Nodes),
+		NewStates:    make([]*VisitGraphNode, 0),
+		LostStates:   make([]*VisitGraphNode, 0),
+	}
+	for _, n := range after.sortedNodes() {
+		if _, ok := before.Nodes[n.Key]; !ok {
+			d.NewStates = append(d.NewStates, n)
+		}
+	}
+	for _, n := range before.sortedNodes() {
+		if _, ok := after.Nodes[n.Key]; !ok {
+			d.LostStates = append(d.LostStates, n)
+		}
+	}
+	return d
+}
+
+// DiffPredicates adds the comparison of the predicates recorded in the summaries
+// of the two campaigns
+func (d *CoverageDiff) DiffPredicates(before, after *CampaignSummary) {
+	held := func(s *CampaignSummary) map[string]bool {
+		result := make(map[string]bool)
+		predicates, _ := s.Stats["predicates"].(map[string]interface{})
+		for name, counts := range predicates {
+			c, _ := counts.(map[string]interface{})
+			n, _ := c["true"].(float64)
+			result[name] = n > 0
+		}
+		return result
+	}
+	b, a := held(before), held(after)
+	names := make(map[string]bool)
+	for name := range b {
+		names[name] = true
+	}
+	for name := range a {
+		names[name] = true
+	}
+	d.Predicates = make([]PredicateDiff, 0, len(names))
+	for name := range names {
+		d.Predicates = append(d.Predicates, PredicateDiff{Name: name, Before: b[name], After: a[name]})
+	}
+	sort.Slice(d.Predicates, func(i, j int) bool {
+		return d.Predicates[i].Name < d.Predicates[j].Name
+	})
+}
+
+// WriteReport writes a readable report of the differences
+func (d *CoverageDiff) WriteReport(w io.Writer) {
+	fmt.Fprintf(w, "states: %d before, %d after, %d new, %d no longer reached\n", d.BeforeStates, d.AfterStates, len(d.NewStates), len(d.LostStates))
+	for _, n := range d.NewStates {
+		fmt.Fprintf(w, "+ %d %s\n", n.Key, n.State)
+	}
+	for _, n := range d.LostStates {
+		fmt.Fprintf(w, "- %d %s\n", n.Key, n.State)
+	}
+	for _, p := range d.Predicates {
+		switch {
+		case p.After && !p.Before:
+			fmt.Fprintf(w, "+ predicate %s now holds\n", p.Name)
+		case p.Before && !p.After:
+			fmt.Fprintf(w, "- predicate %s no longer holds\n", p.Name)
+		}
+	}
+}
+
+func LoadSummary(filePath string) (*CampaignSummary, error) {
+	s := &CampaignSummary{}
+	if err := readJSON(filePath, s); err != nil {
+		return nil, err
+	}
+	if s.FormatVersion != summaryFormatVersion {
+		return nil, fmt.Errorf("unsupported summary format %d, expected %d", s.FormatVersion, summaryFormatVersion)
+	}
+	return s, nil
+}
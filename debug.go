@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fuzzerStatus is the position of a running fuzzer, snapshotted at the start of
+// every step so that it can be inspected while a step hangs
+type fuzzerStatus struct {
+	lock          *sync.Mutex
+	Iteration     string
+	Step          int
+	UpdatedAt     time.Time
+	PendingFaults []string
+	forecast      *forecaster
+}
+
+func newFuzzerStatus() *fuzzerStatus {
+	return &fuzzerStatus{
+		lock:          new(sync.Mutex),
+		PendingFaults: make([]string, 0),
+	}
+}
+
+func (s *fuzzerStatus) update(iteration string, step int, pending []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Iteration = iteration
+	s.Step = step
+	s.UpdatedAt = time.Now()
+	s.PendingFaults = pending
+}
+
+// start begins forecasting a campaign of total iterations
+func (s *fuzzerStatus) start(total int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.forecast = newForecaster(total, time.Now())
+}
+
+// complete records the end of an iteration with the unique states covered so far
+func (s *fuzzerStatus) complete(uniqueStates int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.forecast != nil {
+		s.forecast.observe(time.Now(), uniqueStates)
+	}
+}
+
+func (s *fuzzerStatus) snapshot() map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	snapshot := map[string]interface{}{
+		"iteration":      s.Iteration,
+		"step":           s.Step,
+		"updated_at":     s.UpdatedAt,
+		"pending_faults": s.PendingFaults,
+	}
+	if s.forecast != nil {
+		snapshot["forecast"] = s.forecast.forecast()
+	}
+	return snapshot
+}
+
+// pendingFaults lists the faults of the iteration that have not been injected yet
+func pendingFaults(t *traceCtx, step int, triggers *triggerSet, network *Network) []string {
+	pending := make([]string, 0)
+	for s, node := range t.crashPoints {
+		if s >= step {
+			pending = append(pending, fmt.Sprintf("step %d: crash node %d", s, node))
+		}
+	}
+	for s, node := range t.startPoints {
+		if s >= step {
+			pending = append(pending, fmt.Sprintf("step %d: restart node %d", s, node))
+		}
+	}
+	for s, duration := range t.quorumLosses {
+		if s >= step {
+			pending = append(pending, fmt.Sprintf("step %d: lose quorum for %d steps", s, duration))
+		}
+	}
+	for s, nodes := range t.restores {
+		pending = append(pending, fmt.Sprintf("step %d: restore quorum, restart nodes %v", s, nodes))
+	}
+	sort.Strings(pending)
+	for i, trigger := range triggers.triggers {
+		if !triggers.fired[i] {
+			pending = append(pending, "trigger: "+trigger.Name)
+		}
+	}
+	for node, drops := range network.drops {
+		for _, t := range drops {
+			pending = append(pending, fmt.Sprintf("drop next %s of node %d", t, node))
+		}
+	}
+	return pending
+}
+
+// DebugServer exposes live introspection of a campaign over HTTP:
+//
+//	/debug/pprof/...  the standard pprof handlers, /debug/pprof/goroutine?debug=2 dumps all goroutines
+//	/debug/fuzzer     the current iteration, step, pending faults and forecast of the registered fuzzers
+//	/debug/clients    the DebugDump of every registered client
+//	/debug/cancel     POST ?fuzzer=<name>&iteration=<iteration>&reason=<reason> cancels an iteration
+//	/debug/config     GET ?fuzzer=<name> returns the tunable parameters, POST a ConfigUpdate to change them
+type DebugServer struct {
+	lock    *sync.Mutex
+	fuzzers map[string]*Fuzzer
+	dumpers map[string]func() ([]byte, error)
+}
+
+func NewDebugServer() *DebugServer {
+	return &DebugServer{
+		lock:    new(sync.Mutex),
+		fuzzers: make(map[string]*Fuzzer),
+		dumpers: make(map[string]func() ([]byte, error)),
+	}
+}
+
+func (d *DebugServer) AddFuzzer(name string, f *Fuzzer) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.fuzzers[name] = f
+}
+
+// AddClient registers a dump function, such as the DebugDump method of a pubsub client
+func (d *DebugServer) AddClient(name string, dump func() ([]byte, error)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.dumpers[name] = dump
+}
+
+func (d *DebugServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/fuzzer", d.serveFuzzers)
+	mux.HandleFunc("/debug/clients", d.serveClients)
+	mux.HandleFunc("/debug/cancel", d.serveCancel)
+	mux.HandleFunc("/debug/config", d.serveConfig)
+	return mux
+}
+
+// Start serves the debug endpoint in the background
+func (d *DebugServer) Start(addr string) {
+	go func() {
+		if err := http.ListenAndServe(addr, d.Handler()); err != nil {
+			fmt.Printf("debug server stopped: %s\n", err)
+		}
+	}()
+}
+
+func (d *DebugServer) serveFuzzers(w http.ResponseWriter, r *http.Request) {
+	d.lock.Lock()
+	status := make(map[string]interface{})
+	for name, f := range d.fuzzers {
+		status[name] = f.status.snapshot()
+	}
+	d.lock.Unlock()
+	writeJSON(w, status)
+}
+
+func (d *DebugServer) serveCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	d.lock.Lock()
+	f, ok := d.fuzzers[query.Get("fuzzer")]
+	d.lock.Unlock()
+	if !ok {
+		http.Error(w, "unknown fuzzer", http.StatusNotFound)
+		return
+	}
+	iteration := query.Get("iteration")
+	if iteration == "" {
+		http.Error(w, "missing iteration", http.StatusBadRequest)
+		return
+	}
+	f.Cancel(iteration, query.Get("reason"))
+	writeJSON(w, map[string]string{"cancelled": iteration})
+}
+
+func (d *DebugServer) serveConfig(w http.ResponseWriter, r *http.Request) {
+	d.lock.Lock()
+	f, ok := d.fuzzers[r.URL.Query().Get("fuzzer")]
+	d.lock.Unlock()
+	if !ok {
+		http.Error(w, "unknown fuzzer", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, f.currentConfig())
+	case http.MethodPost:
+		var u ConfigUpdate
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, fmt.Sprintf("invalid config update: %s", err), http.StatusBadRequest)
+			return
+		}
+		if err := f.Reconfigure(u); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, u)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (d *DebugServer) serveClients(w http.ResponseWriter, r *http.Request) {
+	d.lock.Lock()
+	dumpers := make(map[string]func() ([]byte, error))
+	for name, dump := range d.dumpers {
+		dumpers[name] = dump
+	}
+	d.lock.Unlock()
+
+	dumps := make(map[string]interface{})
+	for name, dump := range dumpers {
+		data, err := dump()
+		if err != nil {
+			dumps[name] = map[string]string{"error": err.Error()}
+			continue
+		}
+		dumps[name] = json.RawMessage(data)
+	}
+	writeJSON(w, dumps)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDictionary holds boundary and magic values known to be interesting for
+// etcd: integer limits, key range markers and internal key prefixes
+var DefaultDictionary = [][]byte{
+	[]byte(""),
+	[]byte("\x00"),
+	[]byte("\xff"),
+	[]byte("0"),
+	[]byte("-1"),
+	[]byte("2147483647"),
+	[]byte("-2147483648"),
+	[]byte("9223372036854775807"),
+	[]byte("18446744073709551615"),
+	[]byte("/"),
+	[]byte("/registry/"),
+	[]byte("health"),
+	[]byte("compact_rev_key"),
+	[]byte("finishedCompactRev"),
+	[]byte("scheduledCompactRev"),
+	[]byte("{}"),
+	[]byte("null"),
+}
+
+// LoadDictionary reads a dictionary file with one entry per line. Entries can be
+// quoted Go strings to express escapes; empty lines and lines starting with #
+// are ignored.
+func LoadDictionary(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening dictionary: %s", err)
+	}
+	defer f.Close()
+
+	dict := make([][]byte, 0)
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if strings.HasPrefix(entry, "\"") {
+			unquoted, err := strconv.Unquote(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dictionary entry on line %d: %s", line, err)
+			}
+			entry = unquoted
+		}
+		dict = append(dict, []byte(entry))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading dictionary: %s", err)
+	}
+	return dict, nil
+}
+
+// DictionaryMutator splices dictionary entries into the payloads of randomly
+// picked client requests, either inserting the entry or overwriting part of
+// the payload with it
+type DictionaryMutator struct {
+	NumPoints  int
+	Dictionary [][]byte
+	rand       *rand.Rand
+}
+
+func NewDictionaryMutator(dictionary [][]byte, numPoints int) *DictionaryMutator {
+	return &DictionaryMutator{
+		NumPoints:  numPoints,
+		Dictionary: dictionary,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+var _ Mutator = &DictionaryMutator{}
+
+func (d *DictionaryMutator) Mutate(trace *List[*SchedulingChoice], _ *List[*Event]) (*List[*SchedulingChoice], bool) {
+	if len(d.Dictionary) == 0 {
+		return nil, false
+	}
+	requestIndices := make([]int, 0)
+	for i, choice := range trace.Iter() {
+		if choice.Type == ClientRequest {
+			requestIndices = append(requestIndices, i)
+		}
+	}
+	if len(requestIndices) == 0 {
+		return nil, false
+	}
+	toMutate := make(map[int]bool)
+	for _, i := range sample(requestIndices, d.NumPoints, d.rand) {
+		toMutate[i] = true
+	}
+
+	newTrace := NewList[*SchedulingChoice]()
+	for i, choice := range trace.Iter() {
+		newChoice := choice.Copy()
+		if _, ok := toMutate[i]; ok {
+			newChoice.Payload = d.splice(choice.Payload)
+			tagMutation(newChoice, newMutationID("dictionary"))
+		}
+		newTrace.Append(newChoice)
+	}
+	return newTrace, true
+}
+
+func (d *DictionaryMutator) splice(payload []byte) []byte {
+	entry := d.Dictionary[d.rand.Intn(len(d.Dictionary))]
+	pos := d.rand.Intn(len(payload) + 1)
+	end := pos
+	if d.rand.Intn(2) == 0 {
+		// Overwrite instead of insert
+		end = pos + len(entry)
+		if end > len(payload) {
+			end = len(payload)
+		}
+	}
+	result := make([]byte, 0, len(payload)+len(entry))
+	result = append(result, payload[:pos]...)
+	result = append(result, entry...)
+	result = append(result, payload[end:]...)
+	return result
+}
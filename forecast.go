@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+)
+
+// forecastWindow is the number of recent iterations forecasts are based on
+const forecastWindow = 50
+
+// Forecast projects the rest of a campaign from its recent history
+type Forecast struct {
+	Completed        int
+	Total            int
+	SchedulesPerHour float64
+	// ETA is the remaining time until all iterations are run
+	ETA time.Duration
+	// StatesPerSchedule is the recent rate of new unique states
+	StatesPerSchedule float64
+	// ProjectedStates is the number of unique states expected at the end of the campaign
+	ProjectedStates int
+}
+
+// forecaster keeps the completion times and coverage of the recent iterations
+type forecaster struct {
+	total     int
+	completed int
+	times     []time.Time
+	states    []int
+}
+
+func newForecaster(total int, start time.Time) *forecaster {
+	return &forecaster{
+		total:  total,
+		times:  []time.Time{start},
+		states: []int{0},
+	}
+}
+
+// observe records an iteration completed at the given time with the number of
+// unique states covered so far
+func (fc *forecaster) observe(at time.Time, uniqueStates int) {
+	fc.completed++
+	fc.times = append(fc.times, at)
+	fc.states = append(fc.states, uniqueStates)
+	if len(fc.times) > forecastWindow+1 {
+		fc.times = fc.times[1:]
+		fc.states = fc.states[1:]
+	}
+}
+
+func (fc *forecaster) forecast() Forecast {
+	f := Forecast{Completed: fc.completed, Total: fc.total}
+	n := len(fc.times) - 1
+	if n < 1 {
+		return f
+	}
+	elapsed := fc.times[n].Sub(fc.times[0])
+	if elapsed > 0 {
+		f.SchedulesPerHour = float64(n) / elapsed.Hours()
+	}
+	remaining := fc.total - fc.completed
+	if remaining < 0 {
+		remaining = 0
+	}
+	f.ETA = time.Duration(float64(elapsed) / float64(n) * float64(remaining))
+	f.StatesPerSchedule = float64(fc.states[n]-fc.states[0]) / float64(n)
+	f.ProjectedStates = fc.states[n] + int(f.StatesPerSchedule*float64(remaining))
+	return f
+}
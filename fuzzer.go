@@ -3,19 +3,42 @@ package main
 import (
 	"fmt"
 	"math/rand"
-	"strconv"
+	"os"
+	"sync"
 	"time"
 
 	pb "github.com/ds-testing-user/etcd-fuzzing/raft/raftpb"
 )
 
 type Fuzzer struct {
-	messageQueues      map[string]*Queue[pb.Message]
+	messageQueues      map[string]*Queue[*inFlight]
+	network            *Network
 	nodes              []uint64
 	config             *FuzzerConfig
 	mutatedTracesQueue *Queue[*List[*SchedulingChoice]]
 	rand               *rand.Rand
 	raftEnvironment    *RaftEnvironment
+	workload           WorkloadAdapter
+	bus                *EventBus
+	predicates         *PredicateCoverage
+	invariants         *invariantChecker
+	replay             *replayState
+	corpus             []*List[*SchedulingChoice]
+	corpusHashes       map[string]bool
+	corpusStates       [][]string
+	abstractStates     []string
+	status             *fuzzerStatus
+	cancellations      *cancellations
+	reconfigurations   *reconfigurations
+	cache              *resultCache
+	lastUsage          ResourceUsage
+	totalUsage         ResourceUsage
+	executions         int
+	bugs               map[string]*BugSummary
+	bugOrder           []string
+	stop               chan struct{}
+	stopOnce           *sync.Once
+	running            *sync.WaitGroup
 
 	stats map[string]interface{}
 }
@@ -30,7 +53,16 @@ type traceCtx struct {
 	crashPoints    map[int]uint64
 	startPoints    map[int]uint64
 	clientRequests map[int]int
+	payloads       map[int][]byte
+	mutations      map[int][]string
+	replays        map[int]int
+	delivered      []*delivered
+	quorumLosses   map[int]int
+	restores       map[int][]uint64
 	rand           *rand.Rand
+	iteration      string
+	step           int
+	seenEvents     int
 
 	fuzzer *Fuzzer
 }
@@ -44,6 +76,18 @@ func (t *traceCtx) GetNextNodeChoice() (uint64, uint64, int) {
 		fromChoice = c.From
 		toChoice = c.To
 		maxMessages = c.MaxMessages
+	} else if t.fuzzer.config.Sidecar != nil {
+		events := t.eventTrace.Iter()[t.seenEvents:]
+		t.seenEvents = t.eventTrace.Size()
+		state := abstractState(t.fuzzer.raftEnvironment)
+		// Raft nodes are numbered from 1, node 0 is no choice for the policy
+		action, err := t.fuzzer.config.Sidecar.NextAction(t.iteration, t.step, state, events, t.fuzzer.nodes[1:])
+		if err != nil {
+			panic(fmt.Sprintf("error querying sidecar: %s", err))
+		}
+		fromChoice = action.From
+		toChoice = action.To
+		maxMessages = action.MaxMessages
 	} else {
 		i := t.rand.Intn(len(t.fuzzer.nodes))
 		j := t.rand.Intn(len(t.fuzzer.nodes))
@@ -57,6 +101,7 @@ func (t *traceCtx) GetNextNodeChoice() (uint64, uint64, int) {
 		To:          toChoice,
 		MaxMessages: maxMessages,
 	})
+	t.step++
 
 	return fromChoice, toChoice, maxMessages
 }
@@ -137,15 +182,19 @@ func (t *traceCtx) CanStart(step int) (uint64, bool) {
 	return node, ok
 }
 
-func (t *traceCtx) IsClientRequest(step int) (int, bool) {
+func (t *traceCtx) IsClientRequest(step int) (int, []byte, bool) {
 	req, ok := t.clientRequests[step]
+	payload := t.payloads[step]
 	if ok {
 		t.trace.Append(&SchedulingChoice{
-			Type:    ClientRequest,
-			Request: req,
+			Type:      ClientRequest,
+			Request:   req,
+			Step:      step,
+			Payload:   payload,
+			Mutations: t.mutations[step],
 		})
 	}
-	return req, ok
+	return req, payload, ok
 }
 
 type FuzzerConfig struct {
@@ -160,51 +209,165 @@ type FuzzerConfig struct {
 	SeedPopulationSize    int
 	NumberRequests        int
 	CrashQuota            int
-	MaxMessages           int
-	ReseedFrequency       int
+	// ReplayQuota is the number of earlier messages re-injected in random
+	// iterations, to exercise the handling of stale messages
+	ReplayQuota     int
+	MaxMessages     int
+	ReseedFrequency int
+	// Network configures the links between the nodes
+	Network NetworkConfig
+	// Triggers inject faults when events of the live event stream match
+	Triggers []Trigger
+	// StallSteps is the number of consecutive steps without any event after which
+	// an iteration is reported as stalled, 0 disables the watchdog
+	StallSteps int
+	// SeedSchedules are added to the seed population, e.g. instantiated scenario templates
+	SeedSchedules []*List[*SchedulingChoice]
+	// BundlePath, when set, is the directory where the schedules violating the
+	// checker are saved as reproducing bundles
+	BundlePath string
+	// CostAware gives cheaper schedules more mutations than costlier ones with the
+	// same coverage gain
+	CostAware bool
+	// CacheResults skips the schedules that were already executed with the same
+	// SUT version and config
+	CacheResults bool
+	// Compress stores the bundles gzip compressed
+	Compress bool
+	// Invariants are evaluated after every step, the async ones by a pool of
+	// InvariantWorkers fed through a queue of InvariantQueue evaluations
+	Invariants       []Invariant
+	InvariantWorkers int
+	InvariantQueue   int
+	// Predicates are evaluated after every step to track predicate coverage
+	Predicates []Predicate
+	// Payload optionally generates the payloads of client requests
+	Payload *Generator
+	// Workload drives the client requests, by default a RaftWorkload with Payload
+	Workload WorkloadAdapter
+	// Sidecar optionally delegates the node choices of random iterations to an
+	// external guidance policy
+	Sidecar *SidecarClient
 }
 
 func NewFuzzer(config *FuzzerConfig) *Fuzzer {
 	f := &Fuzzer{
 		config:             config,
 		nodes:              make([]uint64, 0),
-		messageQueues:      make(map[string]*Queue[pb.Message]),
+		messageQueues:      make(map[string]*Queue[*inFlight]),
+		network:            NewNetwork(config.Network),
 		mutatedTracesQueue: NewQueue[*List[*SchedulingChoice]](),
 		rand:               rand.New(rand.NewSource(time.Now().UnixNano())),
 		raftEnvironment:    NewRaftEnvironment(config.RaftEnvironmentConfig),
+		bus:                NewEventBus(),
+		predicates:         NewPredicateCoverage(config.Predicates),
+		invariants:         newInvariantChecker(config.Invariants, config.InvariantWorkers, config.InvariantQueue),
+		status:             newFuzzerStatus(),
+		cancellations:      newCancellations(),
+		reconfigurations:   newReconfigurations(),
+		stop:               make(chan struct{}),
+		stopOnce:           new(sync.Once),
+		running:            new(sync.WaitGroup),
+		corpusHashes:       make(map[string]bool),
+		bugs:               make(map[string]*BugSummary),
 		stats:              make(map[string]interface{}),
 	}
 	for i := 0; i <= f.config.RaftEnvironmentConfig.Replicas; i++ {
 		f.nodes = append(f.nodes, uint64(i))
 		for j := 0; j <= f.config.RaftEnvironmentConfig.Replicas; j++ {
 			key := fmt.Sprintf("%d_%d", i, j)
-			f.messageQueues[key] = NewQueue[pb.Message]()
+			f.messageQueues[key] = NewQueue[*inFlight]()
 		}
 	}
+	f.workload = config.Workload
+	if f.workload == nil {
+		f.workload = NewRaftWorkload(config.Payload)
+	}
 	f.stats["random_executions"] = 0
 	f.stats["mutated_executions"] = 0
 	f.stats["buggy_executions"] = 0
+	f.stats["payload_tainted_executions"] = 0
+	f.stats["cancelled_executions"] = 0
+	f.stats["skipped_invariant_checks"] = 0
+	f.stats["cached_executions"] = 0
+	if config.CacheResults {
+		f.cache = newResultCache()
+	}
+	f.bus.Subscribe(ScheduleStarted, f.recordScheduleStats)
+	f.bus.Subscribe(InvariantViolated, f.recordViolationStats)
+	f.bus.Subscribe(InvariantViolated, f.recordBugs)
+	f.bus.Subscribe(ScheduleStalled, f.recordStallStats)
+	f.bus.Subscribe(ScheduleCancelled, f.recordCancelStats)
+	f.bus.Subscribe(ScheduleCompleted, f.recordUsage)
+	f.bus.Subscribe(ConfigChanged, f.recordConfigChanges)
+	if config.BundlePath != "" {
+		os.MkdirAll(config.BundlePath, 0777)
+		f.bus.Subscribe(InvariantViolated, f.saveViolations)
+		f.bus.Subscribe(ScheduleStalled, f.saveStalls)
+	}
 	return f
 }
 
-func (f *Fuzzer) Schedule(from uint64, to uint64, maxMessages int) []pb.Message {
+// EventBus returns the bus on which the fuzzer publishes its lifecycle events
+func (f *Fuzzer) EventBus() *EventBus {
+	return f.bus
+}
+
+func (f *Fuzzer) recordScheduleStats(e *CampaignEvent) {
+	if mutated, _ := e.Params["mutated"].(bool); mutated {
+		f.stats["mutated_executions"] = f.stats["mutated_executions"].(int) + 1
+	} else {
+		f.stats["random_executions"] = f.stats["random_executions"].(int) + 1
+	}
+}
+
+func (f *Fuzzer) recordViolationStats(e *CampaignEvent) {
+	f.stats["buggy_executions"] = f.stats["buggy_executions"].(int) + 1
+	if tainted, _ := e.Params["payload_tainted"].(bool); tainted {
+		f.stats["payload_tainted_executions"] = f.stats["payload_tainted_executions"].(int) + 1
+	}
+	if _, ok := f.stats["first_buggy_execution"]; !ok {
+		f.stats["first_buggy_execution"] = e.Iteration
+	}
+}
+
+func (f *Fuzzer) Schedule(from uint64, to uint64, maxMessages int) []*inFlight {
 	key := fmt.Sprintf("%d_%d", from, to)
 	queue, ok := f.messageQueues[key]
 	if !ok || queue.Size() == 0 {
-		return []pb.Message{}
+		return []*inFlight{}
+	}
+	messages := make([]*inFlight, 0)
+	if f.network.Blocked(from, to) {
+		// The messages are lost on the partitioned link
+		for i := 0; i < maxMessages; i++ {
+			message, ok := queue.Peek()
+			if !ok || !f.network.Deliverable(from, to, message) {
+				break
+			}
+			queue.Pop()
+		}
+		return messages
 	}
-	messages := make([]pb.Message, 0)
 	for i := 0; i < maxMessages; i++ {
-		message, ok := queue.Pop()
-		if !ok {
+		message, ok := queue.Peek()
+		if !ok || !f.network.Deliverable(from, to, message) {
 			break
 		}
+		queue.Pop()
+		if f.network.dropped(message) {
+			continue
+		}
+		f.network.Transmit(from, to, message)
 		messages = append(messages, message)
 	}
 	return messages
 }
 
-func recordReceive(message pb.Message, eventTrace *List[*Event]) {
+// recordReceive records the delivery of message along with the annotations of
+// the scheduler and fault layers, such as the delay applied and the decision that
+// delivered it
+func recordReceive(message pb.Message, annotations map[string]interface{}, eventTrace *List[*Event]) {
 	eventTrace.Append(&Event{
 		Name: "DeliverMessage",
 		Node: message.To,
@@ -219,6 +382,8 @@ func recordReceive(message pb.Message, eventTrace *List[*Event]) {
 			"commit":   message.Commit,
 			"vote":     message.Vote,
 			"reject":   message.Reject,
+
+			"annotations": annotations,
 		},
 	})
 }
@@ -244,6 +409,9 @@ func recordSend(message pb.Message, eventTrace *List[*Event]) {
 
 func (f *Fuzzer) seed() {
 	f.mutatedTracesQueue.Reset()
+	for _, s := range f.config.SeedSchedules {
+		f.mutatedTracesQueue.Push(copyTrace(s, defaultCopyFilter()))
+	}
 	for i := 0; i < f.config.SeedPopulationSize; i++ {
 		trace, _ := f.RunIteration(fmt.Sprintf("pop_%d", i), nil)
 		f.mutatedTracesQueue.Push(copyTrace(trace, defaultCopyFilter()))
@@ -251,22 +419,73 @@ func (f *Fuzzer) seed() {
 }
 
 func (f *Fuzzer) Run() []CoverageStats {
+	f.running.Add(1)
+	defer f.running.Done()
 	coverages := make([]CoverageStats, 0)
-	for i := 0; i < f.config.Iterations; i++ {
-		if i%f.config.ReseedFrequency == 0 {
+	f.status.start(f.config.Iterations)
+	for i := 0; i < f.config.Iterations && !f.stopped(); i++ {
+		f.applyReconfigure(fmt.Sprintf("fuzz_%d", i))
+		if i == 0 || (f.config.ReseedFrequency > 0 && i%f.config.ReseedFrequency == 0) {
 			f.seed()
 		}
 		fmt.Printf("\rRunning iteration: %d/%d", i+1, f.config.Iterations)
 		var mimic *List[*SchedulingChoice] = nil
 		if f.mutatedTracesQueue.Size() > 0 {
-			f.stats["mutated_executions"] = f.stats["mutated_executions"].(int) + 1
 			mimic, _ = f.mutatedTracesQueue.Pop()
-		} else {
-			f.stats["random_executions"] = f.stats["random_executions"].(int) + 1
 		}
-		trace, eventTrace := f.RunIteration(fmt.Sprintf("fuzz_%d", i), mimic)
-		if numNewStates, _ := f.config.Guider.Check(trace, eventTrace); numNewStates > 0 {
+		iteration := fmt.Sprintf("fuzz_%d", i)
+		var cacheKey string
+		if mimic != nil && f.cache != nil {
+			cacheKey = f.cache.key(mimic, f.config)
+			if _, ok := f.cache.get(cacheKey); ok {
+				// Already executed, it cannot add coverage
+				f.stats["cached_executions"] = f.stats["cached_executions"].(int) + 1
+				coverages = append(coverages, f.config.Guider.Coverage())
+				f.status.complete(coverages[len(coverages)-1].UniqueStates)
+				continue
+			}
+		}
+		f.bus.Publish(&CampaignEvent{
+			Type:      ScheduleStarted,
+			Iteration: iteration,
+			Params: map[string]interface{}{
+				"mutated": mimic != nil,
+			},
+		})
+		trace, eventTrace := f.RunIteration(iteration, mimic)
+		if _, cancelled := f.cancellations.get(iteration); cancelled {
+			// Partial schedules are not fed to the guider
+			coverages = append(coverages, f.config.Guider.Coverage())
+			f.status.complete(coverages[len(coverages)-1].UniqueStates)
+			continue
+		}
+		numNewStates, _ := f.config.Guider.Check(trace, eventTrace)
+		if f.cache != nil {
+			// The executed schedule may differ from the mimicked one, e.g. when
+			// the sidecar filled in missing choices
+			result := cachedResult{Iteration: iteration, NewStates: numNewStates}
+			f.cache.put(f.cache.key(trace, f.config), result)
+			if cacheKey != "" {
+				f.cache.put(cacheKey, result)
+			}
+		}
+		if f.config.Sidecar != nil {
+			if err := f.config.Sidecar.Feedback(iteration, numNewStates, eventTrace); err != nil {
+				panic(fmt.Sprintf("error sending feedback to sidecar: %s", err))
+			}
+		}
+		if numNewStates > 0 {
+			// The schedule is kept as executed, so that it matches its recorded
+			// states, but is only added when its canonical form is new
+			if hash := canonicalHash(trace); !f.corpusHashes[hash] {
+				f.corpusHashes[hash] = true
+				f.corpus = append(f.corpus, copyTrace(trace, defaultCopyFilter()))
+				f.corpusStates = append(f.corpusStates, f.abstractStates)
+			}
 			numMutations := numNewStates * f.config.MutPerTrace
+			if f.config.CostAware {
+				numMutations = f.costAwareMutations(numMutations)
+			}
 			for j := 0; j < numMutations; j++ {
 				new, ok := f.config.Mutator.Mutate(trace, eventTrace)
 				if ok {
@@ -274,7 +493,13 @@ func (f *Fuzzer) Run() []CoverageStats {
 				}
 			}
 		}
-		coverages = append(coverages, f.config.Guider.Coverage())
+		coverage := f.config.Guider.Coverage()
+		coverage.PredicateValuations = f.predicates.Valuations()
+		coverages = append(coverages, coverage)
+		f.status.complete(coverage.UniqueStates)
+	}
+	if len(f.config.Predicates) > 0 {
+		f.stats["predicates"] = f.predicates.Stats()
 	}
 	return coverages
 }
@@ -290,7 +515,13 @@ func (f *Fuzzer) RunIteration(iteration string, mimic *List[*SchedulingChoice])
 		crashPoints:    make(map[int]uint64),
 		startPoints:    make(map[int]uint64),
 		clientRequests: make(map[int]int),
+		payloads:       make(map[int][]byte),
+		mutations:      make(map[int][]string),
+		replays:        make(map[int]int),
+		quorumLosses:   make(map[int]int),
+		restores:       make(map[int][]uint64),
 		rand:           f.rand,
+		iteration:      iteration,
 		fuzzer:         f,
 	}
 	if mimic != nil {
@@ -310,10 +541,18 @@ func (f *Fuzzer) RunIteration(iteration string, mimic *List[*SchedulingChoice])
 				tCtx.crashPoints[ch.Step] = ch.Node
 			case ClientRequest:
 				tCtx.clientRequests[ch.Step] = ch.Request
+				tCtx.payloads[ch.Step] = ch.Payload
+				if len(ch.Mutations) > 0 {
+					tCtx.mutations[ch.Step] = ch.Mutations
+				}
+			case ReplayMessage:
+				tCtx.replays[ch.Step] = ch.IntegerChoice
+			case LoseQuorum:
+				tCtx.quorumLosses[ch.Step] = ch.IntegerChoice
 			}
 		}
 	} else {
-		for i := 0; i < f.config.Steps; i++ {
+		for i := 0; i < f.config.Steps && f.config.Sidecar == nil; i++ {
 			var fromIdx int = 0
 			for fromIdx == 0 {
 				fromIdx = f.rand.Intn(len(f.nodes))
@@ -345,65 +584,166 @@ func (f *Fuzzer) RunIteration(iteration string, mimic *List[*SchedulingChoice])
 		i := 1
 		for _, req := range sample(choices, f.config.NumberRequests, f.rand) {
 			tCtx.clientRequests[req] = i
+			if payload := f.workload.Generate(i); payload != nil {
+				tCtx.payloads[req] = payload
+			}
 			i++
 		}
+		for _, c := range sample(choices, f.config.ReplayQuota, f.rand) {
+			// Resolved against the messages delivered by then
+			tCtx.replays[c] = f.rand.Intn(f.config.Steps * f.config.MaxMessages)
+		}
 	}
 
 	// Reset the queues and environment
 	for _, q := range f.messageQueues {
 		q.Reset()
 	}
+	f.network.Reset()
 	f.raftEnvironment.Reset(&FuzzContext{traceCtx: tCtx})
 
 	crashed := make(map[uint64]bool)
 	fCtx := &FuzzContext{traceCtx: tCtx}
+	history := make([]*HistoryEntry, 0)
+	triggers := newTriggerSet(f.config.Triggers)
+	watchdog := newStallWatchdog(f.config.StallSteps)
+	meter := newUsageMeter()
+	f.abstractStates = make([]string, 0, f.config.Steps)
+	f.invariants.begin()
 	for j := 0; j < f.config.Steps; j++ {
+		if reason, ok := f.cancellations.get(iteration); ok {
+			f.invariants.drain()
+			f.teardown(iteration, j, reason)
+			return tCtx.trace, tCtx.eventTrace
+		}
+		f.network.Advance(j)
+		f.status.update(iteration, j, pendingFaults(tCtx, j, triggers, f.network))
 		if toCrash, ok := tCtx.CanCrash(j); ok {
 			f.raftEnvironment.Stop(fCtx, toCrash)
 			crashed[toCrash] = true
+			f.publishFault(iteration, j, toCrash, "crash")
 		}
 		if toStart, ok := tCtx.CanStart(j); ok {
 			_, isCrashed := crashed[toStart]
 			if isCrashed {
 				f.raftEnvironment.Start(fCtx, toStart)
 				delete(crashed, toStart)
+				f.publishFault(iteration, j, toStart, "restart")
 			}
 		}
+		f.restoreQuorum(fCtx, iteration, j, crashed)
+		if duration, ok := tCtx.IsQuorumLoss(j); ok {
+			f.loseQuorum(fCtx, iteration, j, duration, crashed)
+		}
 		from, to, maxMessages := tCtx.GetNextNodeChoice()
 		if _, ok := crashed[to]; !ok {
-			messages := f.Schedule(from, to, maxMessages)
-			for _, m := range messages {
-				recordReceive(m, tCtx.eventTrace)
-				f.raftEnvironment.Step(fCtx, m)
+			for _, m := range f.Schedule(from, to, maxMessages) {
+				meter.usage.MessagesDelivered++
+				recordReceive(m.message, m.annotations(j), tCtx.eventTrace)
+				f.raftEnvironment.Step(fCtx, m.message)
+				tCtx.delivered = append(tCtx.delivered, &delivered{message: m.message, sentAt: m.sentAt, deliveredAt: j})
 			}
 		}
-
-		if reqNum, ok := tCtx.IsClientRequest(j); ok {
-			req := pb.Message{
-				Type: pb.MsgProp,
-				From: uint64(0),
-				Entries: []pb.Entry{
-					{Data: []byte(strconv.Itoa(reqNum))},
-				},
+		if d, ok := tCtx.IsReplay(j); ok {
+			if _, isCrashed := crashed[d.message.To]; !isCrashed {
+				meter.usage.MessagesDelivered++
+				recordReceive(d.message, d.replayAnnotations(j), tCtx.eventTrace)
+				f.raftEnvironment.Step(fCtx, d.message)
+				f.publishFault(iteration, j, d.message.To, "replay")
 			}
-			f.raftEnvironment.Step(fCtx, req)
+		}
+
+		if reqNum, payload, ok := tCtx.IsClientRequest(j); ok {
+			op := &Operation{Request: reqNum, Step: j, Payload: payload}
+			history = append(history, &HistoryEntry{
+				Operation: *op,
+				Outcome:   f.workload.Execute(fCtx, f.raftEnvironment, op),
+			})
 		}
 
 		for _, n := range f.raftEnvironment.Tick(fCtx) {
+			meter.usage.MessagesSent++
 			recordSend(n, tCtx.eventTrace)
 			key := fmt.Sprintf("%d_%d", n.From, n.To)
-			f.messageQueues[key].Push(n)
+			f.messageQueues[key].Push(&inFlight{message: n, sentAt: j})
 		}
-	}
-	if f.config.Checker != nil && !f.config.Checker(f.raftEnvironment) {
-		f.stats["buggy_executions"] = f.stats["buggy_executions"].(int) + 1
-		if _, ok := f.stats["first_buggy_execution"]; !ok {
-			f.stats["first_buggy_execution"] = iteration
+		f.predicates.Observe(f.raftEnvironment)
+		f.invariants.observe(j, f.raftEnvironment)
+		f.abstractStates = append(f.abstractStates, abstractState(f.raftEnvironment))
+		meter.sample()
+		for _, name := range triggers.Evaluate(tCtx.eventTrace, f.network) {
+			f.bus.Publish(&CampaignEvent{
+				Type:      FaultInjected,
+				Iteration: iteration,
+				Step:      j,
+				Params: map[string]interface{}{
+					"fault":   "trigger",
+					"trigger": name,
+				},
+			})
+		}
+		if watchdog.Observe(tCtx.eventTrace) {
+			f.bus.Publish(&CampaignEvent{
+				Type:      ScheduleStalled,
+				Iteration: iteration,
+				Step:      j,
+				Params: map[string]interface{}{
+					"kind":       f.classifyStall(crashed),
+					"idle_steps": f.config.StallSteps,
+					"schedule":   tCtx.trace,
+					"events":     tCtx.eventTrace,
+				},
+			})
+		}
+		if f.replay != nil && !f.replay.check(j, tCtx.eventTrace) {
+			break
 		}
 	}
+	violations, skipped := f.invariants.drain()
+	f.stats["skipped_invariant_checks"] = f.stats["skipped_invariant_checks"].(int) + skipped
+	checkerFailed := f.config.Checker != nil && !f.config.Checker(f.raftEnvironment)
+	if checkerFailed || len(violations) > 0 {
+		mutations := taintedMutations(f.raftEnvironment, tCtx)
+		f.bus.Publish(&CampaignEvent{
+			Type:      InvariantViolated,
+			Iteration: iteration,
+			Step:      f.config.Steps,
+			Params: map[string]interface{}{
+				"mutations":        mutations,
+				"payload_tainted":  len(mutations) > 0,
+				"schedule_mutated": mimic != nil,
+				"schedule":         tCtx.trace,
+				"events":           tCtx.eventTrace,
+				"checker_failed":   checkerFailed,
+				"violations":       violations,
+				"states":           append([]string(nil), f.abstractStates...),
+			},
+		})
+	}
+	f.workload.Record(iteration, history)
+	f.bus.Publish(&CampaignEvent{
+		Type:      ScheduleCompleted,
+		Iteration: iteration,
+		Step:      f.config.Steps,
+		Params: map[string]interface{}{
+			"usage": meter.stop(),
+		},
+	})
 	return tCtx.trace, tCtx.eventTrace
 }
 
+func (f *Fuzzer) publishFault(iteration string, step int, node uint64, fault string) {
+	f.bus.Publish(&CampaignEvent{
+		Type:      FaultInjected,
+		Iteration: iteration,
+		Step:      step,
+		Node:      node,
+		Params: map[string]interface{}{
+			"fault": fault,
+		},
+	})
+}
+
 type Mutator interface {
 	Mutate(*List[*SchedulingChoice], *List[*Event]) (*List[*SchedulingChoice], bool)
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy decides which artifacts of past campaigns are kept. The
+// artifacts root holds one directory per campaign, with the traces, bundles
+// and corpus directories the fuzz command writes.
+type RetentionPolicy struct {
+	// KeepCampaigns is the number of most recent campaigns kept in full, 0 keeps all
+	KeepCampaigns int
+	// KeepFailing keeps the bundles of failing schedules of every campaign
+	KeepFailing bool
+	// ExpirePassing removes the recorded traces older than the duration, 0 never expires them
+	ExpirePassing time.Duration
+}
+
+// GCReport lists what a garbage collection removed
+type GCReport struct {
+	Removed []string
+	Freed   int64
+}
+
+// CollectGarbage enforces policy over the campaigns in root. With dryRun the
+// report is computed without removing anything.
+func CollectGarbage(root string, policy RetentionPolicy, now time.Time, dryRun bool) (*GCReport, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("error listing campaigns: %s", err)
+	}
+	campaigns := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("error reading campaign %s: %s", e.Name(), err)
+		}
+		campaigns = append(campaigns, info)
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].ModTime().After(campaigns[j].ModTime())
+	})
+
+	report := &GCReport{Removed: make([]string, 0)}
+	remove := func(p string) error {
+		size := diskUsage(p)
+		if !dryRun {
+			if err := os.RemoveAll(p); err != nil {
+				return fmt.Errorf("error removing %s: %s", p, err)
+			}
+		}
+		report.Removed = append(report.Removed, p)
+		report.Freed += size
+		return nil
+	}
+
+	for i, c := range campaigns {
+		dir := filepath.Join(root, c.Name())
+		if policy.KeepCampaigns > 0 && i >= policy.KeepCampaigns {
+			if !policy.KeepFailing {
+				if err := remove(dir); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			// Only the failing bundles survive an expired campaign
+			contents, err := os.ReadDir(dir)
+			if err != nil {
+				return nil, fmt.Errorf("error listing campaign %s: %s", c.Name(), err)
+			}
+			for _, e := range contents {
+				if e.Name() == "bundles" {
+					continue
+				}
+				if err := remove(filepath.Join(dir, e.Name())); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if policy.ExpirePassing <= 0 {
+			continue
+		}
+		traces, err := os.ReadDir(filepath.Join(dir, "traces"))
+		if err != nil {
+			continue
+		}
+		for _, t := range traces {
+			info, err := t.Info()
+			if err != nil || now.Sub(info.ModTime()) < policy.ExpirePassing {
+				continue
+			}
+			if err := remove(filepath.Join(dir, "traces", t.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// diskUsage returns the total size of the files under p
+func diskUsage(p string) int64 {
+	var size int64
+	filepath.Walk(p, func(_ string, info fs.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
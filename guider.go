@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"strconv"
@@ -18,6 +19,9 @@ type CoverageStats struct {
 	UniqueStates      int
 	UniqueTraces      int
 	UniqueStateTraces int
+	// PredicateValuations is the number of distinct valuations of the
+	// registered predicates, filled in by the fuzzer
+	PredicateValuations int `json:",omitempty"`
 }
 
 type Guider interface {
@@ -27,11 +31,16 @@ type Guider interface {
 }
 
 type TLCStateGuider struct {
-	TLCAddr        string
+	TLCAddr string
+	// CompressTraces stores the recorded traces gzip compressed
+	CompressTraces bool
+
 	statesMap      map[int64]bool
 	tracesMap      map[string]bool
 	stateTracesMap map[string]bool
 	tlcClient      *TLCClient
+	graph          *VisitGraph
+	lastStates     []State
 	recordPath     string
 	recordTraces   bool
 	count          int
@@ -52,6 +61,7 @@ func NewTLCStateGuider(tlcAddr, recordPath string, recordTraces bool) *TLCStateG
 		tracesMap:      make(map[string]bool),
 		stateTracesMap: make(map[string]bool),
 		tlcClient:      NewTLCClient(tlcAddr),
+		graph:          NewVisitGraph(),
 		recordPath:     recordPath,
 		recordTraces:   recordTraces,
 		count:          0,
@@ -62,7 +72,16 @@ func (t *TLCStateGuider) Reset(key string) {
 	t.statesMap = make(map[int64]bool)
 	t.tracesMap = make(map[string]bool)
 	t.stateTracesMap = make(map[string]bool)
+	if t.recordTraces && t.recordPath != "" && !t.graph.IsEmpty() {
+		t.graph.Export(t.recordPath, key, 1)
+	}
+	t.graph = NewVisitGraph()
+	t.lastStates = nil
+}
 
+// Graph returns the abstract state graph explored since the last reset
+func (t *TLCStateGuider) Graph() *VisitGraph {
+	return t.graph
 }
 
 func (t *TLCStateGuider) Coverage() CoverageStats {
@@ -86,6 +105,8 @@ func (t *TLCStateGuider) Check(trace *List[*SchedulingChoice], eventTrace *List[
 	numNewStates := 0
 	if tlcStates, err := t.tlcClient.SendTrace(eventTrace); err == nil {
 		t.recordTrace(trace, eventTrace, tlcStates)
+		t.graph.Update(tlcStates, trace)
+		t.lastStates = tlcStates
 		for _, s := range tlcStates {
 			_, ok := t.statesMap[s.Key]
 			if !ok {
@@ -121,7 +142,7 @@ func (t *TLCStateGuider) recordTrace(trace *List[*SchedulingChoice], eventTrace
 	if err != nil {
 		return
 	}
-	file, err := os.Create(filePath)
+	file, err := createArtifact(artifactPath(filePath, t.CompressTraces))
 	if err != nil {
 		return
 	}
@@ -187,6 +208,42 @@ func (t *TraceCoverageGuider) Reset(key string) {
 	t.TLCStateGuider.Reset(key)
 }
 
+// FrontierGuider biases the search toward rarely visited states of the state graph.
+// On top of the new states, every distinct state of a trace contributes a
+// count-based exploration bonus of Bonus/sqrt(visits), so that schedules reaching
+// the frontier of the explored graph are mutated more.
+type FrontierGuider struct {
+	Bonus float64
+	*TLCStateGuider
+}
+
+var _ Guider = &FrontierGuider{}
+
+func NewFrontierGuider(tlcAddr, recordPath string, recordTraces bool, bonus float64) *FrontierGuider {
+	return &FrontierGuider{
+		Bonus:          bonus,
+		TLCStateGuider: NewTLCStateGuider(tlcAddr, recordPath, recordTraces),
+	}
+}
+
+func (f *FrontierGuider) Check(trace *List[*SchedulingChoice], events *List[*Event]) (int, float64) {
+	numNewStates, _ := f.TLCStateGuider.Check(trace, events)
+
+	bonus := 0.0
+	seen := make(map[int64]bool)
+	for _, s := range f.lastStates {
+		if _, ok := seen[s.Key]; ok {
+			continue
+		}
+		seen[s.Key] = true
+		if n, ok := f.graph.Nodes[s.Key]; ok && n.Visits > 0 {
+			bonus += f.Bonus / math.Sqrt(float64(n.Visits))
+		}
+	}
+	score := numNewStates + int(bonus)
+	return score, float64(score) / float64(max(len(f.statesMap), 1))
+}
+
 type eventTrace struct {
 	Nodes map[string]*eventNode
 }
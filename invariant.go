@@ -0,0 +1,173 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/ds-testing-user/etcd-fuzzing/raft"
+)
+
+const (
+	defaultInvariantWorkers = 2
+	defaultInvariantQueue   = 32
+)
+
+// Invariant is a named safety property evaluated after every step of an iteration
+type Invariant struct {
+	Name  string
+	Check func(*RaftEnvironment) bool
+	// Async evaluates the invariant in the background on a snapshot of the
+	// environment, so expensive checks such as linearizability windows do not
+	// delay message delivery and distort the schedule timing they check
+	Async bool
+}
+
+// InvariantViolation is a step at which an invariant did not hold
+type InvariantViolation struct {
+	Invariant string
+	Step      int
+	Async     bool
+}
+
+type invariantJob struct {
+	invariant Invariant
+	step      int
+	env       *RaftEnvironment
+}
+
+// invariantChecker evaluates the live invariants of an iteration. Async
+// invariants go through a bounded queue served by a worker pool; when the queue
+// is full the evaluation is skipped rather than blocking the schedule.
+type invariantChecker struct {
+	invariants []Invariant
+	async      bool
+	workers    int
+	queueSize  int
+
+	jobs       chan invariantJob
+	wg         *sync.WaitGroup
+	lock       *sync.Mutex
+	violations []InvariantViolation
+	skipped    int
+}
+
+func newInvariantChecker(invariants []Invariant, workers, queueSize int) *invariantChecker {
+	if workers <= 0 {
+		workers = defaultInvariantWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultInvariantQueue
+	}
+	c := &invariantChecker{
+		invariants: invariants,
+		workers:    workers,
+		queueSize:  queueSize,
+		wg:         new(sync.WaitGroup),
+		lock:       new(sync.Mutex),
+	}
+	for _, inv := range invariants {
+		if inv.Async {
+			c.async = true
+		}
+	}
+	return c
+}
+
+// begin starts the workers of an iteration
+func (c *invariantChecker) begin() {
+	c.violations = make([]InvariantViolation, 0)
+	c.skipped = 0
+	if !c.async {
+		return
+	}
+	c.jobs = make(chan invariantJob, c.queueSize)
+	for i := 0; i < c.workers; i++ {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			for job := range c.jobs {
+				if !job.invariant.Check(job.env) {
+					c.violated(job.invariant, job.step)
+				}
+			}
+		}()
+	}
+}
+
+func (c *invariantChecker) violated(inv Invariant, step int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.violations = append(c.violations, InvariantViolation{Invariant: inv.Name, Step: step, Async: inv.Async})
+}
+
+// observe evaluates the synchronous invariants and queues the async ones on a
+// snapshot of the environment after step
+func (c *invariantChecker) observe(step int, env *RaftEnvironment) {
+	var snapshot *RaftEnvironment
+	for _, inv := range c.invariants {
+		if !inv.Async {
+			if !inv.Check(env) {
+				c.violated(inv, step)
+			}
+			continue
+		}
+		if snapshot == nil {
+			snapshot = env.snapshot()
+		}
+		select {
+		case c.jobs <- invariantJob{invariant: inv, step: step, env: snapshot}:
+		default:
+			c.skipped++
+		}
+	}
+}
+
+// drain waits for the queued evaluations and returns the violations of the
+// iteration ordered by step, along with the number of skipped evaluations
+func (c *invariantChecker) drain() ([]InvariantViolation, int) {
+	if c.async {
+		close(c.jobs)
+		c.wg.Wait()
+	}
+	sort.SliceStable(c.violations, func(i, j int) bool {
+		return c.violations[i].Step < c.violations[j].Step
+	})
+	return c.violations, c.skipped
+}
+
+// snapshot copies the states and logs of the nodes. The copy cannot be stepped,
+// it is only meant for evaluating invariants.
+func (r *RaftEnvironment) snapshot() *RaftEnvironment {
+	s := &RaftEnvironment{
+		config:    r.config,
+		nodes:     make(map[uint64]*raft.RawNode),
+		storages:  make(map[uint64]*raft.MemoryStorage),
+		curStates: make(map[uint64]raft.Status),
+	}
+	for id, status := range r.curStates {
+		s.curStates[id] = status
+	}
+	for id, storage := range r.storages {
+		s.storages[id] = copyStorage(storage)
+	}
+	return s
+}
+
+func copyStorage(storage *raft.MemoryStorage) *raft.MemoryStorage {
+	c := raft.NewMemoryStorage()
+	if snap, err := storage.Snapshot(); err == nil && snap.Metadata.Index > 0 {
+		c.ApplySnapshot(snap)
+	}
+	if hs, _, err := storage.InitialState(); err == nil {
+		c.SetHardState(hs)
+	}
+	first, _ := storage.FirstIndex()
+	last, _ := storage.LastIndex()
+	if last >= first {
+		if entries, err := storage.Entries(first, last+1, math.MaxUint64); err == nil {
+			c.Append(entries)
+		}
+	}
+	return c
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShutdownPhase orders the teardown of the harness. Phases run in increasing
+// order, so that nothing is torn down while a component that depends on it is
+// still running.
+type ShutdownPhase int
+
+const (
+	// PhaseStopFaults stops injecting faults
+	PhaseStopFaults ShutdownPhase = iota
+	// PhaseDrainScheduler lets the running iteration complete and stops scheduling new ones
+	PhaseDrainScheduler
+	// PhaseStopClients closes the clients of the SUT, brokers and plugins
+	PhaseStopClients
+	// PhaseFlushRecorder flushes recorded traces and artifacts
+	PhaseFlushRecorder
+	// PhaseCloseStorage closes the storage artifacts are written to
+	PhaseCloseStorage
+)
+
+// allPhases runs every phase, including user-defined ones after PhaseCloseStorage
+const allPhases = ShutdownPhase(1<<31 - 1)
+
+var shutdownPhaseNames = []string{"stop faults", "drain scheduler", "stop clients", "flush recorder", "close storage"}
+
+func (p ShutdownPhase) String() string {
+	if int(p) < len(shutdownPhaseNames) {
+		return shutdownPhaseNames[p]
+	}
+	return fmt.Sprintf("phase %d", int(p))
+}
+
+// DefaultShutdownTimeout bounds each shutdown hook
+const DefaultShutdownTimeout = 30 * time.Second
+
+type shutdownHook struct {
+	name  string
+	phase ShutdownPhase
+	stop  func(ctx context.Context) error
+	done  bool
+}
+
+type integrityCheck struct {
+	name  string
+	check func() error
+}
+
+// Lifecycle tears down the components of the harness in dependency order, see
+// ShutdownPhase. Within a phase, hooks run in reverse registration order like
+// deferred calls. Once every hook ran, the integrity checks verify the written
+// artifacts.
+type Lifecycle struct {
+	lock    *sync.Mutex
+	timeout time.Duration
+	hooks   []*shutdownHook
+	checks  []integrityCheck
+	errors  []error
+}
+
+func NewLifecycle(timeout time.Duration) *Lifecycle {
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	return &Lifecycle{
+		lock:    new(sync.Mutex),
+		timeout: timeout,
+		hooks:   make([]*shutdownHook, 0),
+		checks:  make([]integrityCheck, 0),
+		errors:  make([]error, 0),
+	}
+}
+
+// Register adds a hook stopping a component in the given phase. The context of
+// stop is done once the shutdown timeout elapses.
+func (l *Lifecycle) Register(name string, phase ShutdownPhase, stop func(ctx context.Context) error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.hooks = append(l.hooks, &shutdownHook{name: name, phase: phase, stop: stop})
+}
+
+// SetTimeout changes the time each hook is given to stop, e.g. once the timeouts
+// are calibrated to the environment
+func (l *Lifecycle) SetTimeout(timeout time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.timeout = timeout
+}
+
+// Check adds an integrity check run after the last phase, e.g. that an artifact
+// can be read back
+func (l *Lifecycle) Check(name string, check func() error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.checks = append(l.checks, integrityCheck{name: name, check: check})
+}
+
+// runUntil runs the hooks of the phases up to last that did not run yet
+func (l *Lifecycle) runUntil(last ShutdownPhase) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	hooks := make([]*shutdownHook, len(l.hooks))
+	for i, h := range l.hooks {
+		hooks[len(hooks)-1-i] = h
+	}
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].phase < hooks[j].phase })
+	for _, h := range hooks {
+		if h.phase > last {
+			break
+		}
+		if h.done {
+			continue
+		}
+		h.done = true
+		if err := l.stop(h); err != nil {
+			l.errors = append(l.errors, fmt.Errorf("%s: %s: %s", h.phase, h.name, err))
+		}
+	}
+}
+
+// stop runs a hook, giving up on it after the shutdown timeout
+func (l *Lifecycle) stop(h *shutdownHook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- h.stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", l.timeout)
+	}
+}
+
+// Shutdown runs the hooks of every phase that did not run yet, then the integrity
+// checks, and returns the errors of both
+func (l *Lifecycle) Shutdown() error {
+	l.runUntil(allPhases)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, c := range l.checks {
+		if err := c.check(); err != nil {
+			l.errors = append(l.errors, fmt.Errorf("integrity check %s: %s", c.name, err))
+		}
+	}
+	l.checks = l.checks[:0]
+	if len(l.errors) == 0 {
+		return nil
+	}
+	messages := make([]string, len(l.errors))
+	for i, err := range l.errors {
+		messages[i] = err.Error()
+	}
+	l.errors = l.errors[:0]
+	return fmt.Errorf("shutdown failed: %s", strings.Join(messages, "; "))
+}
+
+// DrainOnSignal starts the teardown up to PhaseDrainScheduler on the first of the
+// signals, so that the running iteration completes and the caller can save its
+// results before calling Shutdown. A second signal terminates the process. The
+// returned function stops listening.
+func (l *Lifecycle) DrainOnSignal(signals ...os.Signal) func() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-ch:
+			fmt.Printf("\nReceived %s, draining\n", sig)
+			signal.Stop(ch)
+			l.runUntil(PhaseDrainScheduler)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Stop makes Run return once the running iteration completes
+func (f *Fuzzer) Stop() {
+	f.stopOnce.Do(func() { close(f.stop) })
+}
+
+func (f *Fuzzer) stopped() bool {
+	select {
+	case <-f.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Drain stops the fuzzer and waits for Run to return, it is the
+// PhaseDrainScheduler hook of a campaign
+func (f *Fuzzer) Drain(ctx context.Context) error {
+	f.Stop()
+	done := make(chan struct{})
+	go func() {
+		f.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("iteration still running")
+	}
+}
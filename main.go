@@ -1,7 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -27,22 +36,213 @@ func main() {
 	rootCommand.PersistentFlags().BoolVar(&recordTraces, "record-traces", false, "Record the traces explored")
 	rootCommand.AddCommand(FuzzCommand())
 	rootCommand.AddCommand(OneCommand())
+	rootCommand.AddCommand(ReplayCommand())
+	rootCommand.AddCommand(BisectCommand())
+	rootCommand.AddCommand(RegressCommand())
+	rootCommand.AddCommand(CorpusServerCommand())
+	rootCommand.AddCommand(GCCommand())
+	rootCommand.AddCommand(StatusCommand())
+	rootCommand.AddCommand(CheckpointCommand())
+	rootCommand.AddCommand(DeterminismCommand())
+	rootCommand.AddCommand(ScheduleEditCommand())
+	rootCommand.AddCommand(CoverageDiffCommand())
+	rootCommand.AddCommand(VerifyCommand())
+	rootCommand.AddCommand(ClusterCommand())
 
 	if err := rootCommand.Execute(); err != nil {
 		fmt.Println(err)
+		if errors.Is(err, errCheckerViolated) {
+			os.Exit(exitCheckerViolated)
+		}
+		os.Exit(1)
 	}
 }
 
 func FuzzCommand() *cobra.Command {
-	return &cobra.Command{
+	var mutatorPlugin string
+	var checkerPlugin string
+	var sidecarAddr string
+	var redisAddr string
+	var workloadHistory string
+	var payloadSpec string
+	var payloadSeed int64
+	var dictionaryPath string
+	var scenarios []string
+	var scenarioTarget uint64
+	var topologyPath string
+	var bandwidth int
+	var partitions []string
+	var triggerRules []string
+	var seedCorpus string
+	var corpusOut string
+	var compress bool
+	var costAware bool
+	var replays int
+	var cacheResults bool
+	var checkpointIn string
+	var checkpointOut string
+	var corpusServer string
+	var debugAddr string
+	var summaryPath string
+	var shutdownTimeout time.Duration
+	var presetName string
+	var crashQuota int
+	var reseedFrequency int
+	var calibrate bool
+	cmd := &cobra.Command{
 		Use: "fuzz",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			fuzzer := NewFuzzer(&FuzzerConfig{
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			lifecycle := NewLifecycle(shutdownTimeout)
+			defer func() {
+				if shutdownErr := lifecycle.Shutdown(); shutdownErr != nil && err == nil {
+					err = shutdownErr
+				}
+			}()
+			var strategy Strategy = NewRandomStrategy()
+			var invariants []Invariant
+			if presetName != "" {
+				preset, err := Preset(presetName)
+				if err != nil {
+					return err
+				}
+				unset := func(flag string) bool { return !cmd.Flag(flag).Changed }
+				if unset("episodes") {
+					episodes = preset.Episodes
+				}
+				if unset("horizon") {
+					horizon = preset.Horizon
+				}
+				if unset("replicas") {
+					replicas = preset.Replicas
+				}
+				if unset("requests") {
+					requests = preset.Requests
+				}
+				if unset("crash-quota") {
+					crashQuota = preset.CrashQuota
+				}
+				if unset("reseed-frequency") {
+					reseedFrequency = preset.ReseedFrequency
+				}
+				if unset("replays") {
+					replays = preset.Replays
+				}
+				if unset("scenario") {
+					scenarios = preset.Scenarios
+				}
+				if unset("partition") {
+					partitions = preset.Partitions
+				}
+				if unset("trigger") {
+					triggerRules = preset.Triggers
+				}
+				if strategy, err = preset.NewStrategy(replicas); err != nil {
+					return err
+				}
+				if preset.Invariants {
+					invariants = DefaultInvariants()
+				}
+			}
+			var generator *Generator
+			if payloadSpec != "" {
+				spec, err := LoadPayloadSpec(payloadSpec)
+				if err != nil {
+					return err
+				}
+				generator = NewGenerator(spec, payloadSeed)
+			}
+			payloadMutators := make([]Mutator, 0)
+			if generator != nil {
+				payloadMutators = append(payloadMutators, NewPayloadMutator(generator, 1))
+			}
+			if dictionaryPath != "" {
+				dictionary, err := LoadDictionary(dictionaryPath)
+				if err != nil {
+					return err
+				}
+				payloadMutators = append(payloadMutators, NewDictionaryMutator(append(dictionary, DefaultDictionary...), 1))
+			}
+			if replays > 0 {
+				payloadMutators = append(payloadMutators, NewReplayMessageMutator(1))
+			}
+			var mutator Mutator = &EmptyMutator{}
+			if len(payloadMutators) > 0 {
+				mutator = CombineMutators(payloadMutators...)
+			}
+			if mutatorPlugin != "" {
+				plugin, err := NewPlugin(mutatorPlugin)
+				if err != nil {
+					return err
+				}
+				lifecycle.Register("mutator plugin", PhaseStopClients, func(context.Context) error { return plugin.Close() })
+				mutator = NewPluginMutator(plugin)
+			}
+			var checker Checker
+			if checkerPlugin != "" {
+				plugin, err := NewPlugin(checkerPlugin)
+				if err != nil {
+					return err
+				}
+				lifecycle.Register("checker plugin", PhaseStopClients, func(context.Context) error { return plugin.Close() })
+				checker = PluginChecker(plugin)
+			}
+			seeds := make([]*List[*SchedulingChoice], 0)
+			for _, name := range scenarios {
+				params := DefaultScenarioParams(replicas, horizon)
+				params.Target = scenarioTarget
+				schedule, err := InstantiateScenario(name, params)
+				if err != nil {
+					return err
+				}
+				seeds = append(seeds, schedule)
+			}
+			network := NetworkConfig{
+				DefaultBandwidth: bandwidth,
+			}
+			for _, rule := range partitions {
+				p, err := ParsePartition(rule)
+				if err != nil {
+					return err
+				}
+				network.Partitions = append(network.Partitions, p...)
+			}
+			if topologyPath != "" {
+				topology, err := LoadTopology(topologyPath)
+				if err != nil {
+					return err
+				}
+				network.Topology = topology
+			}
+			triggers := make([]Trigger, 0)
+			for _, rule := range triggerRules {
+				t, err := ParseTrigger(rule)
+				if err != nil {
+					return err
+				}
+				triggers = append(triggers, t)
+			}
+			var sidecar *SidecarClient
+			if sidecarAddr != "" {
+				sidecar = NewSidecarClient(sidecarAddr)
+			}
+			var workload WorkloadAdapter
+			if redisAddr != "" {
+				redis := NewRedisWorkload(redisAddr, 10, payloadSeed)
+				redis.HistoryPath = workloadHistory
+				lifecycle.Register("redis workload", PhaseStopClients, func(context.Context) error { return redis.Close() })
+				workload = redis
+			}
+			guider := NewLineCoverageGuider("127.0.0.1:2023", "traces", recordTraces)
+			guider.CompressTraces = compress
+			config := &FuzzerConfig{
 				Iterations: episodes,
 				Steps:      horizon,
-				Strategy:   NewRandomStrategy(),
-				Guider:     NewLineCoverageGuider("127.0.0.1:2023", "traces", recordTraces),
-				Mutator:    &EmptyMutator{},
+				Strategy:   strategy,
+				Guider:     guider,
+				Mutator:    mutator,
+				Checker:    checker,
+				Invariants: invariants,
+				Predicates: []Predicate{ElectionSafetyNearlyViolated(), LogDivergence(2), CommitLag(2)},
 				RaftEnvironmentConfig: RaftEnvironmentConfig{
 					Replicas:      replicas,
 					ElectionTick:  20,
@@ -51,14 +251,160 @@ func FuzzCommand() *cobra.Command {
 				},
 				MutPerTrace:        5,
 				NumberRequests:     requests,
-				CrashQuota:         2,
+				CrashQuota:         crashQuota,
+				ReplayQuota:        replays,
 				MaxMessages:        10,
 				SeedPopulationSize: 10,
-			})
-			fuzzer.Run()
-			return nil
+				ReseedFrequency:    reseedFrequency,
+				Network:            network,
+				Triggers:           triggers,
+				SeedSchedules:      seeds,
+				BundlePath:         path.Join(savePath, "bundles"),
+				Compress:           compress,
+				CostAware:          costAware,
+				CacheResults:       cacheResults,
+				StallSteps:         20,
+				Payload:            generator,
+				Workload:           workload,
+				Sidecar:            sidecar,
+			}
+			if err := config.validate(); err != nil {
+				return err
+			}
+			manifest := NewCorpusManifest(config)
+			if calibrate {
+				calibration, err := Calibrate(config)
+				if err != nil {
+					return fmt.Errorf("error calibrating timeouts: %s", err)
+				}
+				fmt.Printf("calibrated timeouts with factor %.2f\n", calibration.Factor)
+				lifecycle.SetTimeout(calibration.Scale(shutdownTimeout))
+				if redis, ok := workload.(*RedisWorkload); ok {
+					redis.Timeout = calibration.Scale(redis.Timeout)
+				}
+				manifest.Calibration = &calibration
+			}
+			if seedCorpus != "" {
+				corpus, err := LoadCorpus(seedCorpus)
+				if err != nil {
+					return err
+				}
+				warnings, err := corpus.Manifest.Check(manifest)
+				if err != nil {
+					return fmt.Errorf("incompatible seed corpus: %s", err)
+				}
+				for _, w := range warnings {
+					fmt.Printf("warning: %s\n", w)
+				}
+				config.SeedSchedules = append(config.SeedSchedules, corpus.Schedules...)
+				guider.Import(corpus.Graph)
+			}
+			var corpusClient *CorpusClient
+			if corpusServer != "" {
+				corpusClient = NewCorpusClient(corpusServer)
+				shared, err := corpusClient.Pull(manifest.SUTVersion)
+				if err != nil {
+					return err
+				}
+				config.SeedSchedules = append(config.SeedSchedules, shared...)
+			}
+			fuzzer := NewFuzzer(config)
+			if checkpointIn != "" {
+				checkpoint, err := LoadCheckpoint(checkpointIn)
+				if err != nil {
+					return err
+				}
+				if err := fuzzer.Restore(checkpoint); err != nil {
+					return err
+				}
+			}
+			if debugAddr != "" {
+				debug := NewDebugServer()
+				debug.AddFuzzer("fuzz", fuzzer)
+				debug.Start(debugAddr)
+			}
+			lifecycle.Register("fuzzer", PhaseDrainScheduler, fuzzer.Drain)
+			stopSignals := lifecycle.DrainOnSignal(os.Interrupt, syscall.SIGTERM)
+			coverages := fuzzer.Run()
+			stopSignals()
+			if summaryPath != "" {
+				lifecycle.Check("summary", func() error {
+					_, err := LoadSummary(summaryPath)
+					return err
+				})
+				if err := SaveSummary(summaryPath, fuzzer.Summary(coverages, 0, manifest.SUTVersion)); err != nil {
+					return err
+				}
+			}
+			if checkpointOut != "" {
+				checkpoint, err := fuzzer.Checkpoint()
+				if err != nil {
+					return err
+				}
+				if err := SaveCheckpoint(artifactPath(checkpointOut, compress), checkpoint); err != nil {
+					return err
+				}
+				lifecycle.Check("checkpoint", func() error {
+					_, err := LoadCheckpoint(artifactPath(checkpointOut, compress))
+					return err
+				})
+			}
+			if corpusOut != "" {
+				err := SaveCorpus(corpusOut, &Corpus{
+					Manifest:  manifest,
+					Schedules: fuzzer.Corpus(),
+					States:    fuzzer.CorpusStates(),
+					Graph:     guider.Graph(),
+				}, compress)
+				if err != nil {
+					return err
+				}
+				lifecycle.Check("corpus", func() error {
+					_, err := LoadCorpus(corpusOut)
+					return err
+				})
+			}
+			if corpusClient != nil {
+				for _, schedule := range fuzzer.Corpus() {
+					if _, err := corpusClient.Push(manifest.SUTVersion, schedule); err != nil {
+						return err
+					}
+				}
+			}
+			return guider.Graph().Export("traces", "fuzz", 1)
 		},
 	}
+	cmd.Flags().StringVar(&mutatorPlugin, "mutator-plugin", "", "Path to a plugin executable providing the mutator")
+	cmd.Flags().StringVar(&checkerPlugin, "checker-plugin", "", "Path to a plugin executable providing the invariant checker")
+	cmd.Flags().StringVar(&redisAddr, "redis", "", "Address of a Redis protocol server to run the client workload against instead of the raft environment")
+	cmd.Flags().StringVar(&workloadHistory, "workload-history", "", "Path to append the operation history of every iteration to, with --redis")
+	cmd.Flags().StringVar(&payloadSpec, "payload-spec", "", "Path to a JSON spec of the client request payloads")
+	cmd.Flags().Int64Var(&payloadSeed, "payload-seed", time.Now().UnixNano(), "Seed of the payload generator")
+	cmd.Flags().StringVar(&dictionaryPath, "dictionary", "", "Path to a dictionary of values spliced into request payloads")
+	cmd.Flags().StringSliceVar(&scenarios, "scenario", nil, fmt.Sprintf("Scenario templates to seed the campaign with, one of %v", ScenarioNames()))
+	cmd.Flags().Uint64Var(&scenarioTarget, "scenario-target", 1, "Node the scenario templates act upon")
+	cmd.Flags().StringVar(&topologyPath, "topology", "", "Path to a JSON topology assigning nodes to regions with inter-region latencies")
+	cmd.Flags().IntVar(&bandwidth, "bandwidth", 0, "Bytes per step every link can transmit, 0 for unlimited")
+	cmd.Flags().StringArrayVar(&partitions, "partition", nil, "Partition rule such as 1->2 (one-way) or 1<->2@10:20 (both ways, steps 10 to 20)")
+	cmd.Flags().IntVar(&replays, "replays", 0, "Number of earlier messages re-injected per iteration to exercise stale message handling")
+	cmd.Flags().StringArrayVar(&triggerRules, "trigger", nil, "State-triggered fault such as \"Timeout@2 drop MsgVote\"")
+	cmd.Flags().StringVar(&seedCorpus, "seed-corpus", "", "Corpus of a previous campaign to start from")
+	cmd.Flags().StringVar(&corpusOut, "corpus-out", "", "Directory to save the campaign corpus to")
+	cmd.Flags().BoolVar(&cacheResults, "cache", false, "Skip schedules already executed with the same SUT version and config")
+	cmd.Flags().BoolVar(&costAware, "cost-aware", false, "Mutate cheap schedules more than costly ones with the same coverage gain")
+	cmd.Flags().StringVar(&checkpointIn, "checkpoint-in", "", "Bootstrap the guidance from a strategy checkpoint")
+	cmd.Flags().StringVar(&checkpointOut, "checkpoint-out", "", "Save the learned guidance as a strategy checkpoint")
+	cmd.Flags().BoolVar(&compress, "compress", false, "Store traces, bundles and the corpus gzip compressed")
+	cmd.Flags().StringVar(&corpusServer, "corpus-server", "", "Address of a shared corpus server to pull schedules from and contribute to")
+	cmd.Flags().StringVar(&summaryPath, "summary", "", "Path to write the machine-readable campaign summary to")
+	cmd.Flags().StringVar(&debugAddr, "debug-addr", "", "Address to serve the debug endpoint on, e.g. 127.0.0.1:6060")
+	cmd.Flags().StringVar(&sidecarAddr, "sidecar", "", "Address of an HTTP/JSON guidance sidecar choosing the scheduling actions")
+	cmd.Flags().StringVar(&presetName, "preset", "", fmt.Sprintf("Campaign preset to run, one of %v; explicitly set flags take precedence", PresetNames()))
+	cmd.Flags().IntVar(&crashQuota, "crash-quota", 2, "Number of node crashes per random iteration")
+	cmd.Flags().IntVar(&reseedFrequency, "reseed-frequency", defaultReseedFrequency, "Number of episodes between two reseedings of the population")
+	cmd.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", DefaultShutdownTimeout, "Time each component is given to shut down")
+	cmd.Flags().BoolVar(&calibrate, "calibrate", false, "Scale the shutdown and workload timeouts to the measured speed of the environment and record the factor in the corpus manifest")
+	return cmd
 }
 
 func OneCommand() *cobra.Command {
@@ -72,6 +418,7 @@ func OneCommand() *cobra.Command {
 				Strategy:   NewRandomStrategy(),
 				Mutator:    &EmptyMutator{},
 				Checker:    SerializabilityChecker(),
+				Predicates: []Predicate{ElectionSafetyNearlyViolated(), LogDivergence(2), CommitLag(2)},
 				RaftEnvironmentConfig: RaftEnvironmentConfig{
 					Replicas: replicas,
 					// Higher election tick gives random better chances. (less timeouts)
@@ -93,6 +440,7 @@ func OneCommand() *cobra.Command {
 			combinedMutator := CombineMutators(NewSwapCrashNodeMutator(2), NewSwapNodeMutator(20), NewSwapMaxMessagesMutator(20))
 			c.Add("traceCov", combinedMutator, NewTraceCoverageGuider("127.0.0.1:2023", "traces", recordTraces))
 			c.Add("lineCov", combinedMutator, NewLineCoverageGuider("127.0.0.1:2023", "traces", recordTraces))
+			c.Add("frontier", combinedMutator, NewFrontierGuider("127.0.0.1:2023", "traces", recordTraces, 0.5))
 			c.Add("tlcstate", combinedMutator, NewTLCStateGuider("127.0.0.1:2023", "traces", recordTraces))
 			c.Add("random", &EmptyMutator{}, NewTLCStateGuider("127.0.0.1:2023", "traces", recordTraces))
 
@@ -100,3 +448,450 @@ func OneCommand() *cobra.Command {
 		},
 	}
 }
+
+func ReplayCommand() *cobra.Command {
+	var bundlePath string
+	var strict bool
+	var perturb bool
+	var maxShift int
+	var neighborhoodPath string
+	cmd := &cobra.Command{
+		Use:          "replay",
+		Short:        "Replay a reproducing bundle, failing if the checker is violated",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bundle, err := LoadBundle(bundlePath)
+			if err != nil {
+				return err
+			}
+			if perturb {
+				n := ExploreNeighborhood(bundle, DefaultInvariants(), SerializabilityChecker(), maxShift)
+				if !n.Fails {
+					fmt.Println("warning: the unperturbed schedule does not fail")
+				}
+				for _, p := range n.Perturbations {
+					verdict := "holds"
+					if p.Fails {
+						verdict = "fails"
+					}
+					fmt.Printf("%s: %s\n", p.Description, verdict)
+				}
+				fmt.Printf("%.0f%% of %d perturbed schedules fail\n", 100*n.FailureRate(), len(n.Perturbations))
+				if neighborhoodPath != "" {
+					return SaveNeighborhood(neighborhoodPath, n)
+				}
+				return nil
+			}
+			result := ReplayWithOptions(bundle, SerializabilityChecker(), ReplayOptions{BestEffort: !strict})
+			if result.Divergence != nil {
+				fmt.Println(result.Divergence)
+				if strict {
+					return errors.New("replay diverged from the recorded trace")
+				}
+				fmt.Println("continued best-effort after the divergence")
+			}
+			if !result.Holds {
+				return errCheckerViolated
+			}
+			fmt.Println("checker holds")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "Path to the reproducing bundle")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Stop and fail at the first divergence from the recorded events")
+	cmd.Flags().BoolVar(&perturb, "perturb", false, "Replay small perturbations of the schedule and report which ones still fail")
+	cmd.Flags().IntVar(&maxShift, "max-shift", defaultMaxShift, "Maximum number of steps timed choices are shifted by with --perturb")
+	cmd.Flags().StringVar(&neighborhoodPath, "neighborhood-out", "", "Path to write the perturbations and their outcome as JSON with --perturb")
+	cmd.MarkFlagRequired("bundle")
+	return cmd
+}
+
+func BisectCommand() *cobra.Command {
+	bisector := &Bisector{}
+	cmd := &cobra.Command{
+		Use:          "bisect",
+		Short:        "Find the first commit of the system under test reproducing a bundle",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			commit, err := bisector.Run()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("first bad commit: %s\n", commit)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&bisector.Bundle, "bundle", "", "Path to the reproducing bundle")
+	cmd.Flags().StringVar(&bisector.Good, "good", "", "Known good commit")
+	cmd.Flags().StringVar(&bisector.Bad, "bad", "HEAD", "Known bad commit")
+	cmd.Flags().StringVar(&bisector.Repo, "repo", ".", "Path to the harness repository")
+	cmd.Flags().StringVar(&bisector.SUTPath, "sut", "raft", "Path of the system under test within the repository")
+	cmd.MarkFlagRequired("bundle")
+	cmd.MarkFlagRequired("good")
+	return cmd
+}
+
+func RegressCommand() *cobra.Command {
+	var corpusPath string
+	var reportPath string
+	cmd := &cobra.Command{
+		Use:          "regress",
+		Short:        "Replay all bundles of a corpus as a regression suite",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := RunRegressions(corpusPath, SerializabilityChecker())
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, r := range results {
+				if r.Passed {
+					fmt.Printf("PASS %s\n", r.Bundle)
+				} else {
+					failed++
+					fmt.Printf("FAIL %s: %s\n", r.Bundle, r.Error)
+				}
+			}
+			fmt.Printf("%d/%d bundles passed\n", len(results)-failed, len(results))
+			if reportPath != "" {
+				data, err := json.MarshalIndent(results, "", "\t")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(reportPath, data, 0644); err != nil {
+					return err
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d regressions", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&corpusPath, "corpus", "regressions", "Directory of minimized bundles of fixed bugs")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Write the results as JSON to the given path")
+	return cmd
+}
+
+func CorpusServerCommand() *cobra.Command {
+	var addr string
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "corpus-server",
+		Short: "Serve a corpus shared between campaigns",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Printf("Serving corpus from %s on %s\n", dir, addr)
+			return http.ListenAndServe(addr, NewCorpusServer(dir))
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:2025", "Address to listen on")
+	cmd.Flags().StringVar(&dir, "dir", "shared-corpus", "Directory storing the schedules")
+	return cmd
+}
+
+func GCCommand() *cobra.Command {
+	var root string
+	var policy RetentionPolicy
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:          "gc",
+		Short:        "Remove the artifacts of past campaigns according to a retention policy",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := CollectGarbage(root, policy, time.Now(), dryRun)
+			if err != nil {
+				return err
+			}
+			for _, p := range report.Removed {
+				fmt.Printf("removed %s\n", p)
+			}
+			fmt.Printf("%d artifacts, %d bytes freed\n", len(report.Removed), report.Freed)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&root, "root", "campaigns", "Directory holding one directory per campaign")
+	cmd.Flags().IntVar(&policy.KeepCampaigns, "keep", 10, "Number of most recent campaigns kept in full, 0 keeps all")
+	cmd.Flags().BoolVar(&policy.KeepFailing, "keep-failing", true, "Keep the failing bundles of every campaign")
+	cmd.Flags().DurationVar(&policy.ExpirePassing, "expire", 7*24*time.Hour, "Remove recorded traces older than the duration, 0 never expires them")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be removed without removing it")
+	return cmd
+}
+
+func StatusCommand() *cobra.Command {
+	var addr string
+	cmd := &cobra.Command{
+		Use:          "status",
+		Short:        "Show the progress and forecast of a running campaign",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := http.Get("http://" + addr + "/debug/fuzzer")
+			if err != nil {
+				return fmt.Errorf("error querying debug endpoint: %s", err)
+			}
+			defer resp.Body.Close()
+			status := make(map[string]struct {
+				Iteration string    `json:"iteration"`
+				Step      int       `json:"step"`
+				Forecast  *Forecast `json:"forecast"`
+			})
+			if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+				return fmt.Errorf("error parsing status: %s", err)
+			}
+			for name, s := range status {
+				fmt.Printf("%s: %s step %d\n", name, s.Iteration, s.Step)
+				if fc := s.Forecast; fc != nil {
+					fmt.Printf("  %d/%d schedules, %.0f schedules/hour, ETA %s\n", fc.Completed, fc.Total, fc.SchedulesPerHour, fc.ETA.Round(time.Second))
+					fmt.Printf("  %.2f new states/schedule, %d states projected\n", fc.StatesPerSchedule, fc.ProjectedStates)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:6060", "Address of the campaign debug endpoint")
+	return cmd
+}
+
+func CheckpointCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Inspect strategy checkpoints",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:          "inspect <checkpoint>",
+		Short:        "Summarize the guidance learned in a checkpoint",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checkpoint, err := LoadCheckpoint(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("format version: %d\n", checkpoint.FormatVersion)
+			if checkpoint.Strategy != nil {
+				fmt.Printf("strategy: %s\n", checkpoint.Strategy)
+			}
+			var guider tlcStateCheckpoint
+			var traceCoverage traceCoverageCheckpoint
+			if err := json.Unmarshal(checkpoint.Guider, &traceCoverage); err == nil && traceCoverage.TLCState != nil {
+				fmt.Printf("unique event traces: %d\n", len(traceCoverage.Traces))
+				json.Unmarshal(traceCoverage.TLCState, &guider)
+			} else if checkpoint.Guider != nil {
+				json.Unmarshal(checkpoint.Guider, &guider)
+			}
+			fmt.Printf("unique states: %d\n", len(guider.States))
+			fmt.Printf("unique state traces: %d\n", len(guider.StateTraces))
+			if guider.Graph != nil {
+				fmt.Printf("visit graph nodes: %d\n", len(guider.Graph.Nodes))
+			}
+			fmt.Printf("pending schedules: %d\n", len(checkpoint.Pending))
+			if checkpoint.Sidecar != "" {
+				fmt.Printf("policy sidecar: %s\n", checkpoint.Sidecar)
+			}
+			return nil
+		},
+	})
+	return cmd
+}
+
+func DeterminismCommand() *cobra.Command {
+	var corpusPath string
+	var k int
+	var seed int64
+	cmd := &cobra.Command{
+		Use:          "check-determinism",
+		Short:        "Re-run corpus schedules and check that they reproduce their recorded states",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			corpus, err := LoadCorpus(corpusPath)
+			if err != nil {
+				return err
+			}
+			failed := 0
+			results := CheckDeterminism(corpus, k, seed)
+			for _, r := range results {
+				if r.Reproduced {
+					fmt.Printf("OK   schedule %d\n", r.Schedule)
+					continue
+				}
+				failed++
+				fmt.Printf("DIFF schedule %d at step %d\n  expected %s\n  observed %s\n", r.Schedule, r.Step, r.Expected, r.Observed)
+			}
+			fmt.Printf("%d/%d schedules reproduced\n", len(results)-failed, len(results))
+			if failed > 0 {
+				return fmt.Errorf("%d schedules are not deterministic", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&corpusPath, "corpus", "corpus", "Directory of the corpus to check")
+	cmd.Flags().IntVarP(&k, "schedules", "k", 10, "Number of schedules to re-run")
+	cmd.Flags().Int64Var(&seed, "seed", time.Now().UnixNano(), "Seed for choosing the schedules")
+	return cmd
+}
+
+func ScheduleEditCommand() *cobra.Command {
+	var bundlePath string
+	var outPath string
+	cmd := &cobra.Command{
+		Use:          "schedit",
+		Short:        "Interactively edit the schedule of a bundle and save it for replay",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bundle, err := LoadBundle(bundlePath)
+			if err != nil {
+				return err
+			}
+			if outPath == "" {
+				outPath = bundlePath
+			}
+			editor := NewScheduleEditor(bundle)
+			fmt.Printf("%d choices, %d steps, %d nodes, type help for the commands\n", bundle.Schedule.Size(), bundle.Steps, bundle.RaftEnvironmentConfig.Replicas)
+			return editor.Run(os.Stdin, os.Stdout, func(b *Bundle) error {
+				return SaveBundle(outPath, b)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "Path to the bundle to edit")
+	cmd.Flags().StringVar(&outPath, "out", "", "Path to write the edited bundle to, defaults to the input bundle")
+	cmd.MarkFlagRequired("bundle")
+	return cmd
+}
+
+func CoverageDiffCommand() *cobra.Command {
+	var beforePath, afterPath string
+	var beforeSummary, afterSummary string
+	var reportPath string
+	cmd := &cobra.Command{
+		Use:          "coverage-diff",
+		Short:        "Compare the coverage of two campaigns, e.g. before and after a patch",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			before, err := LoadCorpus(beforePath)
+			if err != nil {
+				return err
+			}
+			after, err := LoadCorpus(afterPath)
+			if err != nil {
+				return err
+			}
+			if before.Manifest.SUTVersion == after.Manifest.SUTVersion {
+				fmt.Printf("warning: both campaigns ran SUT version %s\n", before.Manifest.SUTVersion)
+			}
+			diff := DiffCoverage(before.Graph, after.Graph)
+			if beforeSummary != "" && afterSummary != "" {
+				b, err := LoadSummary(beforeSummary)
+				if err != nil {
+					return err
+				}
+				a, err := LoadSummary(afterSummary)
+				if err != nil {
+					return err
+				}
+				diff.DiffPredicates(b, a)
+			}
+			diff.WriteReport(os.Stdout)
+			if reportPath != "" {
+				data, err := json.MarshalIndent(diff, "", "\t")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(reportPath, data, 0644); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&beforePath, "before", "", "Corpus of the first campaign")
+	cmd.Flags().StringVar(&afterPath, "after", "", "Corpus of the second campaign")
+	cmd.Flags().StringVar(&beforeSummary, "before-summary", "", "Summary of the first campaign, to compare predicates")
+	cmd.Flags().StringVar(&afterSummary, "after-summary", "", "Summary of the second campaign, to compare predicates")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Path to write the differences as JSON")
+	cmd.MarkFlagRequired("before")
+	cmd.MarkFlagRequired("after")
+	return cmd
+}
+
+func VerifyCommand() *cobra.Command {
+	var tlaOut string
+	cmd := &cobra.Command{
+		Use:          "verify <bundle>",
+		Short:        "Check the trace of a bundle against the invariants and checker offline",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bundle, err := LoadBundle(args[0])
+			if err != nil {
+				return err
+			}
+			result := VerifyBundle(bundle, DefaultInvariants(), SerializabilityChecker())
+			if result.Divergence != nil {
+				fmt.Println(result.Divergence)
+			}
+			for _, v := range result.Violations {
+				fmt.Printf("invariant %s violated at step %d\n", v.Invariant, v.Step)
+			}
+			if !result.CheckerHolds {
+				fmt.Println("checker violated")
+			}
+			if tlaOut != "" {
+				if err := ExportTLA(tlaOut, result.Events); err != nil {
+					return err
+				}
+			}
+			if !result.Holds() {
+				return errors.New("trace violates the invariants")
+			}
+			fmt.Println("invariants and checker hold")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&tlaOut, "tla-out", "", "Path to export the trace to for checking against the TLA+ model")
+	return cmd
+}
+
+func ClusterCommand() *cobra.Command {
+	var summaries []string
+	var threshold float64
+	var reportPath string
+	cmd := &cobra.Command{
+		Use:          "cluster",
+		Short:        "Group the failures of campaigns likely sharing a root cause",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			failures := make([]*Failure, 0)
+			bugs := make(map[string]*BugSummary)
+			for _, summaryPath := range summaries {
+				f, b, err := LoadFailures(summaryPath)
+				if err != nil {
+					return err
+				}
+				failures = append(failures, f...)
+				for fingerprint, bug := range b {
+					bugs[fingerprint] = bug
+				}
+			}
+			clusters := ClusterFailures(failures, bugs, threshold)
+			for i, c := range clusters {
+				fmt.Printf("cluster %d: %s, %d failures in %d campaigns on %d SUT versions\n", i, c.Fingerprint, len(c.Failures), len(c.Campaigns), len(c.SUTVersions))
+				if c.CheckerFailed {
+					fmt.Println("  checker failed")
+				}
+				if len(c.Invariants) > 0 {
+					fmt.Printf("  invariants: %s\n", strings.Join(c.Invariants, ", "))
+				}
+				fmt.Printf("  SUT versions: %s\n", strings.Join(c.SUTVersions, ", "))
+				fmt.Printf("  representative: %s\n", c.Representative)
+			}
+			if reportPath != "" {
+				return SaveClusterReport(reportPath, clusters)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&summaries, "summary", nil, "Summary of a campaign whose failures to cluster, repeatable")
+	cmd.Flags().Float64Var(&threshold, "threshold", defaultClusterThreshold, "Abstract-state prefix similarity above which failures are clustered together")
+	cmd.Flags().StringVar(&reportPath, "report", "", "Path to write the clusters as JSON")
+	cmd.MarkFlagRequired("summary")
+	return cmd
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// bundleFormatVersion is bumped whenever the layout of bundles, their schedules
+// or their recorded events changes. Every bump comes with a migration from the
+// previous version, so that archived bundles remain loadable.
+const bundleFormatVersion = 2
+
+// bundleMigration upgrades a decoded bundle by one format version in place
+type bundleMigration func(bundle map[string]json.RawMessage) error
+
+// bundleMigrations[v] upgrades a bundle of format version v to v+1
+var bundleMigrations = []bundleMigration{
+	// Bundles written before versioning have the version 1 layout
+	func(bundle map[string]json.RawMessage) error { return nil },
+	// Version 2 adds the triggers, none are recorded in version 1 bundles
+	func(bundle map[string]json.RawMessage) error { return nil },
+}
+
+// migrateBundle upgrades the encoded bundle data to the current format version
+func migrateBundle(data []byte) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	version := 0
+	if raw, ok := fields["FormatVersion"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, fmt.Errorf("invalid format version: %s", err)
+		}
+	}
+	if version > bundleFormatVersion {
+		return nil, fmt.Errorf("format version %d is newer than the supported %d", version, bundleFormatVersion)
+	}
+	if version == bundleFormatVersion {
+		return data, nil
+	}
+	for v := version; v < bundleFormatVersion; v++ {
+		if err := bundleMigrations[v](fields); err != nil {
+			return nil, fmt.Errorf("error migrating from format version %d: %s", v, err)
+		}
+	}
+	fields["FormatVersion"], _ = json.Marshal(bundleFormatVersion)
+	return json.Marshal(fields)
+}
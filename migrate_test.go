@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The fixtures are bundles archived in every supported format version: v0 was
+// written before bundles were versioned, v1 before triggers were recorded and v2
+// is the current format
+const (
+	bundleFixtureV0    = "testdata/bundles/v0.json"
+	bundleFixtureV1    = "testdata/bundles/v1.json"
+	bundleFixtureV2    = "testdata/bundles/v2.json"
+	bundleFixtureNewer = "testdata/bundles/newer.json"
+)
+
+func TestLoadArchivedBundles(t *testing.T) {
+	current, err := LoadBundle(bundleFixtureV2)
+	if err != nil {
+		t.Fatalf("Failed to load the current bundle: %s", err)
+	}
+	if current.FormatVersion != bundleFormatVersion {
+		t.Errorf("Expected format version %d, got %d", bundleFormatVersion, current.FormatVersion)
+	}
+
+	v0, err := LoadBundle(bundleFixtureV0)
+	if err != nil {
+		t.Fatalf("Failed to load the unversioned bundle: %s", err)
+	}
+	v1, err := LoadBundle(bundleFixtureV1)
+	if err != nil {
+		t.Fatalf("Failed to load the version 1 bundle: %s", err)
+	}
+	for _, upgraded := range []*Bundle{v0, v1} {
+		if upgraded.FormatVersion != bundleFormatVersion {
+			t.Errorf("Expected the bundle to be upgraded to format version %d, got %d", bundleFormatVersion, upgraded.FormatVersion)
+		}
+		if upgraded.Triggers != nil {
+			t.Errorf("Expected no triggers in an upgraded bundle, got %v", upgraded.Triggers)
+		}
+	}
+	if !reflect.DeepEqual(v0, v1) {
+		t.Errorf("Expected the upgraded bundles to match")
+	}
+
+	// The archived schedules still replay
+	for _, bundle := range []*Bundle{v0, v1, current} {
+		if !Replay(bundle, nil) {
+			t.Errorf("Failed to replay the archived bundle")
+		}
+	}
+}
+
+func TestReplayBundleTriggers(t *testing.T) {
+	bundle, err := LoadBundle(bundleFixtureV2)
+	if err != nil {
+		t.Fatalf("Failed to load the bundle: %s", err)
+	}
+	fuzzer := NewFuzzer(bundle.fuzzerConfig(nil))
+	fired := make([]string, 0)
+	fuzzer.EventBus().Subscribe(FaultInjected, func(e *CampaignEvent) {
+		if name, ok := e.Params["trigger"].(string); ok {
+			fired = append(fired, name)
+		}
+	})
+	fuzzer.RunIteration("replay", bundle.Schedule)
+	if !reflect.DeepEqual(fired, bundle.Triggers) {
+		t.Errorf("Expected the triggers %v of the bundle to fire, got %v", bundle.Triggers, fired)
+	}
+
+	recorded, ok := fuzzer.bundleFromEvent(&CampaignEvent{
+		Iteration: "replay",
+		Params:    map[string]interface{}{"schedule": bundle.Schedule},
+	})
+	if !ok || !reflect.DeepEqual(recorded.Triggers, bundle.Triggers) {
+		t.Errorf("Expected the triggers %v to be recorded, got %v", bundle.Triggers, recorded.Triggers)
+	}
+}
+
+func TestMigrateBundleCurrent(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{"FormatVersion": bundleFormatVersion, "Steps": 3})
+	if err != nil {
+		t.Fatalf("Failed to encode bundle: %s", err)
+	}
+	migrated, err := migrateBundle(data)
+	if err != nil {
+		t.Fatalf("Failed to migrate the current bundle: %s", err)
+	}
+	if string(migrated) != string(data) {
+		t.Errorf("Expected the current bundle to be left as is, got %s", migrated)
+	}
+}
+
+func TestLoadNewerBundle(t *testing.T) {
+	_, err := LoadBundle(bundleFixtureNewer)
+	if err == nil || !strings.Contains(err.Error(), "newer than the supported") {
+		t.Errorf("Expected a bundle of a newer format version to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	pb "github.com/ds-testing-user/etcd-fuzzing/raft/raftpb"
+)
+
+// Topology places nodes in regions with base latencies between regions,
+// expressed in steps. Messages on a link can only be delivered once the latency
+// of the link has elapsed since they were sent.
+type Topology struct {
+	Regions map[uint64]string
+	// Latencies between regions, looked up in both directions
+	Latencies map[string]map[string]int
+	// LocalLatency is the latency between nodes of the same region
+	LocalLatency int
+}
+
+func LoadTopology(path string) (*Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading topology: %s", err)
+	}
+	t := &Topology{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("error parsing topology: %s", err)
+	}
+	return t, nil
+}
+
+// Latency returns the latency of the link from -> to. Nodes without a region,
+// such as the client, have no latency.
+func (t *Topology) Latency(from, to uint64) int {
+	fromRegion, ok := t.Regions[from]
+	if !ok {
+		return 0
+	}
+	toRegion, ok := t.Regions[to]
+	if !ok {
+		return 0
+	}
+	if fromRegion == toRegion {
+		return t.LocalLatency
+	}
+	if l, ok := t.Latencies[fromRegion][toRegion]; ok {
+		return l
+	}
+	return t.Latencies[toRegion][fromRegion]
+}
+
+// LinkBandwidth caps the bytes per step a link can transmit
+type LinkBandwidth struct {
+	From         uint64
+	To           uint64
+	BytesPerStep int
+}
+
+type NetworkConfig struct {
+	Topology *Topology
+	// DefaultBandwidth caps every link without its own entry in Bandwidth,
+	// 0 leaves them unlimited
+	DefaultBandwidth int
+	Bandwidth        []LinkBandwidth
+	Partitions       []Partition
+}
+
+func (c NetworkConfig) bandwidth(from, to uint64) int {
+	for _, b := range c.Bandwidth {
+		if b.From == from && b.To == to {
+			return b.BytesPerStep
+		}
+	}
+	return c.DefaultBandwidth
+}
+
+// inFlight is a message queued on a link along with the step it was sent at
+type inFlight struct {
+	message pb.Message
+	sentAt  int
+}
+
+// annotations describes what the harness did to the message when it is
+// delivered at step
+func (m *inFlight) annotations(step int) map[string]interface{} {
+	return map[string]interface{}{
+		"delay":    step - m.sentAt,
+		"decision": step,
+	}
+}
+
+// Network models the links between the nodes. It is consulted when messages
+// are scheduled to decide which of the queued messages can be delivered.
+type Network struct {
+	config NetworkConfig
+	step   int
+	links  map[string]*linkState
+	// drops holds the message types to drop next, per sender
+	drops map[uint64][]pb.MessageType
+}
+
+// linkState tracks the transmit credit of a bandwidth capped link. Credit
+// accrues every step up to the cap of the link; transmitting a message larger
+// than the credit drives it negative, so the messages queued behind it wait
+// until the link caught up.
+type linkState struct {
+	credit   int
+	lastStep int
+}
+
+func NewNetwork(config NetworkConfig) *Network {
+	return &Network{
+		config: config,
+		links:  make(map[string]*linkState),
+		drops:  make(map[uint64][]pb.MessageType),
+	}
+}
+
+// Reset restores the network for a new iteration
+func (n *Network) Reset() {
+	n.step = 0
+	n.links = make(map[string]*linkState)
+	n.drops = make(map[uint64][]pb.MessageType)
+}
+
+// DropNext drops the next message of the given type sent by node
+func (n *Network) DropNext(node uint64, msgType pb.MessageType) {
+	n.drops[node] = append(n.drops[node], msgType)
+}
+
+// dropped returns true and consumes the rule if the message is to be dropped
+func (n *Network) dropped(m *inFlight) bool {
+	drops := n.drops[m.message.From]
+	for i, t := range drops {
+		if t == m.message.Type {
+			n.drops[m.message.From] = append(drops[:i:i], drops[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Network) link(from, to uint64) *linkState {
+	bandwidth := n.config.bandwidth(from, to)
+	if bandwidth <= 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%d_%d", from, to)
+	l, ok := n.links[key]
+	if !ok {
+		l = &linkState{credit: bandwidth, lastStep: n.step}
+		n.links[key] = l
+	}
+	if n.step > l.lastStep {
+		l.credit += bandwidth * (n.step - l.lastStep)
+		if l.credit > bandwidth {
+			l.credit = bandwidth
+		}
+		l.lastStep = n.step
+	}
+	return l
+}
+
+// Advance moves the network to the given step
+func (n *Network) Advance(step int) {
+	n.step = step
+}
+
+// Deliverable returns true if the message can be delivered at the current step
+func (n *Network) Deliverable(from, to uint64, m *inFlight) bool {
+	if n.config.Topology != nil && n.step < m.sentAt+n.config.Topology.Latency(from, to) {
+		return false
+	}
+	if l := n.link(from, to); l != nil && l.credit <= 0 {
+		return false
+	}
+	return true
+}
+
+// Blocked returns true if a partition drops the messages from -> to at the current step
+func (n *Network) Blocked(from, to uint64) bool {
+	for _, p := range n.config.Partitions {
+		if p.From == from && p.To == to && p.active(n.step) {
+			return true
+		}
+	}
+	return false
+}
+
+// Transmit charges the message to the bandwidth of its link
+func (n *Network) Transmit(from, to uint64, m *inFlight) {
+	if l := n.link(from, to); l != nil {
+		l.credit -= m.message.Size()
+	}
+}
+
+// Partition drops the messages sent from From to To while the step is within
+// [Start, End). An End of 0 keeps the partition until the end of the iteration.
+// Partitions are one-way, symmetric partitions are expressed as two rules.
+type Partition struct {
+	From  uint64
+	To    uint64
+	Start int
+	End   int
+}
+
+// OneWay returns the rule dropping messages from -> to
+func OneWay(from, to uint64, start, end int) []Partition {
+	return []Partition{{From: from, To: to, Start: start, End: end}}
+}
+
+// Bidirectional returns the rules dropping messages between a and b in both directions
+func Bidirectional(a, b uint64, start, end int) []Partition {
+	return append(OneWay(a, b, start, end), OneWay(b, a, start, end)...)
+}
+
+func (p Partition) active(step int) bool {
+	return step >= p.Start && (p.End == 0 || step < p.End)
+}
+
+// ParsePartition parses a partition rule of the form "1->2" (messages from 1 to 2
+// are lost) or "1<->2" (lost in both directions), optionally followed by a step
+// window "@start:end" where either bound may be left empty
+func ParsePartition(rule string) ([]Partition, error) {
+	rule = strings.TrimSpace(rule)
+	start, end := 0, 0
+	if i := strings.Index(rule, "@"); i >= 0 {
+		window := strings.SplitN(rule[i+1:], ":", 2)
+		if len(window) != 2 {
+			return nil, fmt.Errorf("invalid partition window in %q, expected @start:end", rule)
+		}
+		var err error
+		if window[0] != "" {
+			if start, err = strconv.Atoi(window[0]); err != nil {
+				return nil, fmt.Errorf("invalid partition start in %q: %s", rule, err)
+			}
+		}
+		if window[1] != "" {
+			if end, err = strconv.Atoi(window[1]); err != nil {
+				return nil, fmt.Errorf("invalid partition end in %q: %s", rule, err)
+			}
+		}
+		rule = rule[:i]
+	}
+
+	sep, bidirectional := "->", false
+	if strings.Contains(rule, "<->") {
+		sep, bidirectional = "<->", true
+	}
+	nodes := strings.SplitN(rule, sep, 2)
+	if len(nodes) != 2 {
+		return nil, fmt.Errorf("invalid partition rule %q, expected a->b or a<->b", rule)
+	}
+	from, err := strconv.ParseUint(strings.TrimSpace(nodes[0]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node in partition rule %q: %s", rule, err)
+	}
+	to, err := strconv.ParseUint(strings.TrimSpace(nodes[1]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node in partition rule %q: %s", rule, err)
+	}
+	if bidirectional {
+		return Bidirectional(from, to, start, end), nil
+	}
+	return OneWay(from, to, start, end), nil
+}
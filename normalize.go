@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// stepChoice reports whether the choice applies at its Step rather than in the
+// order it appears in the schedule
+func stepChoice(ch *SchedulingChoice) bool {
+	switch ch.Type {
+	case StopNode, StartNode, ClientRequest, ReplayMessage, LoseQuorum:
+		return true
+	}
+	return false
+}
+
+// Normalize rewrites a schedule into a canonical form, so that schedules that
+// only differ syntactically normalize the same:
+//   - the choices applying at a step follow the ordered choices, sorted by step,
+//     and only the last of the same type at a step is kept, as it is the one
+//     executed
+//   - restarts of nodes that are not crashed are dropped
+//   - nodes are renumbered in order of first appearance, the nodes being
+//     interchangeable at the start of an iteration
+func Normalize(schedule *List[*SchedulingChoice]) *List[*SchedulingChoice] {
+	ordered := make([]*SchedulingChoice, 0, schedule.Size())
+	atStep := make(map[SchedulingChoiceType]map[int]*SchedulingChoice)
+	for _, ch := range schedule.Iter() {
+		if !stepChoice(ch) {
+			ordered = append(ordered, ch.Copy())
+			continue
+		}
+		if _, ok := atStep[ch.Type]; !ok {
+			atStep[ch.Type] = make(map[int]*SchedulingChoice)
+		}
+		atStep[ch.Type][ch.Step] = ch.Copy()
+	}
+
+	stepped := make([]*SchedulingChoice, 0)
+	for _, choices := range atStep {
+		for _, ch := range choices {
+			stepped = append(stepped, ch)
+		}
+	}
+	// Within a step, crashes are executed before restarts
+	typeOrder := map[SchedulingChoiceType]int{StopNode: 0, StartNode: 1, LoseQuorum: 2, ClientRequest: 3, ReplayMessage: 4}
+	sort.Slice(stepped, func(i, j int) bool {
+		if stepped[i].Step != stepped[j].Step {
+			return stepped[i].Step < stepped[j].Step
+		}
+		return typeOrder[stepped[i].Type] < typeOrder[stepped[j].Type]
+	})
+
+	crashed := make(map[uint64]bool)
+	faults := make([]*SchedulingChoice, 0, len(stepped))
+	for _, ch := range stepped {
+		switch ch.Type {
+		case StopNode:
+			crashed[ch.Node] = true
+		case StartNode:
+			if !crashed[ch.Node] {
+				continue
+			}
+			delete(crashed, ch.Node)
+		}
+		faults = append(faults, ch)
+	}
+
+	renumber := make(map[uint64]uint64)
+	id := func(node uint64) uint64 {
+		if node == 0 {
+			return 0
+		}
+		if n, ok := renumber[node]; ok {
+			return n
+		}
+		renumber[node] = uint64(len(renumber) + 1)
+		return renumber[node]
+	}
+	normalized := NewList[*SchedulingChoice]()
+	for _, ch := range append(ordered, faults...) {
+		switch ch.Type {
+		case Node:
+			ch.From = id(ch.From)
+			ch.To = id(ch.To)
+		case StopNode, StartNode:
+			ch.Node = id(ch.Node)
+		}
+		normalized.Append(ch)
+	}
+	return normalized
+}
+
+// canonicalHash identifies the canonical form of a schedule
+func canonicalHash(schedule *List[*SchedulingChoice]) string {
+	bs, _ := json.Marshal(Normalize(schedule))
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultInvariants are the invariants checked after every step by verify
+func DefaultInvariants() []Invariant {
+	return []Invariant{
+		{Name: "single-leader", Check: SingleLeader()},
+	}
+}
+
+// VerifyResult is the outcome of checking a recorded trace offline
+type VerifyResult struct {
+	// CheckerHolds reports whether the checker holds at the end of the trace
+	CheckerHolds bool
+	Violations   []InvariantViolation
+	// Divergence is nil if the re-execution matched the recorded events
+	Divergence *Divergence
+	// Events is the event trace of the re-execution
+	Events *List[*Event]
+	// States is the abstract state after every step of the re-execution
+	States []string
+}
+
+// Holds reports whether the checker and every invariant held
+func (r *VerifyResult) Holds() bool {
+	return r.CheckerHolds && len(r.Violations) == 0
+}
+
+// VerifyBundle checks the trace of a bundle against invariants and checker
+// without a campaign: its schedule is re-executed deterministically in the
+// in-process environment, so invariants written after the bundle was recorded
+// can be checked against it.
+func VerifyBundle(bundle *Bundle, invariants []Invariant, checker Checker) *VerifyResult {
+	config := bundle.fuzzerConfig(checker)
+	config.Invariants = invariants
+	fuzzer := NewFuzzer(config)
+	if bundle.Events != nil {
+		fuzzer.replay = &replayState{expected: bundle.Events, bestEffort: true}
+	}
+	result := &VerifyResult{CheckerHolds: true, Violations: make([]InvariantViolation, 0)}
+	fuzzer.EventBus().Subscribe(InvariantViolated, func(e *CampaignEvent) {
+		result.CheckerHolds = !e.Params["checker_failed"].(bool)
+		result.Violations = e.Params["violations"].([]InvariantViolation)
+	})
+	_, result.Events = fuzzer.RunIteration("verify", bundle.Schedule)
+	result.States = fuzzer.abstractStates
+	if fuzzer.replay != nil {
+		result.Divergence = fuzzer.replay.divergence
+	}
+	return result
+}
+
+// ExportTLA writes events in the format of the execute endpoint of the TLC
+// server, so that the trace can be checked against the TLA+ model later
+func ExportTLA(filePath string, events *List[*Event]) error {
+	trace := NewList[*Event]()
+	for _, e := range events.Iter() {
+		trace.Append(e)
+	}
+	trace.Append(&Event{Reset: true})
+	data, err := json.Marshal(trace)
+	if err != nil {
+		return fmt.Errorf("error marshalling trace: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return fmt.Errorf("error writing trace: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+type FieldType string
+
+var (
+	IntField    FieldType = "int"
+	StringField FieldType = "string"
+	EnumField   FieldType = "enum"
+	KeyField    FieldType = "key"
+	BytesField  FieldType = "bytes"
+)
+
+// FieldSpec declares the shape of one field of a generated payload
+type FieldSpec struct {
+	Name string
+	Type FieldType
+	// Min and Max bound int fields and the length of string and bytes fields
+	Min int
+	Max int
+	// Values is the set enum fields are drawn from
+	Values []string
+	// Prefix, Keys and Distribution shape key fields: one of Keys keys named
+	// Prefix<n>, drawn either "uniform" or "zipf" (skewed towards low n)
+	Prefix       string
+	Keys         int
+	Distribution string
+}
+
+// PayloadSpec declares the payloads of client requests
+type PayloadSpec struct {
+	Fields []FieldSpec
+}
+
+func LoadPayloadSpec(path string) (*PayloadSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading payload spec: %s", err)
+	}
+	spec := &PayloadSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("error parsing payload spec: %s", err)
+	}
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid payload spec: %s", err)
+	}
+	return spec, nil
+}
+
+// validate checks that payloads can be generated for every field
+func (s *PayloadSpec) validate() error {
+	for _, f := range s.Fields {
+		switch f.Type {
+		case StringField, BytesField:
+			if f.Min < 0 {
+				return fmt.Errorf("field %s has a negative minimum length %d", f.Name, f.Min)
+			}
+			fallthrough
+		case IntField:
+			if f.Max < f.Min {
+				return fmt.Errorf("field %s has a maximum %d below its minimum %d", f.Name, f.Max, f.Min)
+			}
+		case EnumField, KeyField:
+		default:
+			return fmt.Errorf("field %s has an unknown type %q", f.Name, f.Type)
+		}
+	}
+	return nil
+}
+
+// Generator produces payloads from a PayloadSpec. Payloads are drawn from a
+// seeded source so that a campaign can be reproduced from its seed.
+type Generator struct {
+	Spec *PayloadSpec
+	Seed int64
+	rand *rand.Rand
+}
+
+func NewGenerator(spec *PayloadSpec, seed int64) *Generator {
+	return &Generator{
+		Spec: spec,
+		Seed: seed,
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Next generates the next payload of the generator's stream
+func (g *Generator) Next() []byte {
+	payload, _ := json.Marshal(g.Fields(g.rand))
+	return payload
+}
+
+// Fields draws a value for every field of the spec from r
+func (g *Generator) Fields(r *rand.Rand) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, f := range g.Spec.Fields {
+		fields[f.Name] = f.generate(r)
+	}
+	return fields
+}
+
+func (f FieldSpec) generate(r *rand.Rand) interface{} {
+	switch f.Type {
+	case IntField:
+		return f.Min + r.Intn(f.Max-f.Min+1)
+	case StringField:
+		s := make([]byte, f.Min+r.Intn(f.Max-f.Min+1))
+		for i := range s {
+			s[i] = byte('a' + r.Intn(26))
+		}
+		return string(s)
+	case BytesField:
+		b := make([]byte, f.Min+r.Intn(f.Max-f.Min+1))
+		r.Read(b)
+		return b
+	case EnumField:
+		if len(f.Values) == 0 {
+			return ""
+		}
+		return f.Values[r.Intn(len(f.Values))]
+	case KeyField:
+		if f.Keys <= 0 {
+			return f.Prefix
+		}
+		var n uint64
+		if f.Distribution == "zipf" {
+			n = rand.NewZipf(r, 1.1, 1, uint64(f.Keys-1)).Uint64()
+		} else {
+			n = uint64(r.Intn(f.Keys))
+		}
+		return f.Prefix + strconv.FormatUint(n, 10)
+	}
+	return nil
+}
+
+// encodeRequest builds the proposal data of a client request
+func encodeRequest(request int, payload []byte) []byte {
+	data := []byte(strconv.Itoa(request))
+	if payload != nil {
+		data = append(append(data, ':'), payload...)
+	}
+	return data
+}
+
+// decodeRequest splits proposal data into the request number and payload
+func decodeRequest(data []byte) (int, []byte) {
+	reqData, payload := data, []byte(nil)
+	if i := bytes.IndexByte(data, ':'); i >= 0 {
+		reqData, payload = data[:i], data[i+1:]
+	}
+	request, _ := strconv.Atoi(string(reqData))
+	return request, payload
+}
+
+// PayloadMutator regenerates the payload of randomly picked client requests
+type PayloadMutator struct {
+	NumPoints int
+	generator *Generator
+	rand      *rand.Rand
+}
+
+func NewPayloadMutator(generator *Generator, numPoints int) *PayloadMutator {
+	return &PayloadMutator{
+		NumPoints: numPoints,
+		generator: generator,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+var _ Mutator = &PayloadMutator{}
+
+func (p *PayloadMutator) Mutate(trace *List[*SchedulingChoice], _ *List[*Event]) (*List[*SchedulingChoice], bool) {
+	requestIndices := make([]int, 0)
+	for i, choice := range trace.Iter() {
+		if choice.Type == ClientRequest {
+			requestIndices = append(requestIndices, i)
+		}
+	}
+	if len(requestIndices) == 0 {
+		return nil, false
+	}
+	toMutate := make(map[int]bool)
+	for _, i := range sample(requestIndices, p.NumPoints, p.rand) {
+		toMutate[i] = true
+	}
+
+	newTrace := NewList[*SchedulingChoice]()
+	for i, choice := range trace.Iter() {
+		newChoice := choice.Copy()
+		if _, ok := toMutate[i]; ok {
+			newChoice.Payload = p.generator.Next()
+			tagMutation(newChoice, newMutationID("payload"))
+		}
+		newTrace.Append(newChoice)
+	}
+	return newTrace, true
+}
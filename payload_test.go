@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPayloadSpec(t *testing.T) {
+	testCases := []struct {
+		name  string
+		spec  string
+		error string
+	}{
+		{"valid", `{"Fields": [{"Name": "n", "Type": "int", "Min": -5, "Max": 5}, {"Name": "s", "Type": "string", "Min": 0, "Max": 3}]}`, ""},
+		{"int range", `{"Fields": [{"Name": "n", "Type": "int", "Min": 5, "Max": 1}]}`, "maximum 1 below its minimum 5"},
+		{"bytes range", `{"Fields": [{"Name": "b", "Type": "bytes", "Min": 2, "Max": 1}]}`, "maximum 1 below its minimum 2"},
+		{"negative length", `{"Fields": [{"Name": "s", "Type": "string", "Min": -1, "Max": 3}]}`, "negative minimum length"},
+		{"unknown type", `{"Fields": [{"Name": "f", "Type": "float"}]}`, "unknown type"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spec.json")
+			if err := os.WriteFile(path, []byte(tc.spec), 0644); err != nil {
+				t.Fatalf("Failed to write spec: %s", err)
+			}
+			spec, err := LoadPayloadSpec(path)
+			if tc.error == "" {
+				if err != nil {
+					t.Fatalf("Failed to load spec: %s", err)
+				}
+				NewGenerator(spec, 1).Next()
+			} else if err == nil || !strings.Contains(err.Error(), tc.error) {
+				t.Errorf("Expected an error containing %q, got %v", tc.error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// defaultMaxShift is the number of steps timed choices are shifted by at most
+// when mapping the neighborhood of a failing schedule
+const defaultMaxShift = 2
+
+// Perturbation is a small controlled change to a schedule
+type Perturbation struct {
+	// Index is the choice of the schedule that is changed
+	Index       int
+	Description string
+	// Fails reports whether the perturbed schedule still violates the checker or
+	// an invariant
+	Fails bool
+}
+
+// Neighborhood maps the schedules around a failing one
+type Neighborhood struct {
+	// Fails reports whether the unperturbed schedule fails
+	Fails         bool
+	Perturbations []*Perturbation
+}
+
+// FailureRate is the fraction of the perturbed schedules that still fail. A rate
+// close to 1 is a robust bug, a rate close to 0 a timing-sensitive one.
+func (n *Neighborhood) FailureRate() float64 {
+	if len(n.Perturbations) == 0 {
+		return 0
+	}
+	failing := 0
+	for _, p := range n.Perturbations {
+		if p.Fails {
+			failing++
+		}
+	}
+	return float64(failing) / float64(len(n.Perturbations))
+}
+
+// perturbations enumerates the single perturbations of schedule: timed choices
+// shifted by up to maxShift steps within [0, steps), adjacent distinct deliveries
+// swapped and random tie-breaks flipped or moved to the neighboring value
+func perturbations(schedule *List[*SchedulingChoice], steps int, maxShift int) []func() (*Perturbation, *List[*SchedulingChoice]) {
+	choices := schedule.Iter()
+	perturbed := func(index int, description string, change func([]*SchedulingChoice)) func() (*Perturbation, *List[*SchedulingChoice]) {
+		return func() (*Perturbation, *List[*SchedulingChoice]) {
+			copied := make([]*SchedulingChoice, len(choices))
+			for i, ch := range choices {
+				copied[i] = ch.Copy()
+			}
+			change(copied)
+			l := NewList[*SchedulingChoice]()
+			for _, ch := range copied {
+				l.Append(ch)
+			}
+			return &Perturbation{Index: index, Description: description}, l
+		}
+	}
+
+	result := make([]func() (*Perturbation, *List[*SchedulingChoice]), 0)
+	lastNode := -1
+	for i, ch := range choices {
+		i := i
+		switch ch.Type {
+		case StartNode, StopNode, ClientRequest, ReplayMessage, LoseQuorum:
+			for shift := -maxShift; shift <= maxShift; shift++ {
+				step := ch.Step + shift
+				if shift == 0 || step < 0 || step >= steps || occupied(choices, ch.Type, step) {
+					continue
+				}
+				result = append(result, perturbed(i, fmt.Sprintf("shift %s@%d by %+d", ch.Type, ch.Step, shift), func(s []*SchedulingChoice) {
+					s[i].Step = step
+				}))
+			}
+		case Node:
+			if prev := lastNode; prev >= 0 && (choices[prev].From != ch.From || choices[prev].To != ch.To || choices[prev].MaxMessages != ch.MaxMessages) {
+				j := prev
+				result = append(result, perturbed(i, fmt.Sprintf("swap deliveries %d and %d", j, i), func(s []*SchedulingChoice) {
+					s[i], s[j] = s[j], s[i]
+				}))
+			}
+			lastNode = i
+		case RandomBoolean:
+			result = append(result, perturbed(i, fmt.Sprintf("flip tie-break %d", i), func(s []*SchedulingChoice) {
+				s[i].BooleanChoice = !s[i].BooleanChoice
+			}))
+		case RandomInteger:
+			for _, delta := range []int{-1, 1} {
+				value := ch.IntegerChoice + delta
+				if value < 0 {
+					continue
+				}
+				result = append(result, perturbed(i, fmt.Sprintf("tie-break %d to %d", i, value), func(s []*SchedulingChoice) {
+					s[i].IntegerChoice = value
+				}))
+			}
+		}
+	}
+	return result
+}
+
+// occupied reports whether the schedule already has a choice of the given type
+// at step, which a shifted choice would replace
+func occupied(choices []*SchedulingChoice, t SchedulingChoiceType, step int) bool {
+	for _, ch := range choices {
+		if ch.Type == t && ch.Step == step {
+			return true
+		}
+	}
+	return false
+}
+
+// ExploreNeighborhood replays every single perturbation of the schedule of a
+// bundle and reports which ones still fail. Perturbed schedules are replayed
+// without comparing events, since they are expected to diverge.
+func ExploreNeighborhood(bundle *Bundle, invariants []Invariant, checker Checker, maxShift int) *Neighborhood {
+	fails := func(schedule *List[*SchedulingChoice]) bool {
+		b := *bundle
+		b.Schedule = schedule
+		b.Events = nil
+		return !VerifyBundle(&b, invariants, checker).Holds()
+	}
+	n := &Neighborhood{
+		Fails:         fails(bundle.Schedule),
+		Perturbations: make([]*Perturbation, 0),
+	}
+	for _, perturb := range perturbations(bundle.Schedule, bundle.Steps, maxShift) {
+		p, schedule := perturb()
+		p.Fails = fails(schedule)
+		n.Perturbations = append(n.Perturbations, p)
+	}
+	return n
+}
+
+func SaveNeighborhood(filePath string, n *Neighborhood) error {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling neighborhood: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return fmt.Errorf("error writing neighborhood: %s", err)
+	}
+	return nil
+}
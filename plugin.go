@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Plugin is an external process implementing custom mutators, checkers or
+// strategies. The harness talks to it over stdin/stdout, one JSON request and one
+// JSON response per line:
+//
+//	-> {"method": "mutate", "params": {...}}
+//	<- {"result": {...}, "error": ""}
+type Plugin struct {
+	Path   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	lock   *sync.Mutex
+}
+
+type pluginRequest struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+type pluginResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error"`
+}
+
+// NewPlugin starts the plugin executable at path
+func NewPlugin(path string, args ...string) (*Plugin, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating plugin stdin: %s", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error creating plugin stdout: %s", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting plugin %s: %s", path, err)
+	}
+	return &Plugin{
+		Path:   path,
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		lock:   new(sync.Mutex),
+	}, nil
+}
+
+// Call invokes method on the plugin and decodes the result into result
+func (p *Plugin) Call(method string, params interface{}, result interface{}) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	req, err := json.Marshal(pluginRequest{Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("error marshalling plugin request: %s", err)
+	}
+	if _, err := p.stdin.Write(append(req, '\n')); err != nil {
+		return fmt.Errorf("error sending request to plugin %s: %s", p.Path, err)
+	}
+	line, err := p.stdout.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("error reading response from plugin %s: %s", p.Path, err)
+	}
+	res := &pluginResponse{}
+	if err := json.Unmarshal(line, res); err != nil {
+		return fmt.Errorf("error parsing plugin response: %s", err)
+	}
+	if res.Error != "" {
+		return fmt.Errorf("plugin %s: %s", p.Path, res.Error)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(res.Result, result); err != nil {
+		return fmt.Errorf("error parsing plugin result: %s", err)
+	}
+	return nil
+}
+
+// Close stops the plugin process
+func (p *Plugin) Close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
+
+// PluginMutator delegates mutations to the "mutate" method of a plugin
+type PluginMutator struct {
+	plugin *Plugin
+}
+
+var _ Mutator = &PluginMutator{}
+
+func NewPluginMutator(plugin *Plugin) *PluginMutator {
+	return &PluginMutator{plugin: plugin}
+}
+
+func (p *PluginMutator) Mutate(trace *List[*SchedulingChoice], eventTrace *List[*Event]) (*List[*SchedulingChoice], bool) {
+	params := map[string]interface{}{
+		"trace":       trace,
+		"event_trace": eventTrace,
+	}
+	result := &struct {
+		Trace *List[*SchedulingChoice] `json:"trace"`
+		Ok    bool                     `json:"ok"`
+	}{}
+	if err := p.plugin.Call("mutate", params, result); err != nil || !result.Ok || result.Trace == nil {
+		return nil, false
+	}
+	return result.Trace, true
+}
+
+// PluginStrategy delegates scheduling decisions to the "next_node",
+// "random_boolean" and "random_integer" methods of a plugin
+type PluginStrategy struct {
+	plugin *Plugin
+}
+
+var _ Strategy = &PluginStrategy{}
+
+func NewPluginStrategy(plugin *Plugin) *PluginStrategy {
+	return &PluginStrategy{plugin: plugin}
+}
+
+func (p *PluginStrategy) GetNextNode(available []uint64) uint64 {
+	var node uint64
+	if err := p.plugin.Call("next_node", map[string]interface{}{"available": available}, &node); err != nil {
+		panic(fmt.Sprintf("error querying strategy plugin: %s", err))
+	}
+	return node
+}
+
+func (p *PluginStrategy) GetRandomBoolean() bool {
+	var choice bool
+	if err := p.plugin.Call("random_boolean", nil, &choice); err != nil {
+		panic(fmt.Sprintf("error querying strategy plugin: %s", err))
+	}
+	return choice
+}
+
+func (p *PluginStrategy) GetRandomInteger(max int) int {
+	var choice int
+	if err := p.plugin.Call("random_integer", map[string]interface{}{"max": max}, &choice); err != nil {
+		panic(fmt.Sprintf("error querying strategy plugin: %s", err))
+	}
+	return choice
+}
+
+type pluginNodeState struct {
+	ID        uint64
+	RaftState string
+	Term      uint64
+	Commit    uint64
+	Lead      uint64
+	Log       []pluginEntry
+}
+
+type pluginEntry struct {
+	Term  uint64
+	Index uint64
+	Data  string
+}
+
+// PluginChecker delegates the invariant check to the "check" method of a plugin.
+// The plugin receives the status and log of every node and returns true if the
+// invariant holds.
+func PluginChecker(plugin *Plugin) Checker {
+	return func(re *RaftEnvironment) bool {
+		nodes := make([]pluginNodeState, 0, len(re.curStates))
+		for id, state := range re.curStates {
+			node := pluginNodeState{
+				ID:        id,
+				RaftState: state.RaftState.String(),
+				Term:      state.Term,
+				Commit:    state.Commit,
+				Lead:      state.Lead,
+				Log:       make([]pluginEntry, 0),
+			}
+			if storage, ok := re.storages[id]; ok {
+				first, _ := storage.FirstIndex()
+				last, _ := storage.LastIndex()
+				if entries, err := storage.Entries(first, last+1, 1<<30); err == nil {
+					for _, e := range entries {
+						node.Log = append(node.Log, pluginEntry{Term: e.Term, Index: e.Index, Data: string(e.Data)})
+					}
+				}
+			}
+			nodes = append(nodes, node)
+		}
+		var ok bool
+		if err := plugin.Call("check", map[string]interface{}{"nodes": nodes}, &ok); err != nil {
+			panic(fmt.Sprintf("error running checker plugin: %s", err))
+		}
+		return ok
+	}
+}
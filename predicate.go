@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/ds-testing-user/etcd-fuzzing/raft"
+)
+
+// Predicate is a named condition over the environment, typically mirroring a
+// predicate of the TLA+ specification. The campaign tracks which valuations of
+// the registered predicates have been observed.
+type Predicate struct {
+	Name string
+	Eval func(*RaftEnvironment) bool
+}
+
+// PredicateCoverage records the valuations of a set of predicates observed
+// after every step of the campaign
+type PredicateCoverage struct {
+	predicates []Predicate
+	valuations map[string]bool
+	// counts per predicate how often it evaluated to true and to false
+	trueCount  map[string]int
+	falseCount map[string]int
+}
+
+func NewPredicateCoverage(predicates []Predicate) *PredicateCoverage {
+	return &PredicateCoverage{
+		predicates: predicates,
+		valuations: make(map[string]bool),
+		trueCount:  make(map[string]int),
+		falseCount: make(map[string]int),
+	}
+}
+
+// Observe evaluates all predicates on the environment and returns true if the
+// valuation was not observed before
+func (p *PredicateCoverage) Observe(re *RaftEnvironment) bool {
+	if len(p.predicates) == 0 {
+		return false
+	}
+	var valuation strings.Builder
+	for _, pred := range p.predicates {
+		if pred.Eval(re) {
+			p.trueCount[pred.Name] += 1
+			valuation.WriteByte('1')
+		} else {
+			p.falseCount[pred.Name] += 1
+			valuation.WriteByte('0')
+		}
+	}
+	key := valuation.String()
+	if _, ok := p.valuations[key]; ok {
+		return false
+	}
+	p.valuations[key] = true
+	return true
+}
+
+// Valuations returns the number of distinct valuations observed
+func (p *PredicateCoverage) Valuations() int {
+	return len(p.valuations)
+}
+
+// Stats returns per predicate the number of observations where it held and
+// where it did not
+func (p *PredicateCoverage) Stats() map[string]map[string]int {
+	stats := make(map[string]map[string]int)
+	for _, pred := range p.predicates {
+		stats[pred.Name] = map[string]int{
+			"true":  p.trueCount[pred.Name],
+			"false": p.falseCount[pred.Name],
+		}
+	}
+	return stats
+}
+
+// ElectionSafetyNearlyViolated holds when two nodes consider themselves leader or
+// candidate in the same term
+func ElectionSafetyNearlyViolated() Predicate {
+	return Predicate{
+		Name: "ElectionSafetyNearlyViolated",
+		Eval: func(re *RaftEnvironment) bool {
+			terms := make(map[uint64]bool)
+			for _, s := range re.curStates {
+				if s.RaftState != raft.StateLeader && s.RaftState != raft.StateCandidate {
+					continue
+				}
+				if _, ok := terms[s.Term]; ok {
+					return true
+				}
+				terms[s.Term] = true
+			}
+			return false
+		},
+	}
+}
+
+// LogDivergence holds when the last indices of two logs differ by more than bound
+func LogDivergence(bound uint64) Predicate {
+	return Predicate{
+		Name: "LogDivergence",
+		Eval: func(re *RaftEnvironment) bool {
+			var minIndex, maxIndex uint64
+			first := true
+			for _, storage := range re.storages {
+				last, err := storage.LastIndex()
+				if err != nil {
+					continue
+				}
+				if first || last < minIndex {
+					minIndex = last
+				}
+				if first || last > maxIndex {
+					maxIndex = last
+				}
+				first = false
+			}
+			return maxIndex-minIndex > bound
+		},
+	}
+}
+
+// CommitLag holds when some node has committed more than bound entries ahead of another
+func CommitLag(bound uint64) Predicate {
+	return Predicate{
+		Name: "CommitLag",
+		Eval: func(re *RaftEnvironment) bool {
+			var minCommit, maxCommit uint64
+			first := true
+			for _, s := range re.curStates {
+				if first || s.Commit < minCommit {
+					minCommit = s.Commit
+				}
+				if first || s.Commit > maxCommit {
+					maxCommit = s.Commit
+				}
+				first = false
+			}
+			return maxCommit-minCommit > bound
+		},
+	}
+}
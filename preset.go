@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CampaignPreset is a ready-made campaign: budget, strategy, chaos profile and
+// invariants. Flags set explicitly on the command line take precedence.
+type CampaignPreset struct {
+	Description string
+	// Episodes and Horizon are the budget of the campaign
+	Episodes int
+	Horizon  int
+	Replicas int
+	Requests int
+	// ReseedFrequency is the number of episodes between two reseedings of the
+	// population
+	ReseedFrequency int
+	// Strategy is either "random" or "round-robin"
+	Strategy string
+	// CrashQuota, Replays, Scenarios, Partitions and Triggers are the chaos profile
+	CrashQuota int
+	Replays    int
+	Scenarios  []string
+	Partitions []string
+	Triggers   []string
+	// Invariants checks DefaultInvariants after every step
+	Invariants bool
+}
+
+// defaultReseedFrequency is the number of episodes between two reseedings of
+// the population without a preset
+const defaultReseedFrequency = 2000
+
+var CampaignPresets = map[string]CampaignPreset{
+	"quick-smoke": {
+		Description:     "Short campaign checking a change does not break the basics",
+		Episodes:        200,
+		Horizon:         30,
+		Replicas:        3,
+		Requests:        1,
+		ReseedFrequency: 50,
+		Strategy:        "random",
+		CrashQuota:      1,
+		Invariants:      true,
+	},
+	"nightly-etcd-raft": {
+		Description:     "Long campaign seeded with every scenario template",
+		Episodes:        20000,
+		Horizon:         100,
+		Replicas:        5,
+		Requests:        5,
+		ReseedFrequency: 2000,
+		Strategy:        "random",
+		CrashQuota:      2,
+		Replays:         2,
+		Scenarios:       []string{"leader-isolation", "duplicate-vote", "stale-snapshot", "partitioned-minority-write", "quorum-loss"},
+		Invariants:      true,
+	},
+	"membership-stress": {
+		Description:     "Nodes dropping out of and rejoining the quorum",
+		Episodes:        5000,
+		Horizon:         80,
+		Replicas:        5,
+		Requests:        3,
+		ReseedFrequency: 1000,
+		Strategy:        "random",
+		CrashQuota:      4,
+		Scenarios:       []string{"quorum-loss", "leader-isolation"},
+		Partitions:      []string{"1<->2@20:40", "3<->4@40:60"},
+		Invariants:      true,
+	},
+	"storage-faults": {
+		Description:     "Crash-restarts and stale messages against recovering storage",
+		Episodes:        5000,
+		Horizon:         80,
+		Replicas:        3,
+		Requests:        3,
+		ReseedFrequency: 1000,
+		Strategy:        "random",
+		CrashQuota:      4,
+		Replays:         4,
+		Scenarios:       []string{"stale-snapshot"},
+		Invariants:      true,
+	},
+}
+
+func PresetNames() []string {
+	names := make([]string, 0, len(CampaignPresets))
+	for name := range CampaignPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Preset returns the campaign preset with the given name
+func Preset(name string) (CampaignPreset, error) {
+	preset, ok := CampaignPresets[name]
+	if !ok {
+		return CampaignPreset{}, fmt.Errorf("unknown preset %s, available: %v", name, PresetNames())
+	}
+	return preset, nil
+}
+
+// NewStrategy returns the scheduling strategy of the preset for the given
+// number of replicas
+func (p CampaignPreset) NewStrategy(replicas int) (Strategy, error) {
+	switch p.Strategy {
+	case "", "random":
+		return NewRandomStrategy(), nil
+	case "round-robin":
+		return NewRoundRobinStrategy(replicas), nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %s", p.Strategy)
+	}
+}
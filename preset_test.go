@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestPresetsValidate(t *testing.T) {
+	for _, name := range PresetNames() {
+		preset, err := Preset(name)
+		if err != nil {
+			t.Fatalf("Failed to get preset %s: %s", name, err)
+		}
+		config := &FuzzerConfig{
+			Iterations: preset.Episodes,
+			Steps:      preset.Horizon,
+			RaftEnvironmentConfig: RaftEnvironmentConfig{
+				Replicas:      preset.Replicas,
+				ElectionTick:  20,
+				HeartbeatTick: 2,
+				TicksPerStep:  2,
+			},
+			MutPerTrace:     5,
+			NumberRequests:  preset.Requests,
+			CrashQuota:      preset.CrashQuota,
+			ReplayQuota:     preset.Replays,
+			MaxMessages:     10,
+			ReseedFrequency: preset.ReseedFrequency,
+		}
+		if err := config.validate(); err != nil {
+			t.Errorf("Preset %s has an invalid config: %s", name, err)
+		}
+		if _, err := preset.NewStrategy(preset.Replicas); err != nil {
+			t.Errorf("Preset %s has an invalid strategy: %s", name, err)
+		}
+		for _, scenario := range preset.Scenarios {
+			if _, err := InstantiateScenario(scenario, DefaultScenarioParams(preset.Replicas, preset.Horizon)); err != nil {
+				t.Errorf("Preset %s has an invalid scenario: %s", name, err)
+			}
+		}
+		for _, rule := range preset.Partitions {
+			if _, err := ParsePartition(rule); err != nil {
+				t.Errorf("Preset %s has an invalid partition: %s", name, err)
+			}
+		}
+		for _, rule := range preset.Triggers {
+			if _, err := ParseTrigger(rule); err != nil {
+				t.Errorf("Preset %s has an invalid trigger: %s", name, err)
+			}
+		}
+	}
+}
+
+func TestConfigValidateReseedFrequency(t *testing.T) {
+	config := &FuzzerConfig{
+		Iterations:            10,
+		Steps:                 10,
+		RaftEnvironmentConfig: RaftEnvironmentConfig{Replicas: 3},
+		MaxMessages:           10,
+	}
+	if err := config.validate(); err == nil {
+		t.Errorf("Expected a zero ReseedFrequency to be rejected")
+	}
+	config.ReseedFrequency = 10
+	if err := config.validate(); err != nil {
+		t.Errorf("Unexpected validation error: %s", err)
+	}
+}
@@ -0,0 +1,171 @@
+package pubsub
+
+import (
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// AckModeAttribute is the reserved attribute through which a publisher requests
+// how the receiving client settles a message, overriding the client AckMode
+const AckModeAttribute = "fuzz-ack-mode"
+
+// Values of AckModeAttribute
+const (
+	// AckAttrAck acks the message on receive
+	AckAttrAck = "ack"
+	// AckAttrNack nacks the message on receive so that it is redelivered
+	AckAttrNack = "nack"
+	// AckAttrManual leaves the message unsettled, the receiver acks or nacks it itself
+	AckAttrManual = "manual"
+	// AckAttrNackOnce nacks the first delivery and acks the redeliveries
+	AckAttrNackOnce = "nack-once"
+)
+
+// WithAckMode returns a copy of attributes requesting the given settlement mode
+func WithAckMode(attributes map[string]string, mode string) map[string]string {
+	attrs := copyAttributes(attributes)
+	attrs[AckModeAttribute] = mode
+	return attrs
+}
+
+// settle acks or nacks a received message according to its AckModeAttribute,
+// falling back to the client AckMode
+func (c *PubSubClient) settle(msg *pubsub.Message) {
+	d, _, _ := c.deliveries.lookup(msg.ID)
+	if ack, ok := ackDecision(msg.Attributes, d.count, c.ackMode); ok {
+		if !ack && c.nackDelay > 0 {
+			c.NackWithDelay(msg, c.nackDelay)
+			return
+		}
+		c.acknowledge(msg, ack)
+	}
+}
+
+// NackWithDelay nacks msg after roughly d instead of right away, so that its
+// redelivery is delayed. The message is held meanwhile, its lease extended up
+// to the ack deadline, or up to an hour with AckModeManual, and d is bounded by
+// that lease: the broker would redeliver the message at its end anyway. Nacks
+// still held back when the client is closed are issued by Close.
+func (c *PubSubClient) NackWithDelay(msg *pubsub.Message, d time.Duration) {
+	if max := c.maxExtension(); max > 0 && d > max {
+		d = max
+	}
+	if d <= 0 || c.ctx.Err() != nil {
+		c.acknowledge(msg, false)
+		return
+	}
+	c.delayMutex.Lock()
+	defer c.delayMutex.Unlock()
+	if c.delayedNacks == nil {
+		c.delayedNacks = make(map[*pubsub.Message]*time.Timer)
+	}
+	c.delayedNacks[msg] = time.AfterFunc(d, func() {
+		if c.takeDelayedNack(msg) {
+			c.acknowledge(msg, false)
+		}
+	})
+}
+
+// takeDelayedNack removes the delayed nack of msg, reporting whether it was
+// still pending, so that either its timer or Close issues it but not both
+func (c *PubSubClient) takeDelayedNack(msg *pubsub.Message) bool {
+	c.delayMutex.Lock()
+	defer c.delayMutex.Unlock()
+	if _, ok := c.delayedNacks[msg]; !ok {
+		return false
+	}
+	delete(c.delayedNacks, msg)
+	return true
+}
+
+// flushDelayedNacks stops the timers of the pending delayed nacks and issues
+// the nacks right away
+func (c *PubSubClient) flushDelayedNacks() {
+	c.delayMutex.Lock()
+	pending := c.delayedNacks
+	c.delayedNacks = nil
+	c.delayMutex.Unlock()
+	for msg, timer := range pending {
+		timer.Stop()
+		c.acknowledge(msg, false)
+	}
+}
+
+// ackDecision returns whether a message with the given attributes, delivered the
+// given number of times, is acked or nacked on receive. ok is false when the
+// message is left to the receiver.
+func ackDecision(attributes map[string]string, deliveries int, mode AckMode) (ack bool, ok bool) {
+	switch attributes[AckModeAttribute] {
+	case AckAttrAck:
+		return true, true
+	case AckAttrNack:
+		return false, true
+	case AckAttrManual:
+		return false, false
+	case AckAttrNackOnce:
+		return deliveries > 1, true
+	default:
+		if mode == AckModeManual {
+			return false, false
+		}
+		return mode == AckModeAck, true
+	}
+}
+
+// AckOutcome reports whether an ack or nack issued by the client took effect
+type AckOutcome struct {
+	MessageID string
+	Ack       bool // true for an ack, false for a nack
+	Status    pubsub.AcknowledgeStatus
+	Err       error
+	Latency   time.Duration // Time between issuing the ack/nack and learning its outcome
+}
+
+// Succeeded reports whether the broker accepted the ack or nack
+func (o AckOutcome) Succeeded() bool {
+	return o.Err == nil && o.Status == pubsub.AcknowledgeStatusSuccess
+}
+
+// acknowledge acks or nacks msg. The outcome is awaited in the background,
+// recorded in the delivery log, see LastAckOutcome, and reported to the ack
+// observer if configured. Outcomes are only meaningful on subscriptions with
+// exactly-once delivery; otherwise they always succeed.
+func (c *PubSubClient) acknowledge(msg *pubsub.Message, ack bool) {
+	start := time.Now()
+	var result *pubsub.AckResult
+	if ack {
+		result = msg.AckWithResult()
+	} else {
+		result = msg.NackWithResult()
+	}
+	atomic.AddInt64(&c.pendingAckResults, 1)
+	go func() {
+		status, err := result.Get(c.ctx)
+		atomic.AddInt64(&c.pendingAckResults, -1)
+		outcome := AckOutcome{
+			MessageID: msg.ID,
+			Ack:       ack,
+			Status:    status,
+			Err:       err,
+			Latency:   time.Since(start),
+		}
+		if c.deliveries != nil {
+			c.deliveries.recordOutcome(outcome)
+		}
+		if c.onAckOutcome != nil {
+			c.onAckOutcome(outcome)
+		}
+	}()
+}
+
+// LastAckOutcome returns the outcome of the last ack or nack the client issued
+// for msgID, so that schedule analysis does not assume every one took effect
+func (c *PubSubClient) LastAckOutcome(msgID string) (AckOutcome, bool) {
+	d, _, ok := c.deliveries.lookup(msgID)
+	if !ok || d.outcome == nil {
+		return AckOutcome{}, false
+	}
+	return *d.outcome, true
+}
@@ -0,0 +1,121 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestNackWithDelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	outcomes := make(chan AckOutcome, 1)
+	c := &PubSubClient{
+		ctx:          ctx,
+		ackMode:      AckModeNack,
+		nackDelay:    50 * time.Millisecond,
+		deliveries:   newDeliveryLog(),
+		onAckOutcome: func(o AckOutcome) { outcomes <- o },
+	}
+
+	start := time.Now()
+	c.settle(&pubsub.Message{ID: "delayed"})
+	select {
+	case o := <-outcomes:
+		if o.Ack || o.MessageID != "delayed" {
+			t.Errorf("Expected a nack of the message, got %+v", o)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("Expected the nack to be delayed by 50ms, got %v", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the message to be nacked")
+	}
+}
+
+func TestNackWithDelayBoundedByLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	outcomes := make(chan AckOutcome, 1)
+	c := &PubSubClient{
+		ctx:          ctx,
+		ackMode:      AckModeNack,
+		ackDeadline:  50 * time.Millisecond,
+		deliveries:   newDeliveryLog(),
+		onAckOutcome: func(o AckOutcome) { outcomes <- o },
+	}
+
+	c.NackWithDelay(&pubsub.Message{ID: "bounded"}, time.Hour)
+	select {
+	case o := <-outcomes:
+		if o.Ack || o.MessageID != "bounded" {
+			t.Errorf("Expected a nack of the message, got %+v", o)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the delay to be bounded by the ack deadline")
+	}
+}
+
+func TestFlushDelayedNacks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	outcomes := make(chan AckOutcome, 2)
+	c := &PubSubClient{
+		ctx:          ctx,
+		ackMode:      AckModeManual,
+		ackDeadline:  10 * time.Second,
+		deliveries:   newDeliveryLog(),
+		onAckOutcome: func(o AckOutcome) { outcomes <- o },
+	}
+
+	c.NackWithDelay(&pubsub.Message{ID: "held"}, time.Minute)
+	c.flushDelayedNacks()
+	select {
+	case o := <-outcomes:
+		if o.Ack || o.MessageID != "held" {
+			t.Errorf("Expected a nack of the message, got %+v", o)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the held message to be nacked on flush")
+	}
+	if len(c.delayedNacks) != 0 {
+		t.Errorf("Expected no pending delayed nacks, got %d", len(c.delayedNacks))
+	}
+	select {
+	case o := <-outcomes:
+		t.Errorf("Expected the message to be nacked once, got %+v", o)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLastAckOutcome(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &PubSubClient{
+		ctx:        ctx,
+		ackMode:    AckModeAck,
+		deliveries: newDeliveryLog(),
+	}
+
+	msg := &pubsub.Message{ID: "acked"}
+	c.deliveries.recordDelivery(msg, time.Now())
+	if _, ok := c.LastAckOutcome(msg.ID); ok {
+		t.Errorf("Expected no outcome before the message is settled")
+	}
+	c.settle(msg)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if o, ok := c.LastAckOutcome(msg.ID); ok {
+			if !o.Ack || !o.Succeeded() {
+				t.Errorf("Expected a successful ack, got %+v", o)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the outcome of the ack to be recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
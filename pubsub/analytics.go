@@ -0,0 +1,211 @@
+package pubsub
+
+import (
+	"sort"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// maxTrackedValues bounds the distinct values of an attribute counted by
+// TrafficStats
+const maxTrackedValues = 10000
+
+// TrafficStats accumulates per-topic analytics of messages: attribute
+// cardinalities, payload sizes and top talkers. It is fed live, e.g. from
+// ObserverClient.Observe, or offline from snapshots with AddSnapshot.
+type TrafficStats struct {
+	mutex  sync.Mutex
+	topics map[string]*topicTraffic
+}
+
+type topicTraffic struct {
+	messages   int
+	bytes      int64
+	minSize    int
+	maxSize    int
+	sizes      map[int]int
+	attributes map[string]*attributeValues
+	talkers    map[string]*Talker
+}
+
+type attributeValues struct {
+	messages int
+	values   map[string]bool
+	capped   bool
+}
+
+// TrafficReport is the analytics of the topics seen by TrafficStats
+type TrafficReport struct {
+	Topics []TopicTraffic
+}
+
+// TopicTraffic is the analytics of a topic
+type TopicTraffic struct {
+	TopicID  string
+	Messages int
+	Bytes    int64
+	// MinSize, MaxSize and MeanSize are payload sizes in bytes
+	MinSize  int
+	MaxSize  int
+	MeanSize float64
+	// Sizes is the payload size distribution in power of two buckets
+	Sizes []SizeBucket
+	// Attributes are the attribute keys by decreasing cardinality
+	Attributes []AttributeCardinality
+	// TopTalkers are the senders of the most messages, see SenderAttribute
+	TopTalkers []Talker
+}
+
+// SizeBucket counts the payloads of more than UpTo/2 and at most UpTo bytes
+type SizeBucket struct {
+	UpTo  int
+	Count int
+}
+
+// AttributeCardinality is the number of distinct values of an attribute key.
+// Capped reports that more than maxTrackedValues values were seen, Distinct is
+// then a lower bound.
+type AttributeCardinality struct {
+	Key      string
+	Messages int
+	Distinct int
+	Capped   bool
+}
+
+// Talker is the traffic of a sender, empty for messages without SenderAttribute
+type Talker struct {
+	Sender   string
+	Messages int
+	Bytes    int64
+}
+
+func NewTrafficStats() *TrafficStats {
+	return &TrafficStats{topics: make(map[string]*topicTraffic)}
+}
+
+// Add accounts msg seen on a topic
+func (s *TrafficStats) Add(topicID string, msg *pubsub.Message) {
+	s.add(topicID, msg.Data, msg.Attributes)
+}
+
+// AddSnapshot accounts the messages of a snapshot, see Backfill
+func (s *TrafficStats) AddSnapshot(snapshot *MemorySnapshot) {
+	for _, m := range snapshot.Messages {
+		s.add(snapshot.TopicID, m.Data, m.Attributes)
+	}
+}
+
+func (s *TrafficStats) add(topicID string, data []byte, attributes map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	t, ok := s.topics[topicID]
+	if !ok {
+		t = &topicTraffic{
+			minSize:    len(data),
+			sizes:      make(map[int]int),
+			attributes: make(map[string]*attributeValues),
+			talkers:    make(map[string]*Talker),
+		}
+		s.topics[topicID] = t
+	}
+
+	size := len(data)
+	t.messages++
+	t.bytes += int64(size)
+	if size < t.minSize {
+		t.minSize = size
+	}
+	if size > t.maxSize {
+		t.maxSize = size
+	}
+	t.sizes[sizeBucket(size)]++
+
+	for k, v := range attributes {
+		a, ok := t.attributes[k]
+		if !ok {
+			a = &attributeValues{values: make(map[string]bool)}
+			t.attributes[k] = a
+		}
+		a.messages++
+		if len(a.values) < maxTrackedValues {
+			a.values[v] = true
+		} else if !a.values[v] {
+			a.capped = true
+		}
+	}
+
+	sender := attributes[SenderAttribute]
+	talker, ok := t.talkers[sender]
+	if !ok {
+		talker = &Talker{Sender: sender}
+		t.talkers[sender] = talker
+	}
+	talker.Messages++
+	talker.Bytes += int64(size)
+}
+
+// sizeBucket returns the smallest power of two of at least size
+func sizeBucket(size int) int {
+	bucket := 1
+	for bucket < size {
+		bucket <<= 1
+	}
+	return bucket
+}
+
+// Report returns the analytics of every topic by topic ID, with up to top
+// talkers each
+func (s *TrafficStats) Report(top int) *TrafficReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	report := &TrafficReport{Topics: make([]TopicTraffic, 0, len(s.topics))}
+	for id, t := range s.topics {
+		tt := TopicTraffic{
+			TopicID:    id,
+			Messages:   t.messages,
+			Bytes:      t.bytes,
+			MinSize:    t.minSize,
+			MaxSize:    t.maxSize,
+			MeanSize:   float64(t.bytes) / float64(t.messages),
+			Sizes:      make([]SizeBucket, 0, len(t.sizes)),
+			Attributes: make([]AttributeCardinality, 0, len(t.attributes)),
+			TopTalkers: make([]Talker, 0, len(t.talkers)),
+		}
+		for upTo, count := range t.sizes {
+			tt.Sizes = append(tt.Sizes, SizeBucket{UpTo: upTo, Count: count})
+		}
+		sort.Slice(tt.Sizes, func(i, j int) bool { return tt.Sizes[i].UpTo < tt.Sizes[j].UpTo })
+
+		for k, a := range t.attributes {
+			tt.Attributes = append(tt.Attributes, AttributeCardinality{
+				Key:      k,
+				Messages: a.messages,
+				Distinct: len(a.values),
+				Capped:   a.capped,
+			})
+		}
+		sort.Slice(tt.Attributes, func(i, j int) bool {
+			if tt.Attributes[i].Distinct != tt.Attributes[j].Distinct {
+				return tt.Attributes[i].Distinct > tt.Attributes[j].Distinct
+			}
+			return tt.Attributes[i].Key < tt.Attributes[j].Key
+		})
+
+		for _, talker := range t.talkers {
+			tt.TopTalkers = append(tt.TopTalkers, *talker)
+		}
+		sort.Slice(tt.TopTalkers, func(i, j int) bool {
+			if tt.TopTalkers[i].Messages != tt.TopTalkers[j].Messages {
+				return tt.TopTalkers[i].Messages > tt.TopTalkers[j].Messages
+			}
+			return tt.TopTalkers[i].Sender < tt.TopTalkers[j].Sender
+		})
+		if top > 0 && len(tt.TopTalkers) > top {
+			tt.TopTalkers = tt.TopTalkers[:top]
+		}
+		report.Topics = append(report.Topics, tt)
+	}
+	sort.Slice(report.Topics, func(i, j int) bool { return report.Topics[i].TopicID < report.Topics[j].TopicID })
+	return report
+}
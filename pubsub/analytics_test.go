@@ -0,0 +1,48 @@
+package pubsub
+
+import (
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestTrafficStats(t *testing.T) {
+	stats := NewTrafficStats()
+	for i := 0; i < 10; i++ {
+		sender := "n1"
+		if i%5 == 0 {
+			sender = "n2"
+		}
+		stats.Add("raft", &pubsub.Message{
+			Data:       make([]byte, 100*(i+1)),
+			Attributes: map[string]string{SenderAttribute: sender, "term": fmt.Sprint(i), "kind": "append"},
+		})
+	}
+	stats.AddSnapshot(&MemorySnapshot{
+		TopicID:  "control",
+		Messages: []SnapshotMessage{{Attributes: map[string]string{ControlAttribute: ControlAbort}}},
+	})
+
+	report := stats.Report(1)
+	if len(report.Topics) != 2 || report.Topics[0].TopicID != "control" {
+		t.Fatalf("Expected the control and raft topics, got %+v", report.Topics)
+	}
+	raft := report.Topics[1]
+	if raft.Messages != 10 || raft.Bytes != 5500 || raft.MinSize != 100 || raft.MaxSize != 1000 {
+		t.Errorf("Unexpected totals %+v", raft)
+	}
+	buckets := 0
+	for _, b := range raft.Sizes {
+		buckets += b.Count
+	}
+	if buckets != 10 || raft.Sizes[0].UpTo != 128 || raft.Sizes[len(raft.Sizes)-1].UpTo != 1024 {
+		t.Errorf("Unexpected size distribution %+v", raft.Sizes)
+	}
+	if a := raft.Attributes[0]; a.Key != "term" || a.Distinct != 10 {
+		t.Errorf("Expected term to have the highest cardinality, got %+v", a)
+	}
+	if len(raft.TopTalkers) != 1 || raft.TopTalkers[0].Sender != "n1" || raft.TopTalkers[0].Messages != 8 {
+		t.Errorf("Expected n1 as top talker, got %+v", raft.TopTalkers)
+	}
+}
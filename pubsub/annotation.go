@@ -0,0 +1,43 @@
+package pubsub
+
+import (
+	"strings"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// AnnotationPrefix starts the reserved attributes through which the scheduler and
+// fault layers record what they did to a message
+const AnnotationPrefix = "fuzz-note-"
+
+// Well-known annotation keys
+const (
+	// AnnotationDelay is the delay applied to the message before delivery
+	AnnotationDelay = "delay"
+	// AnnotationDuplicate is the index of a duplicated copy, the original being 0
+	AnnotationDuplicate = "duplicate"
+	// AnnotationDecision identifies the scheduling decision that delivered the message
+	AnnotationDecision = "decision"
+	// AnnotationImpersonated is the true sender of a message whose sender was forged
+	AnnotationImpersonated = "impersonated"
+	// AnnotationMutated marks a message rewritten in flight by a PublishMutator
+	AnnotationMutated = "mutated"
+)
+
+// Annotate returns a copy of attributes carrying the annotation key=value
+func Annotate(attributes map[string]string, key, value string) map[string]string {
+	attrs := copyAttributes(attributes)
+	attrs[AnnotationPrefix+key] = value
+	return attrs
+}
+
+// Annotations returns the annotations carried by msg, keyed without the prefix
+func Annotations(msg *pubsub.Message) map[string]string {
+	annotations := make(map[string]string)
+	for k, v := range msg.Attributes {
+		if strings.HasPrefix(k, AnnotationPrefix) {
+			annotations[strings.TrimPrefix(k, AnnotationPrefix)] = v
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,20 @@
+package pubsub
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestAnnotations(t *testing.T) {
+	attrs := map[string]string{"type": "MsgApp"}
+	annotated := Annotate(Annotate(attrs, AnnotationDelay, "3"), AnnotationDecision, "7.12")
+	if len(attrs) != 1 {
+		t.Errorf("Expected the original attributes to be left untouched, got %v", attrs)
+	}
+
+	annotations := Annotations(&pubsub.Message{Attributes: annotated})
+	if len(annotations) != 2 || annotations[AnnotationDelay] != "3" || annotations[AnnotationDecision] != "7.12" {
+		t.Errorf("Unexpected annotations %v", annotations)
+	}
+}
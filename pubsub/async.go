@@ -0,0 +1,79 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// PublishResult is the handle of a message published with PublishAsync
+type PublishResult struct {
+	result *pubsub.PublishResult
+	// id and err are the outcome of a publish that completed before returning:
+	// one failing before the message is handed to the topic, or recorded in
+	// dry-run mode
+	id  string
+	err error
+}
+
+// Ready is closed once the outcome of the publish is known
+func (r *PublishResult) Ready() <-chan struct{} {
+	if r.result == nil {
+		ready := make(chan struct{})
+		close(ready)
+		return ready
+	}
+	return r.result.Ready()
+}
+
+// Get waits for the outcome of the publish and returns the server ID of the message
+func (r *PublishResult) Get(ctx context.Context) (string, error) {
+	if r.result == nil {
+		return r.id, r.err
+	}
+	id, err := r.result.Get(ctx)
+	if err != nil {
+		return "", publishError(ctx, err)
+	}
+	return id, nil
+}
+
+// PublishAsync hands a message to the topic and returns immediately, so that many
+// messages can be in flight at once. Call Get on the result for the server ID,
+// or Flush to wait for every pending publish. With a RateLimit it returns once the
+// limit allows the message.
+func (c *PubSubClient) PublishAsync(data []byte, attributes map[string]string) *PublishResult {
+	if err := c.validatePayload(data); err != nil {
+		return &PublishResult{err: err}
+	}
+	if err := c.limit(c.ctx, len(data)); err != nil {
+		return &PublishResult{err: err}
+	}
+	if c.checksums {
+		attributes = stampChecksum(data, attributes)
+	}
+	if c.dryRun != nil {
+		id, err := c.publishOn(c.ctx, c.topic, data, attributes, "")
+		return &PublishResult{id: id, err: err}
+	}
+	msg := &pubsub.Message{Data: data, Attributes: attributes}
+	msg, err := c.mutate(msg)
+	if err != nil {
+		return &PublishResult{err: err}
+	}
+	publishedAt := time.Now()
+	result := c.topic.Publish(c.ctx, msg)
+	go func() {
+		<-result.Ready()
+		if id, err := result.Get(c.ctx); err == nil {
+			c.deliveries.recordPublish(id, publishedAt)
+		}
+	}()
+	return &PublishResult{result: result}
+}
+
+// Flush blocks until every message published so far has been sent to the server
+func (c *PubSubClient) Flush() {
+	c.topic.Flush()
+}
@@ -0,0 +1,168 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// ReceiverScaling bounds the receive concurrency adapted by the client: the
+// number of goroutines pulling messages and the messages outstanding at once.
+// Concurrency is doubled while the delivery latency of the messages exceeds
+// TargetLatency, so the consumer catches up with a burst, and halved once it is
+// below half of it. It is also halved when messages are dropped because the
+// callers of ReceiveMessage do not keep up, since more concurrency would only
+// cause redeliveries. Each change restarts the receive stream, messages
+// delivered but not settled yet may be redelivered.
+type ReceiverScaling struct {
+	// MinGoroutines and MaxGoroutines bound the goroutines. Default: 1 and 10.
+	MinGoroutines int
+	MaxGoroutines int
+	// MinOutstanding and MaxOutstanding bound the outstanding messages. Default:
+	// 10 and 1000.
+	MinOutstanding int
+	MaxOutstanding int
+	// Interval between adjustments. Default: 5s.
+	Interval time.Duration
+	// TargetLatency is the delivery latency the scaling aims for. Default: 1s.
+	TargetLatency time.Duration
+	// OnScale is optionally called with the new concurrency after each change
+	OnScale func(goroutines, outstanding int)
+}
+
+// receiverScaler adapts the receive settings of a subscription from the
+// deliveries observed since the last adjustment
+type receiverScaler struct {
+	cfg         ReceiverScaling
+	mutex       sync.Mutex
+	goroutines  int
+	outstanding int
+	restart     func()
+
+	// Observed since the last adjustment
+	latencies time.Duration
+	delivered int
+	dropped   int
+}
+
+func newReceiverScaler(cfg *ReceiverScaling) *receiverScaler {
+	if cfg == nil {
+		return nil
+	}
+	s := &receiverScaler{cfg: *cfg}
+	if s.cfg.MinGoroutines <= 0 {
+		s.cfg.MinGoroutines = 1
+	}
+	if s.cfg.MaxGoroutines < s.cfg.MinGoroutines {
+		s.cfg.MaxGoroutines = 10
+		if s.cfg.MaxGoroutines < s.cfg.MinGoroutines {
+			s.cfg.MaxGoroutines = s.cfg.MinGoroutines
+		}
+	}
+	if s.cfg.MinOutstanding <= 0 {
+		s.cfg.MinOutstanding = 10
+	}
+	if s.cfg.MaxOutstanding < s.cfg.MinOutstanding {
+		s.cfg.MaxOutstanding = 1000
+		if s.cfg.MaxOutstanding < s.cfg.MinOutstanding {
+			s.cfg.MaxOutstanding = s.cfg.MinOutstanding
+		}
+	}
+	if s.cfg.Interval <= 0 {
+		s.cfg.Interval = 5 * time.Second
+	}
+	if s.cfg.TargetLatency <= 0 {
+		s.cfg.TargetLatency = time.Second
+	}
+	s.goroutines = s.cfg.MinGoroutines
+	s.outstanding = s.cfg.MinOutstanding
+	return s
+}
+
+// begin applies the current concurrency to sub before a receive stream starts.
+// restart ends the stream.
+func (s *receiverScaler) begin(sub *pubsub.Subscription, restart func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sub.ReceiveSettings.NumGoroutines = s.goroutines
+	sub.ReceiveSettings.MaxOutstandingMessages = s.outstanding
+	s.restart = restart
+}
+
+// observe records the delivery of msg, dropped if it could not be queued
+func (s *receiverScaler) observe(msg *pubsub.Message, at time.Time, dropped bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if dropped {
+		s.dropped++
+		return
+	}
+	if !msg.PublishTime.IsZero() {
+		s.latencies += at.Sub(msg.PublishTime)
+		s.delivered++
+	}
+}
+
+// adjust rescales from the deliveries observed since the last adjustment and
+// reports whether the concurrency changed
+func (s *receiverScaler) adjust() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	latencies, delivered, dropped := s.latencies, s.delivered, s.dropped
+	s.latencies, s.delivered, s.dropped = 0, 0, 0
+
+	goroutines, outstanding := s.goroutines, s.outstanding
+	switch {
+	case dropped > 0:
+		goroutines, outstanding = goroutines/2, outstanding/2
+	case delivered == 0:
+		return false
+	case latencies/time.Duration(delivered) > s.cfg.TargetLatency:
+		goroutines, outstanding = goroutines*2, outstanding*2
+	case latencies/time.Duration(delivered) < s.cfg.TargetLatency/2:
+		goroutines, outstanding = goroutines/2, outstanding/2
+	}
+	goroutines = clamp(goroutines, s.cfg.MinGoroutines, s.cfg.MaxGoroutines)
+	outstanding = clamp(outstanding, s.cfg.MinOutstanding, s.cfg.MaxOutstanding)
+	if goroutines == s.goroutines && outstanding == s.outstanding {
+		return false
+	}
+	s.goroutines, s.outstanding = goroutines, outstanding
+	if s.cfg.OnScale != nil {
+		s.cfg.OnScale(goroutines, outstanding)
+	}
+	return true
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// run adjusts the concurrency every interval until done, restarting the receive
+// stream on changes
+func (s *receiverScaler) run(done <-chan struct{}) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if s.adjust() {
+			s.mutex.Lock()
+			restart := s.restart
+			s.mutex.Unlock()
+			if restart != nil {
+				restart()
+			}
+		}
+	}
+}
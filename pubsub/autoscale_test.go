@@ -0,0 +1,57 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestReceiverScaler(t *testing.T) {
+	scales := 0
+	s := newReceiverScaler(&ReceiverScaling{
+		MaxGoroutines:  4,
+		MaxOutstanding: 40,
+		TargetLatency:  time.Second,
+		OnScale:        func(int, int) { scales++ },
+	})
+	now := time.Now()
+	deliver := func(latency time.Duration, dropped bool) {
+		s.observe(&pubsub.Message{PublishTime: now.Add(-latency)}, now, dropped)
+	}
+	expect := func(goroutines, outstanding int) {
+		t.Helper()
+		if s.goroutines != goroutines || s.outstanding != outstanding {
+			t.Errorf("Expected %d goroutines and %d outstanding, got %d and %d", goroutines, outstanding, s.goroutines, s.outstanding)
+		}
+	}
+	expect(1, 10)
+
+	// A burst delivered late scales up to the bounds
+	for i := 0; i < 3; i++ {
+		deliver(3*time.Second, false)
+		s.adjust()
+	}
+	expect(4, 40)
+
+	// Nothing delivered keeps the concurrency
+	if s.adjust() {
+		t.Error("Expected no change without deliveries")
+	}
+
+	// Drops scale down even while late
+	deliver(3*time.Second, false)
+	deliver(3*time.Second, true)
+	s.adjust()
+	expect(2, 20)
+
+	// Timely deliveries scale down to the bounds
+	deliver(100*time.Millisecond, false)
+	s.adjust()
+	deliver(100*time.Millisecond, false)
+	s.adjust()
+	expect(1, 10)
+	if scales != 4 {
+		t.Errorf("Expected 4 changes, got %d", scales)
+	}
+}
@@ -0,0 +1,182 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// MemorySnapshot is the state of a subscription in the format of the
+// MemoryBroker, so that a live situation can be frozen and debugged offline
+type MemorySnapshot struct {
+	TopicID        string
+	SubscriptionID string
+	AckDeadline    time.Duration
+	Filter         string `json:",omitempty"`
+	// Messages are the pending messages in delivery order
+	Messages []SnapshotMessage
+}
+
+// SnapshotMessage is a pending message of a MemorySnapshot
+type SnapshotMessage struct {
+	ID          string
+	Data        []byte
+	Attributes  map[string]string `json:",omitempty"`
+	PublishTime time.Time
+	// Deliveries is the number of times the message was delivered so far
+	Deliveries int
+}
+
+// Snapshot captures the pending messages of the broker, unsettled ones included
+func (b *MemoryBroker) Snapshot() *MemorySnapshot {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	s := &MemorySnapshot{
+		AckDeadline: b.ackDeadline,
+		Filter:      b.filterExpr,
+		Messages:    make([]SnapshotMessage, 0, len(b.ready)+len(b.outstanding)),
+	}
+	add := func(m *memoryMessage) {
+		s.Messages = append(s.Messages, SnapshotMessage{
+			ID:          m.id,
+			Data:        append([]byte(nil), m.data...),
+			Attributes:  copyAttributes(m.attributes),
+			PublishTime: m.published,
+			Deliveries:  m.deliveries,
+		})
+	}
+	for _, m := range b.ready {
+		add(m)
+	}
+	// Unsettled messages follow in publish order, ties broken by ID, so that
+	// snapshots of the same state are identical
+	outstanding := append([]*memoryMessage(nil), b.outstanding...)
+	sort.SliceStable(outstanding, func(i, j int) bool {
+		if !outstanding[i].published.Equal(outstanding[j].published) {
+			return outstanding[i].published.Before(outstanding[j].published)
+		}
+		return outstanding[i].id < outstanding[j].id
+	})
+	for _, m := range outstanding {
+		add(m)
+	}
+	return s
+}
+
+// RestoreMemoryBroker creates a MemoryBroker holding the messages of the
+// snapshot, with its ack deadline and filter. Later publishes get IDs after the
+// largest numeric ID of the snapshot.
+func RestoreMemoryBroker(cfg Config, s *MemorySnapshot) (*MemoryBroker, error) {
+	cfg.SubConfig = &SubscriptionConfig{AckDeadline: s.AckDeadline, Filter: s.Filter}
+	b, err := NewMemoryBroker(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range s.Messages {
+		if n, err := strconv.Atoi(m.ID); err == nil && n > b.nextID {
+			b.nextID = n
+		}
+		b.ready = append(b.ready, &memoryMessage{
+			id:         m.ID,
+			data:       append([]byte(nil), m.Data...),
+			attributes: copyAttributes(m.Attributes),
+			published:  m.PublishTime,
+			deliveries: m.Deliveries,
+		})
+	}
+	return b, nil
+}
+
+// Backfill snapshots the subscriptions of a project, with up to n of their
+// pending messages each, without consuming them. Only ProjectID and Credentials
+// of cfg are used.
+func Backfill(ctx context.Context, cfg Config, n int) ([]*MemorySnapshot, error) {
+	var opts []option.ClientOption
+	if cfg.Credentials != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.Credentials))
+	}
+	client, err := pubsub.NewClient(ctx, cfg.ProjectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pubsub client: %v", err)
+	}
+	defer client.Close()
+
+	snapshots := make([]*MemorySnapshot, 0)
+	it := client.Subscriptions(ctx)
+	for {
+		sub, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list subscriptions: %v", err)
+		}
+		if strings.HasPrefix(sub.ID(), peekPrefix) {
+			continue
+		}
+		subCfg, err := sub.Config(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config of subscription %s: %v", sub.ID(), err)
+		}
+		messages, err := peekSubscription(ctx, client, sub, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to backfill subscription %s: %v", sub.ID(), err)
+		}
+		s := &MemorySnapshot{
+			SubscriptionID: sub.ID(),
+			AckDeadline:    subCfg.AckDeadline,
+			Filter:         subCfg.Filter,
+			Messages:       make([]SnapshotMessage, 0, len(messages)),
+		}
+		if subCfg.Topic != nil {
+			s.TopicID = subCfg.Topic.ID()
+		}
+		for _, msg := range messages {
+			deliveries := 0
+			if msg.DeliveryAttempt != nil {
+				deliveries = *msg.DeliveryAttempt - 1
+			}
+			s.Messages = append(s.Messages, SnapshotMessage{
+				ID:          msg.ID,
+				Data:        msg.Data,
+				Attributes:  msg.Attributes,
+				PublishTime: msg.PublishTime,
+				Deliveries:  deliveries,
+			})
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+func SaveMemorySnapshot(filePath string, s *MemorySnapshot) error {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return nil
+}
+
+func LoadMemorySnapshot(filePath string) (*MemorySnapshot, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	s := &MemorySnapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %v", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,60 @@
+package pubsub
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// IdleBackoff returns the pause to add after the given number of consecutive
+// receives that timed out without a message
+type IdleBackoff func(idleReceives int) time.Duration
+
+// ExponentialIdleBackoff doubles the pause after each idle receive, starting at
+// initial and capped at max
+func ExponentialIdleBackoff(initial, max time.Duration) IdleBackoff {
+	return func(idleReceives int) time.Duration {
+		pause := initial
+		for i := 1; i < idleReceives && pause < max; i++ {
+			pause *= 2
+		}
+		if pause > max {
+			pause = max
+		}
+		return pause
+	}
+}
+
+// idle handles a receive that timed out: it reports the idle period and, if a
+// backoff is configured, keeps receiving for the backoff pause before giving
+// up. The pause receives through the active receive path, the continuous
+// receiver or a synchronous pull, after taking a peeked message if any, so a
+// message arriving during the pause is delivered as usual.
+func (c *PubSubClient) idle(match func(*pubsub.Message) bool) (*pubsub.Message, error) {
+	c.idleMutex.Lock()
+	c.idleReceives++
+	idleReceives := c.idleReceives
+	idleFor := time.Since(c.lastActive)
+	c.idleMutex.Unlock()
+
+	if c.onIdle != nil {
+		c.onIdle(idleFor)
+	}
+
+	if c.idleBackoff != nil {
+		if pause := c.idleBackoff(idleReceives); pause > 0 {
+			if msg := c.popPeeked(); msg != nil {
+				return c.deliverMatching(msg, true, match)
+			}
+			msg, err := c.next(pause)
+			if err != nil {
+				return nil, err
+			}
+			if msg != nil {
+				return c.deliverMatching(msg, true, match)
+			}
+		}
+	}
+	return nil, fmt.Errorf("timeout waiting for message")
+}
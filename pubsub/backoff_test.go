@@ -0,0 +1,58 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestExponentialIdleBackoff(t *testing.T) {
+	backoff := ExponentialIdleBackoff(10*time.Millisecond, 100*time.Millisecond)
+
+	expected := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		80 * time.Millisecond,
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+	for i, want := range expected {
+		if got := backoff(i + 1); got != want {
+			t.Errorf("Idle receive %d: expected pause %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestIdlePauseDelivers(t *testing.T) {
+	c := newTestClient(t, AckModeAck)
+	c.idleBackoff = func(int) time.Duration { return time.Second }
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c.messageChan <- &pubsub.Message{ID: "late"}
+	}()
+	msg, err := c.idle(nil)
+	if err != nil {
+		t.Fatalf("Failed to receive during the pause: %s", err)
+	}
+	if msg == nil || msg.ID != "late" {
+		t.Errorf("Expected the message arriving during the pause, got %v", msg)
+	}
+}
+
+func TestIdlePauseTakesPeeked(t *testing.T) {
+	c := newTestClient(t, AckModeAck)
+	c.synchronous = true
+	c.idleBackoff = func(int) time.Duration { return time.Second }
+	c.peeked = &pubsub.Message{ID: "peeked"}
+
+	msg, err := c.idle(nil)
+	if err != nil {
+		t.Fatalf("Failed to receive during the pause: %s", err)
+	}
+	if msg == nil || msg.ID != "peeked" {
+		t.Errorf("Expected the peeked message, got %v", msg)
+	}
+}
@@ -0,0 +1,120 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// MessageSpec describes a message to publish
+type MessageSpec struct {
+	Data       []byte
+	Attributes map[string]string
+	// OrderingKey requires MessageOrdering, see PublishOrderedMessage
+	OrderingKey string
+}
+
+// BatchError reports the messages of a batch that failed to publish. Errors has
+// one entry per message of the batch, nil for the ones that were published.
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d/%d messages failed to publish, first error: %v", failed, len(e.Errors), first)
+}
+
+// PublishMessages publishes many messages in one call. All messages are handed to
+// the topic at once and their results are collected concurrently. The returned
+// IDs are in the order of msgs, empty for the messages that failed, in which case
+// the error is a *BatchError. Messages failing schema validation are not published.
+func (c *PubSubClient) PublishMessages(msgs []MessageSpec, timeout time.Duration) ([]string, error) {
+	ctx := c.ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(c.ctx, timeout)
+		defer cancel()
+	}
+
+	for i, spec := range msgs {
+		if spec.OrderingKey != "" && !c.topic.EnableMessageOrdering {
+			return nil, fmt.Errorf("message %d: ordering key %s set but message ordering is not enabled", i, spec.OrderingKey)
+		}
+	}
+
+	ids := make([]string, len(msgs))
+	errs := make([]error, len(msgs))
+	failed := false
+	publishedAt := time.Now()
+	results := make([]*pubsub.PublishResult, len(msgs))
+	for i, spec := range msgs {
+		if err := c.validatePayload(spec.Data); err != nil {
+			errs[i] = err
+			failed = true
+			continue
+		}
+		attrs := spec.Attributes
+		if c.checksums {
+			attrs = stampChecksum(spec.Data, attrs)
+		}
+		if c.dryRun != nil {
+			if ids[i], errs[i] = c.publishOn(ctx, c.topic, spec.Data, attrs, spec.OrderingKey); errs[i] != nil {
+				failed = true
+			}
+			continue
+		}
+		msg := &pubsub.Message{Data: spec.Data, Attributes: attrs, OrderingKey: spec.OrderingKey}
+		msg, err := c.mutate(msg)
+		if err != nil {
+			errs[i] = err
+			failed = true
+			continue
+		}
+		results[i] = c.topic.Publish(ctx, msg)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, result *pubsub.PublishResult) {
+			defer wg.Done()
+			id, err := result.Get(ctx)
+			if err != nil {
+				err = publishError(ctx, err)
+				if key := msgs[i].OrderingKey; key != "" {
+					c.topic.ResumePublish(key)
+				}
+				mutex.Lock()
+				errs[i] = err
+				failed = true
+				mutex.Unlock()
+				return
+			}
+			ids[i] = id
+			c.deliveries.recordPublish(id, publishedAt)
+		}(i, result)
+	}
+	wg.Wait()
+
+	if failed {
+		return ids, &BatchError{Errors: errs}
+	}
+	return ids, nil
+}
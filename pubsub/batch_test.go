@@ -0,0 +1,14 @@
+package pubsub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBatchError(t *testing.T) {
+	err := &BatchError{Errors: []error{nil, errors.New("first"), errors.New("second")}}
+	if msg := err.Error(); !strings.HasPrefix(msg, "2/3 messages failed") || !strings.Contains(msg, "first") {
+		t.Errorf("Unexpected error message %q", msg)
+	}
+}
@@ -0,0 +1,19 @@
+package pubsub
+
+import (
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// Broker is the transport the harness exchanges messages over. PubSubClient talks
+// to Google Cloud PubSub or its emulator; other backends must pass RunConformance
+// so the harness behaves identically regardless of transport.
+type Broker interface {
+	PublishMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error)
+	ReceiveMessage(timeout time.Duration) (*pubsub.Message, error)
+	Close() error
+}
+
+var _ Broker = (*PubSubClient)(nil)
+var _ Broker = (*MemoryBroker)(nil)
@@ -0,0 +1,90 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Reference environment the hard-coded timeouts were tuned for
+const (
+	referenceRTT     = 20 * time.Millisecond
+	referenceStartup = 500 * time.Millisecond
+)
+
+// Calibration holds the measured speed of the environment and the factor by
+// which timeouts are scaled relative to the reference environment
+type Calibration struct {
+	BrokerRTT   time.Duration `json:"broker_rtt"`
+	StartupTime time.Duration `json:"startup_time"`
+	Factor      float64       `json:"factor"`
+}
+
+// NewCalibration derives the scaling factor from a broker round trip time and a
+// startup time. Environments faster than the reference keep a factor of 1.
+func NewCalibration(rtt, startup time.Duration) Calibration {
+	factor := 1.0
+	if f := float64(rtt) / float64(referenceRTT); f > factor {
+		factor = f
+	}
+	if f := float64(startup) / float64(referenceStartup); f > factor {
+		factor = f
+	}
+	return Calibration{
+		BrokerRTT:   rtt,
+		StartupTime: startup,
+		Factor:      factor,
+	}
+}
+
+// Scale returns the timeout d adjusted to the calibrated environment
+func (cal Calibration) Scale(d time.Duration) time.Duration {
+	if cal.Factor <= 1 {
+		return d
+	}
+	return time.Duration(float64(d) * cal.Factor)
+}
+
+// Record stores the calibration under the "calibration" key of the JSON run
+// manifest at path, creating the manifest if needed
+func (cal Calibration) Record(path string) error {
+	manifest := make(map[string]interface{})
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("failed to parse manifest: %v", err)
+		}
+	}
+	manifest["calibration"] = cal
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+	return nil
+}
+
+// Calibrate measures the broker round trip time as the median of samples
+// readiness probes and combines it with the given startup time of the system
+// under test, e.g. the time it took for the client to become ready
+func (c *PubSubClient) Calibrate(ctx context.Context, samples int, startup time.Duration) (Calibration, error) {
+	if samples < 1 {
+		samples = 1
+	}
+	rtts := make([]time.Duration, 0, samples)
+	for i := 0; i < samples; i++ {
+		rtt, err := c.RoundTripProbe(ctx)
+		if err != nil {
+			return Calibration{}, fmt.Errorf("failed to measure broker round trip: %v", err)
+		}
+		rtts = append(rtts, rtt)
+	}
+	sort.Slice(rtts, func(i, j int) bool {
+		return rtts[i] < rtts[j]
+	})
+	return NewCalibration(rtts[len(rtts)/2], startup), nil
+}
@@ -0,0 +1,28 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibrationScale(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rtt     time.Duration
+		startup time.Duration
+		timeout time.Duration
+	}{
+		{"Faster than reference", time.Millisecond, 100 * time.Millisecond, 5 * time.Second},
+		{"Slow broker", 4 * referenceRTT, referenceStartup, 20 * time.Second},
+		{"Slow startup", referenceRTT, 3 * referenceStartup, 15 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cal := NewCalibration(tc.rtt, tc.startup)
+			if got := cal.Scale(5 * time.Second); got != tc.timeout {
+				t.Errorf("Expected scaled timeout %v, got %v", tc.timeout, got)
+			}
+		})
+	}
+}
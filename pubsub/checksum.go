@@ -0,0 +1,90 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+const (
+	// ChecksumAttribute carries the CRC-32C checksum of the payload, stamped on publish
+	ChecksumAttribute = "fuzz-checksum"
+	// CorruptedAttribute marks a payload that a corruption fault broke on purpose
+	CorruptedAttribute = "fuzz-corrupted"
+)
+
+// ErrChecksumMismatch is returned when a received payload does not match its checksum
+// and the message was not intentionally corrupted
+var ErrChecksumMismatch = errors.New("payload checksum mismatch")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// payloadChecksum returns the checksum of data as stored in ChecksumAttribute
+func payloadChecksum(data []byte) string {
+	return fmt.Sprintf("%08x", crc32.Checksum(data, castagnoli))
+}
+
+// copyAttributes returns a copy of attrs that is safe to modify
+func copyAttributes(attrs map[string]string) map[string]string {
+	copied := make(map[string]string, len(attrs)+1)
+	for k, v := range attrs {
+		copied[k] = v
+	}
+	return copied
+}
+
+// stampChecksum returns a copy of attrs carrying the checksum of data
+func stampChecksum(data []byte, attrs map[string]string) map[string]string {
+	stamped := copyAttributes(attrs)
+	stamped[ChecksumAttribute] = payloadChecksum(data)
+	return stamped
+}
+
+// verifyChecksum checks the payload of msg against its checksum attribute.
+// Messages without a checksum are accepted as-is. A mismatch on a message carrying
+// CorruptedAttribute is expected and is left for the consumer to record.
+func verifyChecksum(msg *pubsub.Message) error {
+	expected, ok := msg.Attributes[ChecksumAttribute]
+	if !ok {
+		return nil
+	}
+	if payloadChecksum(msg.Data) == expected {
+		return nil
+	}
+	if _, corrupted := msg.Attributes[CorruptedAttribute]; corrupted {
+		return nil
+	}
+	return fmt.Errorf("message %s: %w", msg.ID, ErrChecksumMismatch)
+}
+
+// IsCorrupted reports whether msg was broken on purpose by a corruption fault
+func IsCorrupted(msg *pubsub.Message) bool {
+	_, ok := msg.Attributes[CorruptedAttribute]
+	return ok
+}
+
+// PublishCorruptedMessage publishes data with the checksum of the original payload
+// but a corrupted body, and marks it with CorruptedAttribute so receivers flag it
+// instead of treating it as transport corruption
+func (c *PubSubClient) PublishCorruptedMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
+	if _, ok := attributes[ControlAttribute]; ok {
+		return "", fmt.Errorf("control commands are not subject to corruption")
+	}
+	if err := c.allowFault("corruption"); err != nil {
+		return "", err
+	}
+	attrs := stampChecksum(data, attributes)
+	attrs[CorruptedAttribute] = "true"
+
+	corrupted := make([]byte, len(data))
+	copy(corrupted, data)
+	if len(corrupted) == 0 {
+		corrupted = append(corrupted, 0xff)
+	} else {
+		corrupted[len(corrupted)/2] ^= 0xff
+	}
+	return c.publish(corrupted, attrs, "", timeout)
+}
@@ -0,0 +1,66 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestChecksumVerification(t *testing.T) {
+	data := []byte("checksummed payload")
+	attrs := map[string]string{"key": "value"}
+
+	stamped := stampChecksum(data, attrs)
+	if _, ok := attrs[ChecksumAttribute]; ok {
+		t.Error("Expected caller attributes to be left untouched")
+	}
+	if stamped["key"] != "value" {
+		t.Errorf("Expected attribute value 'value', got '%s'", stamped["key"])
+	}
+
+	testCases := []struct {
+		name        string
+		data        []byte
+		attributes  map[string]string
+		expectError bool
+	}{
+		{
+			name:       "Intact payload",
+			data:       data,
+			attributes: stamped,
+		},
+		{
+			name:       "Payload without checksum",
+			data:       []byte("unstamped"),
+			attributes: map[string]string{},
+		},
+		{
+			name:        "Unintentionally corrupted payload",
+			data:        []byte("checksummed paylaod"),
+			attributes:  stamped,
+			expectError: true,
+		},
+		{
+			name: "Intentionally corrupted payload",
+			data: []byte("checksummed paylaod"),
+			attributes: map[string]string{
+				ChecksumAttribute:  stamped[ChecksumAttribute],
+				CorruptedAttribute: "true",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &pubsub.Message{ID: "test-id", Data: tc.data, Attributes: tc.attributes}
+			err := verifyChecksum(msg)
+			if tc.expectError && !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("Expected checksum mismatch, got %v", err)
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
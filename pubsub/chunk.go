@@ -0,0 +1,142 @@
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// Attributes sequencing the chunks of an oversized payload
+const (
+	ChunkIDAttribute    = "fuzz-chunk-id"
+	ChunkIndexAttribute = "fuzz-chunk-index"
+	ChunkCountAttribute = "fuzz-chunk-count"
+)
+
+// DefaultChunkSize leaves headroom for the attributes below the 10MB message size
+// limit of PubSub
+const DefaultChunkSize = 9 * 1000 * 1000
+
+// partialChunkTTL bounds how long the chunks of an incomplete payload are kept
+const partialChunkTTL = 5 * time.Minute
+
+// IsChunk reports whether msg is a chunk of an oversized payload
+func IsChunk(msg *pubsub.Message) bool {
+	_, ok := msg.Attributes[ChunkIDAttribute]
+	return ok
+}
+
+// splitPayload splits data in chunks of at most size bytes
+func splitPayload(data []byte, size int) [][]byte {
+	chunks := make([][]byte, 0, (len(data)+size-1)/size)
+	for len(data) > size {
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+	return append(chunks, data)
+}
+
+// publishChunked publishes a payload larger than the chunk size as a sequence of
+// chunk messages, each carrying the attributes of the message. It returns the ID
+// of the first chunk.
+func (c *PubSubClient) publishChunked(ctx context.Context, data []byte, attributes map[string]string, orderingKey string) (string, error) {
+	chunks := splitPayload(data, c.chunkSize)
+	chunkID := newID()
+	var first string
+	for i, chunk := range chunks {
+		attrs := copyAttributes(attributes)
+		attrs[ChunkIDAttribute] = chunkID
+		attrs[ChunkIndexAttribute] = strconv.Itoa(i)
+		attrs[ChunkCountAttribute] = strconv.Itoa(len(chunks))
+		id, err := c.publishOn(ctx, c.topic, chunk, attrs, orderingKey)
+		if err != nil {
+			return "", fmt.Errorf("chunk %d/%d: %v", i+1, len(chunks), err)
+		}
+		if i == 0 {
+			first = id
+		}
+	}
+	return first, nil
+}
+
+// partialPayload collects the chunks of a payload
+type partialPayload struct {
+	first    *pubsub.Message
+	chunks   [][]byte
+	received int
+	started  time.Time
+}
+
+// chunkAssembler reassembles the payloads split by publishChunked. Chunks may
+// arrive in any order; duplicates are ignored.
+type chunkAssembler struct {
+	mutex   sync.Mutex
+	partial map[string]*partialPayload
+}
+
+func newChunkAssembler() *chunkAssembler {
+	return &chunkAssembler{partial: make(map[string]*partialPayload)}
+}
+
+// reset drops the partially received payloads
+func (a *chunkAssembler) reset() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.partial = make(map[string]*partialPayload)
+}
+
+// add records a chunk and returns the reassembled message once all chunks of its
+// payload were received
+func (a *chunkAssembler) add(msg *pubsub.Message) (*pubsub.Message, bool, error) {
+	id := msg.Attributes[ChunkIDAttribute]
+	index, err1 := strconv.Atoi(msg.Attributes[ChunkIndexAttribute])
+	count, err2 := strconv.Atoi(msg.Attributes[ChunkCountAttribute])
+	if err1 != nil || err2 != nil || count <= 0 || index < 0 || index >= count {
+		return nil, false, fmt.Errorf("invalid chunk %s of message %s", msg.Attributes[ChunkIndexAttribute], id)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for pid, p := range a.partial {
+		if time.Since(p.started) > partialChunkTTL {
+			delete(a.partial, pid)
+		}
+	}
+	p, ok := a.partial[id]
+	if !ok {
+		p = &partialPayload{chunks: make([][]byte, count), started: time.Now()}
+		a.partial[id] = p
+	}
+	if len(p.chunks) != count {
+		return nil, false, fmt.Errorf("chunk count of message %s changed from %d to %d", id, len(p.chunks), count)
+	}
+	if p.chunks[index] != nil {
+		return nil, false, nil
+	}
+	p.chunks[index] = msg.Data
+	p.received++
+	if index == 0 {
+		p.first = msg
+	}
+	if p.received < count {
+		return nil, false, nil
+	}
+
+	delete(a.partial, id)
+	attrs := copyAttributes(p.first.Attributes)
+	delete(attrs, ChunkIDAttribute)
+	delete(attrs, ChunkIndexAttribute)
+	delete(attrs, ChunkCountAttribute)
+	return &pubsub.Message{
+		ID:          p.first.ID,
+		Data:        bytes.Join(p.chunks, nil),
+		Attributes:  attrs,
+		PublishTime: p.first.PublishTime,
+		OrderingKey: p.first.OrderingKey,
+	}, true, nil
+}
@@ -0,0 +1,52 @@
+package pubsub
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestChunkAssembler(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	chunks := splitPayload(data, 100)
+	if len(chunks) != 3 || len(chunks[2]) != 50 {
+		t.Fatalf("Expected chunks of 100, 100 and 50 bytes, got %d chunks", len(chunks))
+	}
+
+	a := newChunkAssembler()
+	// Chunks arrive out of order and duplicated
+	for n, i := range []int{2, 0, 2, 1} {
+		msg := &pubsub.Message{
+			ID:   "m" + strconv.Itoa(i),
+			Data: chunks[i],
+			Attributes: map[string]string{
+				"kind":              "snapshot",
+				ChunkIDAttribute:    "payload",
+				ChunkIndexAttribute: strconv.Itoa(i),
+				ChunkCountAttribute: "3",
+			},
+		}
+		full, complete, err := a.add(msg)
+		if err != nil {
+			t.Fatalf("Failed to add chunk %d: %v", i, err)
+		}
+		if complete != (n == 3) {
+			t.Fatalf("Chunk %d: expected complete %v", i, n == 3)
+		}
+		if complete {
+			if !bytes.Equal(full.Data, data) || full.ID != "m0" {
+				t.Errorf("Unexpected reassembled message %s of %d bytes", full.ID, len(full.Data))
+			}
+			if len(full.Attributes) != 1 || full.Attributes["kind"] != "snapshot" {
+				t.Errorf("Expected only the message attributes, got %v", full.Attributes)
+			}
+		}
+	}
+
+	invalid := &pubsub.Message{Attributes: map[string]string{ChunkIDAttribute: "x", ChunkIndexAttribute: "3", ChunkCountAttribute: "3"}}
+	if _, _, err := a.add(invalid); err == nil {
+		t.Error("Expected out of range chunk index to be rejected")
+	}
+}
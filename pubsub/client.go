@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -20,13 +21,89 @@ type PubSubClient struct {
 	ctx           context.Context
 	cancel        context.CancelFunc
 	ackMode       AckMode
+	nackDelay     time.Duration
+	drainAck      bool
+	ackDeadline   time.Duration
+	margin        time.Duration
+	checksums     bool
+	deliveries    *deliveryLog
+	onAckOutcome  func(AckOutcome)
+	schema        *topicSchema
+	limiter       *rateLimiter
+	chunkSize     int
+	chunks        *chunkAssembler
+
+	// Dedup IDs of recent publishes, with their server IDs, and deliveries
+	published *recentIDs
+	received  *recentIDs
+
+	// Messages recorded instead of published in dry-run mode
+	dryRun *dryRunLog
+
+	// In-flight mutation of published messages
+	mutation *mutation
+
+	// Adapts the receive concurrency, nil without ReceiverScaling
+	scaler *receiverScaler
+
+	// Subscribe handlers running at once
+	handlerConcurrency int
+
+	// Other topics published to with PublishTo, by topic ID
+	topics      map[string]*pubsub.Topic
+	topicsMutex sync.Mutex
+
+	// Acks and nacks whose outcome is awaited
+	pendingAckResults int64
+
+	// Nacks held back by NackWithDelay, issued right away on Close
+	delayedNacks map[*pubsub.Message]*time.Timer
+	delayMutex   sync.Mutex
+
+	// Fault injection interlock
+	runID      string
+	safety     SafetyPolicy
+	safetyOnce sync.Once
+	safetyErr  error
+
+	// Idle receive state
+	idleBackoff  IdleBackoff
+	onIdle       func(idle time.Duration)
+	idleReceives int
+	lastActive   time.Time
+	idleMutex    sync.Mutex
+
+	// Outstanding readiness probes by probe ID
+	probes     map[string]chan struct{}
+	probeMutex sync.Mutex
 
 	// Continuous receive state
 	receiverStarted bool
+	subscribed      bool
 	receiverMutex   sync.Mutex
 	messageChan     chan *pubsub.Message
 	errorChan       chan error
 	receiverOnce    sync.Once
+	overflowPolicy  OverflowPolicy
+	overflows       int64
+
+	// Message returned by PeekMessage and not received yet, signaled on
+	// peekReady once Messages is used
+	peeked    *pubsub.Message
+	peekReady chan struct{}
+	peekMutex sync.Mutex
+
+	// Synchronous pull state
+	synchronous     bool
+	pullMaxMessages int
+	pulled          []*pubsub.Message
+	pullSlot        chan struct{}
+	pullMutex       sync.Mutex
+
+	// Channels of Messages and Errors
+	messagesOut chan *ReceivedMessage
+	errorsOut   chan error
+	pumpOnce    sync.Once
 }
 
 // AckMode defines how messages should be acknowledged
@@ -37,6 +114,10 @@ const (
 	AckModeNack AckMode = iota
 	// AckModeAck indicates messages should be acknowledged (not redelivered)
 	AckModeAck
+	// AckModeManual leaves messages unsettled for the receiver to settle once
+	// processed, see ReceivedMessage. Messages it does not settle in time are
+	// redelivered, so a crashing receiver does not lose them.
+	AckModeManual
 )
 
 // SubscriptionConfig holds configuration for the subscription
@@ -49,6 +130,11 @@ type SubscriptionConfig struct {
 	// Default: 7 days.
 	RetentionDuration time.Duration
 
+	// RetainAckedMessages retains acked messages for RetentionDuration, so that
+	// SeekToTime can replay them. It only applies to subscriptions created by the
+	// client. Default: false.
+	RetainAckedMessages bool
+
 	// ExpirationPolicy specifies the policy for subscription expiration.
 	// Default: never expire.
 	ExpirationPolicy time.Duration
@@ -56,8 +142,71 @@ type SubscriptionConfig struct {
 	// Filter is a filter expression that restricts the messages delivered to
 	// the subscription. Default: no filter.
 	Filter string
+
+	// DeadlineMargin is subtracted from the ack deadline when deriving the
+	// deadline of per-message handler contexts. Default: 1s.
+	DeadlineMargin time.Duration
+
+	// MaxOutstandingMessages and MaxOutstandingBytes bound the messages the
+	// receiver holds unsettled, prefetched ones included. NumGoroutines is the
+	// number of goroutines pulling messages. Zero fields keep the library
+	// defaults: 1000 messages, 1GB and 10 goroutines. ReceiverScaling, Subscribe
+	// and SynchronousPull override the messages and goroutines.
+	MaxOutstandingMessages int
+	MaxOutstandingBytes    int
+	NumGoroutines          int
+
+	// RetryPolicy paces the redelivery of nacked messages. It only applies to
+	// subscriptions created by the client. Default: immediate redelivery.
+	RetryPolicy *RetryPolicy
 }
 
+// PublishConfig holds the batching configuration of the topic. Zero fields keep
+// the library defaults.
+type PublishConfig struct {
+	// DelayThreshold is the longest a message waits for its batch to fill.
+	// Default: 10ms.
+	DelayThreshold time.Duration
+
+	// CountThreshold is the number of messages that triggers sending a batch.
+	// Default: 100.
+	CountThreshold int
+
+	// ByteThreshold is the batch size in bytes that triggers sending it.
+	// Default: 1MB.
+	ByteThreshold int
+
+	// Timeout bounds the publish of a batch, retries included. Default: 60s.
+	Timeout time.Duration
+
+	// MaxOutstandingMessages caps the messages published but not yet sent to the
+	// server. Default: unlimited.
+	MaxOutstandingMessages int
+
+	// MaxOutstandingBytes caps the bytes published but not yet sent to the
+	// server. Default: unlimited.
+	MaxOutstandingBytes int
+
+	// FlowControl is the behavior once an outstanding limit is reached.
+	// Default: FlowControlBlock.
+	FlowControl FlowControlBehavior
+}
+
+// FlowControlBehavior is what publishing does once the outstanding limits of the
+// topic are reached
+type FlowControlBehavior int
+
+const (
+	// FlowControlBlock blocks publishing until outstanding messages are sent
+	FlowControlBlock FlowControlBehavior = iota
+	// FlowControlError fails publishing with ErrPublishFlowControl
+	FlowControlError
+)
+
+// ErrPublishFlowControl is returned when publishing exceeds the outstanding limits
+// of the topic with FlowControlError
+var ErrPublishFlowControl = errors.New("publish flow control limit exceeded")
+
 // Config holds the configuration for PubSubClient
 type Config struct {
 	ProjectID      string
@@ -66,6 +215,95 @@ type Config struct {
 	Credentials    string // Path to service account JSON file
 	AckMode        AckMode
 	SubConfig      *SubscriptionConfig // Optional subscription configuration
+	PubConfig      *PublishConfig      // Optional publish batching configuration
+	Checksums      bool                // Stamp payload checksums on publish and verify them on receive
+
+	// IdleBackoff optionally extends receives that time out on a quiet channel, so
+	// tight ReceiveMessage loops back off instead of spinning. Default: no backoff.
+	IdleBackoff IdleBackoff
+	// OnIdle is optionally called with the time since the last message whenever a
+	// receive times out
+	OnIdle func(idle time.Duration)
+
+	// OnAckOutcome is optionally called with the outcome of every ack and nack
+	// issued by the client, so recorders don't assume each one took effect. The
+	// outcomes are also recorded in the delivery log, see LastAckOutcome.
+	OnAckOutcome func(AckOutcome)
+
+	// ChunkSize is the payload size above which PublishMessage and
+	// PublishOrderedMessage split payloads in chunks, reassembled by
+	// ReceiveMessage. Default: DefaultChunkSize.
+	ChunkSize int
+
+	// Deduplicate stamps the messages published with PublishMessage with a dedup
+	// ID. A publish of an ID published before returns the earlier server ID, and
+	// ReceiveMessage drops redeliveries of an ID, e.g. after a publish that timed
+	// out but reached the server. The last DedupWindow IDs are remembered on each
+	// side. Default: 10000.
+	Deduplicate bool
+	DedupWindow int
+
+	// RateLimit optionally shapes the messages published with PublishMessage,
+	// PublishOrderedMessage and PublishAsync. Default: unlimited.
+	RateLimit *RateLimit
+
+	// Schema optionally attaches a schema to the topic
+	Schema *SchemaConfig
+
+	// MessageOrdering enables ordered delivery of the messages published with the
+	// same ordering key. It only applies to subscriptions created by the client.
+	MessageOrdering bool
+
+	// RunID labels the topic and subscription created by the client
+	RunID string
+	// Safety restricts the faults the client injects. Default: DefaultSafetyPolicy.
+	Safety *SafetyPolicy
+
+	// PublishMutator optionally rewrites published messages in flight, after
+	// checksums are stamped and payloads chunked, with probability MutationRate.
+	// The picks are drawn from MutationSeed. Mutation is a fault: the safety
+	// interlock fails the publishes it refuses to mutate.
+	PublishMutator PublishMutator
+	MutationRate   float64
+	MutationSeed   int64
+
+	// NackDelay delays the nacks the client issues according to the AckMode, see
+	// NackWithDelay, so that nacked messages are not redelivered in a tight loop.
+	// Bounded by the ack deadline. Default: nack right away.
+	NackDelay time.Duration
+
+	// DrainAck acks the messages taken by Drain whatever the AckMode, so that
+	// Drain can empty the backlog of a subscription whose messages are nacked.
+	// Default: Drain settles messages according to the AckMode.
+	DrainAck bool
+
+	// HandlerConcurrency bounds the Subscribe handlers running at once.
+	// Default: 10.
+	HandlerConcurrency int
+
+	// ReceiverScaling optionally adapts the receive concurrency to bursts of
+	// messages. Default: the library receive settings.
+	ReceiverScaling *ReceiverScaling
+
+	// DryRun validates, encodes and records published messages, see
+	// DryRunMessages, instead of sending them. The client does not connect to the
+	// server: no topic or subscription is created, schemas are not checked and
+	// receiving fails.
+	DryRun bool
+
+	// SynchronousPull receives with synchronous pulls of at most PullMaxMessages
+	// messages issued by ReceiveMessage, instead of a streaming receiver running
+	// in the background, so that deliveries follow the receives step by step. A
+	// pull waits for the messages of the previous one to be settled. Default: 1
+	// message.
+	SynchronousPull bool
+	PullMaxMessages int
+
+	// BufferSize is the number of received messages queued for ReceiveMessage,
+	// and Overflow what the receiver does with the deliveries that find the queue
+	// full, see Overflows. Default: 100 messages, nacking the overflow.
+	BufferSize int
+	Overflow   OverflowPolicy
 }
 
 // NewPubSubClient creates a new PubSubClient instance
@@ -75,6 +313,9 @@ func NewPubSubClient(cfg Config) (*PubSubClient, error) {
 	if cfg.Credentials != "" {
 		opts = append(opts, option.WithCredentialsFile(cfg.Credentials))
 	}
+	if cfg.DryRun {
+		opts = append(opts, option.WithoutAuthentication())
+	}
 
 	client, err := pubsub.NewClient(ctx, cfg.ProjectID, opts...)
 	if err != nil {
@@ -83,90 +324,395 @@ func NewPubSubClient(cfg Config) (*PubSubClient, error) {
 	}
 
 	topic := client.Topic(cfg.TopicID)
-	exists, err := topic.Exists(ctx)
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to check topic existence: %v", err)
-	}
-	if !exists {
-		topic, err = client.CreateTopic(ctx, cfg.TopicID)
+	if !cfg.DryRun {
+		exists, err := topic.Exists(ctx)
 		if err != nil {
 			cancel()
-			return nil, fmt.Errorf("failed to create topic: %v", err)
+			return nil, fmt.Errorf("failed to check topic existence: %v", err)
+		}
+		if !exists {
+			topicCfg := &pubsub.TopicConfig{}
+			if cfg.RunID != "" {
+				topicCfg.Labels = map[string]string{RunIDLabel: cfg.RunID}
+			}
+			topic, err = client.CreateTopicWithConfig(ctx, cfg.TopicID, topicCfg)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to create topic: %v", err)
+			}
 		}
 	}
 
-	sub := client.Subscription(cfg.SubscriptionID)
-	exists, err = sub.Exists(ctx)
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("failed to check subscription existence: %v", err)
-	}
-	if !exists {
-		subCfg := pubsub.SubscriptionConfig{
-			Topic: topic,
+	topic.EnableMessageOrdering = cfg.MessageOrdering
+	if cfg.PubConfig != nil {
+		if cfg.PubConfig.DelayThreshold > 0 {
+			topic.PublishSettings.DelayThreshold = cfg.PubConfig.DelayThreshold
+		}
+		if cfg.PubConfig.CountThreshold > 0 {
+			topic.PublishSettings.CountThreshold = cfg.PubConfig.CountThreshold
+		}
+		if cfg.PubConfig.ByteThreshold > 0 {
+			topic.PublishSettings.ByteThreshold = cfg.PubConfig.ByteThreshold
+		}
+		if cfg.PubConfig.Timeout > 0 {
+			topic.PublishSettings.Timeout = cfg.PubConfig.Timeout
+		}
+		if cfg.PubConfig.MaxOutstandingMessages > 0 || cfg.PubConfig.MaxOutstandingBytes > 0 {
+			flow := &topic.PublishSettings.FlowControlSettings
+			flow.MaxOutstandingMessages = cfg.PubConfig.MaxOutstandingMessages
+			flow.MaxOutstandingBytes = cfg.PubConfig.MaxOutstandingBytes
+			flow.LimitExceededBehavior = pubsub.FlowControlBlock
+			if cfg.PubConfig.FlowControl == FlowControlError {
+				flow.LimitExceededBehavior = pubsub.FlowControlSignalError
+			}
 		}
+	}
+
+	margin := defaultDeadlineMargin
+	if cfg.SubConfig != nil && cfg.SubConfig.DeadlineMargin > 0 {
+		margin = cfg.SubConfig.DeadlineMargin
+	}
 
-		// Apply custom subscription configuration if provided
-		if cfg.SubConfig != nil {
-			if cfg.SubConfig.AckDeadline > 0 {
-				subCfg.AckDeadline = cfg.SubConfig.AckDeadline
+	sub := client.Subscription(cfg.SubscriptionID)
+	ackDeadline := defaultAckDeadline
+	if cfg.DryRun {
+		if cfg.SubConfig != nil && cfg.SubConfig.AckDeadline > 0 {
+			ackDeadline = cfg.SubConfig.AckDeadline
+		}
+	} else {
+		exists, err := sub.Exists(ctx)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to check subscription existence: %v", err)
+		}
+		if exists {
+			subCfg, err := sub.Config(ctx)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to get subscription config: %v", err)
+			}
+			if subCfg.AckDeadline > 0 {
+				ackDeadline = subCfg.AckDeadline
 			}
-			if cfg.SubConfig.RetentionDuration > 0 {
-				subCfg.RetentionDuration = cfg.SubConfig.RetentionDuration
+		} else {
+			subCfg := pubsub.SubscriptionConfig{
+				Topic:                 topic,
+				EnableMessageOrdering: cfg.MessageOrdering,
+			}
+			if cfg.RunID != "" {
+				subCfg.Labels = map[string]string{RunIDLabel: cfg.RunID}
+			}
+
+			// Apply custom subscription configuration if provided
+			if cfg.SubConfig != nil {
+				if cfg.SubConfig.AckDeadline > 0 {
+					subCfg.AckDeadline = cfg.SubConfig.AckDeadline
+				}
+				if cfg.SubConfig.RetentionDuration > 0 {
+					subCfg.RetentionDuration = cfg.SubConfig.RetentionDuration
+				}
+				subCfg.RetainAckedMessages = cfg.SubConfig.RetainAckedMessages
+				if cfg.SubConfig.ExpirationPolicy > 0 {
+					subCfg.ExpirationPolicy = cfg.SubConfig.ExpirationPolicy
+				}
+				if cfg.SubConfig.Filter != "" {
+					subCfg.Filter = cfg.SubConfig.Filter
+				}
+				if policy := cfg.SubConfig.RetryPolicy; policy != nil {
+					if err := policy.validate(); err != nil {
+						cancel()
+						return nil, err
+					}
+					subCfg.RetryPolicy = policy.pubsub()
+				}
 			}
-			if cfg.SubConfig.ExpirationPolicy > 0 {
-				subCfg.ExpirationPolicy = cfg.SubConfig.ExpirationPolicy
+
+			if subCfg.AckDeadline > 0 {
+				ackDeadline = subCfg.AckDeadline
 			}
-			if cfg.SubConfig.Filter != "" {
-				subCfg.Filter = cfg.SubConfig.Filter
+			sub, err = client.CreateSubscription(ctx, cfg.SubscriptionID, subCfg)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to create subscription: %v", err)
 			}
 		}
+	}
+
+	if cfg.SubConfig != nil {
+		if cfg.SubConfig.MaxOutstandingMessages > 0 {
+			sub.ReceiveSettings.MaxOutstandingMessages = cfg.SubConfig.MaxOutstandingMessages
+		}
+		if cfg.SubConfig.MaxOutstandingBytes > 0 {
+			sub.ReceiveSettings.MaxOutstandingBytes = cfg.SubConfig.MaxOutstandingBytes
+		}
+		if cfg.SubConfig.NumGoroutines > 0 {
+			sub.ReceiveSettings.NumGoroutines = cfg.SubConfig.NumGoroutines
+		}
+	}
 
-		sub, err = client.CreateSubscription(ctx, cfg.SubscriptionID, subCfg)
+	var schema *topicSchema
+	if cfg.Schema != nil && !cfg.DryRun {
+		schema, err = attachSchema(ctx, cfg.ProjectID, opts, topic, cfg.Schema)
 		if err != nil {
 			cancel()
-			return nil, fmt.Errorf("failed to create subscription: %v", err)
+			return nil, err
 		}
 	}
 
+	chunkSize := DefaultChunkSize
+	if cfg.ChunkSize > 0 {
+		chunkSize = cfg.ChunkSize
+	}
+
+	var published, received *recentIDs
+	if cfg.Deduplicate {
+		published = newRecentIDs(cfg.DedupWindow)
+		received = newRecentIDs(cfg.DedupWindow)
+	}
+
+	handlerConcurrency := defaultHandlerConcurrency
+	if cfg.HandlerConcurrency > 0 {
+		handlerConcurrency = cfg.HandlerConcurrency
+	}
+
+	var dryRun *dryRunLog
+	if cfg.DryRun {
+		dryRun = &dryRunLog{}
+	}
+
+	pullMaxMessages := 1
+	if cfg.PullMaxMessages > 0 {
+		pullMaxMessages = cfg.PullMaxMessages
+	}
+
+	bufferSize := defaultBufferSize
+	if cfg.BufferSize > 0 {
+		bufferSize = cfg.BufferSize
+	}
+
+	safety := DefaultSafetyPolicy
+	if cfg.Safety != nil {
+		safety = *cfg.Safety
+	}
+
 	return &PubSubClient{
-		client:       client,
-		topic:        topic,
-		subscription: sub,
-		ctx:          ctx,
-		cancel:       cancel,
-		ackMode:      cfg.AckMode,
-		messageChan:  make(chan *pubsub.Message, 100), // Buffer for messages
-		errorChan:    make(chan error, 10),            // Buffer for errors
+		runID:              cfg.RunID,
+		safety:             safety,
+		client:             client,
+		topic:              topic,
+		subscription:       sub,
+		ctx:                ctx,
+		cancel:             cancel,
+		ackMode:            cfg.AckMode,
+		nackDelay:          cfg.NackDelay,
+		drainAck:           cfg.DrainAck,
+		ackDeadline:        ackDeadline,
+		margin:             margin,
+		checksums:          cfg.Checksums,
+		deliveries:         newDeliveryLog(),
+		onAckOutcome:       cfg.OnAckOutcome,
+		schema:             schema,
+		limiter:            newRateLimiter(cfg.RateLimit),
+		chunkSize:          chunkSize,
+		chunks:             newChunkAssembler(),
+		published:          published,
+		received:           received,
+		dryRun:             dryRun,
+		scaler:             newReceiverScaler(cfg.ReceiverScaling),
+		handlerConcurrency: handlerConcurrency,
+		synchronous:        cfg.SynchronousPull,
+		pullMaxMessages:    pullMaxMessages,
+		mutation:           newMutation(cfg.PublishMutator, cfg.MutationRate, cfg.MutationSeed),
+		topics:             make(map[string]*pubsub.Topic),
+		probes:             make(map[string]chan struct{}),
+		idleBackoff:        cfg.IdleBackoff,
+		onIdle:             cfg.OnIdle,
+		lastActive:         time.Now(),
+		messageChan:        make(chan *pubsub.Message, bufferSize), // Buffer for messages
+		errorChan:          make(chan error, 10),                   // Buffer for errors
+		overflowPolicy:     cfg.Overflow,
 	}, nil
 }
 
 // PublishMessage publishes a message to the configured topic with an optional timeout
 func (c *PubSubClient) PublishMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
-	msg := &pubsub.Message{
-		Data:       data,
-		Attributes: attributes,
+	ctx, cancel := c.publishContext(timeout)
+	defer cancel()
+	return c.PublishMessageCtx(ctx, data, attributes)
+}
+
+// PublishMessageCtx publishes a message to the configured topic until ctx is done,
+// so callers can abort an in-flight publish, e.g. when a fuzz iteration is
+// cancelled. The publish is also aborted when the client is closed.
+func (c *PubSubClient) PublishMessageCtx(ctx context.Context, data []byte, attributes map[string]string) (string, error) {
+	ctx, cancel := c.clientContext(ctx)
+	defer cancel()
+	if err := c.validatePayload(data); err != nil {
+		return "", err
+	}
+	if err := c.limit(ctx, len(data)); err != nil {
+		return "", err
+	}
+	if c.published != nil {
+		attributes = stampDedupID(attributes)
+		if id, ok := c.published.get(attributes[DedupIDAttribute]); ok {
+			return id, nil
+		}
+	}
+	if c.checksums {
+		attributes = stampChecksum(data, attributes)
+	}
+	var id string
+	var err error
+	if len(data) > c.chunkSize {
+		id, err = c.publishChunked(ctx, data, attributes, "")
+	} else {
+		id, err = c.publishOn(ctx, c.topic, data, attributes, "")
+	}
+	if err == nil && c.published != nil {
+		c.published.add(attributes[DedupIDAttribute], id)
+	}
+	return id, err
+}
+
+// PublishOrderedMessage publishes a message with an ordering key. With
+// MessageOrdering enabled, messages sharing a key are delivered in publish order.
+// A failed publish pauses the key in the library; it is resumed here so the
+// caller can retry.
+func (c *PubSubClient) PublishOrderedMessage(data []byte, attributes map[string]string, orderingKey string, timeout time.Duration) (string, error) {
+	if orderingKey != "" && !c.topic.EnableMessageOrdering {
+		return "", fmt.Errorf("ordering key %s set but message ordering is not enabled", orderingKey)
+	}
+	if err := c.validatePayload(data); err != nil {
+		return "", err
+	}
+	ctx, cancel := c.publishContext(timeout)
+	defer cancel()
+	if err := c.limit(ctx, len(data)); err != nil {
+		return "", err
+	}
+	if c.checksums {
+		attributes = stampChecksum(data, attributes)
+	}
+	var id string
+	var err error
+	if len(data) > c.chunkSize {
+		id, err = c.publishChunked(ctx, data, attributes, orderingKey)
+	} else {
+		id, err = c.publishOn(ctx, c.topic, data, attributes, orderingKey)
 	}
+	if err != nil && orderingKey != "" {
+		c.topic.ResumePublish(orderingKey)
+	}
+	return id, err
+}
 
-	ctx := c.ctx
+// publishContext returns the context of a publish with an optional timeout
+func (c *PubSubClient) publishContext(timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(c.ctx, timeout)
-		defer cancel()
+		return context.WithTimeout(c.ctx, timeout)
 	}
+	return context.WithCancel(c.ctx)
+}
 
-	result := c.topic.Publish(ctx, msg)
-	id, err := result.Get(ctx)
+// clientContext returns a context done when either ctx is done or the client is
+// closed
+func (c *PubSubClient) clientContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// publish sends a message to the configured topic as-is
+func (c *PubSubClient) publish(data []byte, attributes map[string]string, orderingKey string, timeout time.Duration) (string, error) {
+	ctx, cancel := c.publishContext(timeout)
+	defer cancel()
+	return c.publishOn(ctx, c.topic, data, attributes, orderingKey)
+}
+
+func (c *PubSubClient) publishOn(ctx context.Context, topic *pubsub.Topic, data []byte, attributes map[string]string, orderingKey string) (string, error) {
+	msg := &pubsub.Message{
+		Data:        data,
+		Attributes:  attributes,
+		OrderingKey: orderingKey,
+	}
+
+	msg, err := c.mutate(msg)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("timeout publishing message: %v", err)
+		return "", err
+	}
+
+	publishedAt := time.Now()
+	if c.dryRun != nil {
+		if err := validateMessage(msg); err != nil {
+			return "", err
 		}
-		return "", fmt.Errorf("failed to publish message: %v", err)
+		id := c.dryRun.record(topic.ID(), msg, publishedAt)
+		c.deliveries.recordPublish(id, publishedAt)
+		return id, nil
+	}
+	result := topic.Publish(ctx, msg)
+	id, err := result.Get(ctx)
+	if err != nil {
+		return "", publishError(ctx, err)
 	}
+	c.deliveries.recordPublish(id, publishedAt)
 	return id, nil
 }
 
+// publishError describes the failure to publish a message
+func publishError(ctx context.Context, err error) error {
+	if errors.Is(err, pubsub.ErrFlowControllerMaxOutstandingMessages) || errors.Is(err, pubsub.ErrFlowControllerMaxOutstandingBytes) {
+		return fmt.Errorf("%w: %v", ErrPublishFlowControl, err)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timeout publishing message: %v", err)
+	}
+	return fmt.Errorf("failed to publish message: %v", err)
+}
+
+// handleReceived queues a message delivered by the receive stream for ReceiveMessage
+func (c *PubSubClient) handleReceived(ctx context.Context, msg *pubsub.Message) {
+	if c.interceptProbe(msg) {
+		return
+	}
+	now := time.Now()
+	c.deliveries.recordDelivery(msg, now)
+
+	// Check if context is cancelled before sending
+	select {
+	case <-c.ctx.Done():
+		return
+	case <-ctx.Done():
+		return
+	default:
+	}
+
+	// Try to send message, but don't block if context is cancelled
+	select {
+	case c.messageChan <- msg:
+		// Message queued successfully
+		if c.scaler != nil {
+			c.scaler.observe(msg, now, false)
+		}
+	case <-c.ctx.Done():
+		// Client context cancelled, stop trying
+		return
+	case <-ctx.Done():
+		// Message context cancelled
+		return
+	default:
+		// Channel is full, handle the message according to the overflow policy
+		c.overflow(ctx, msg, now)
+	}
+}
+
 // startContinuousReceiver starts a background goroutine that continuously receives messages
 func (c *PubSubClient) startContinuousReceiver() {
 	c.receiverOnce.Do(func() {
@@ -181,55 +727,115 @@ func (c *PubSubClient) startContinuousReceiver() {
 				c.receiverMutex.Unlock()
 			}()
 
-			err := c.subscription.Receive(c.ctx, func(ctx context.Context, msg *pubsub.Message) {
-				// Check if context is cancelled before sending
-				select {
-				case <-c.ctx.Done():
-					return
-				case <-ctx.Done():
-					return
-				default:
-				}
+			// Stop extending leases at the ack deadline, so unsettled messages are
+			// redelivered when MessageContext says they are
+			c.subscription.ReceiveSettings.MaxExtension = c.maxExtension()
+			if c.scaler != nil {
+				go c.scaler.run(c.ctx.Done())
+			}
 
-				// Try to send message, but don't block if context is cancelled
-				select {
-				case c.messageChan <- msg:
-					// Message queued successfully
-				case <-c.ctx.Done():
-					// Client context cancelled, stop trying
-					return
-				case <-ctx.Done():
-					// Message context cancelled
+			for {
+				// A stream is restarted by the scaler to apply new receive settings
+				ctx, restart := context.WithCancel(c.ctx)
+				if c.scaler != nil {
+					c.scaler.begin(c.subscription, restart)
+				}
+				err := c.subscription.Receive(ctx, c.handleReceived)
+				restart()
+
+				// Only send error if context is not cancelled and channel is available
+				if err != nil && err != context.Canceled {
+					select {
+					case c.errorChan <- err:
+					case <-c.ctx.Done():
+					default:
+					}
 					return
-				default:
-					// Channel is full, drop the message and nack it
-					msg.Nack()
 				}
-			})
-
-			// Only send error if context is not cancelled and channel is available
-			if err != nil && err != context.Canceled {
-				select {
-				case c.errorChan <- err:
-				case <-c.ctx.Done():
-				default:
+				if c.ctx.Err() != nil || c.scaler == nil {
+					return
 				}
 			}
 		}()
 	})
 }
 
-// ReceiveMessage receives a single message from the subscription
+// ReceiveMessage receives a single message from the subscription, reassembling
+// chunked payloads and, with Deduplicate, dropping duplicates
 func (c *PubSubClient) ReceiveMessage(timeout time.Duration) (*pubsub.Message, error) {
+	return c.receiveMessage(timeout, nil)
+}
+
+// receiveMessage receives the first message satisfying match, any message when
+// match is nil
+func (c *PubSubClient) receiveMessage(timeout time.Duration, match func(*pubsub.Message) bool) (*pubsub.Message, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		msg, err := c.receive(time.Until(deadline), match)
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			// Keep receiving until a chunked payload is complete
+			msg, err = c.assemble(msg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if msg != nil && match != nil && !match(msg) {
+			// A reassembled payload cannot be nacked, its chunks were settled
+			c.BufferMessage(msg)
+			msg = nil
+		}
+		if msg != nil {
+			return msg, nil
+		}
+		if time.Until(deadline) <= 0 {
+			return nil, fmt.Errorf("timeout waiting for message")
+		}
+	}
+}
+
+// receive receives the next message, which may be a chunk. Messages not
+// satisfying match are nacked and skipped, returning nil; buffered ones stay
+// buffered.
+func (c *PubSubClient) receive(timeout time.Duration, match func(*pubsub.Message) bool) (*pubsub.Message, error) {
 	// Check buffer first
-	c.bufferMutex.Lock()
-	if len(c.messageBuffer) > 0 {
-		msg := c.messageBuffer[0]
-		c.messageBuffer = c.messageBuffer[1:]
-		c.bufferMutex.Unlock()
+	if msg := c.popBufferedMatching(match); msg != nil {
+		if err := c.verify(msg); err != nil {
+			return nil, err
+		}
 		return msg, nil
 	}
-	c.bufferMutex.Unlock()
+	if msg := c.popPeeked(); msg != nil {
+		return c.deliverMatching(msg, true, match)
+	}
+	msg, err := c.next(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return c.idle(match)
+	}
+	return c.deliverMatching(msg, true, match)
+}
+
+// next takes the next message from the receiver without settling it. It returns
+// nil when no message arrives within timeout.
+func (c *PubSubClient) next(timeout time.Duration) (*pubsub.Message, error) {
+	if c.dryRun != nil {
+		return nil, fmt.Errorf("receiving is not supported in dry-run mode")
+	}
+	c.receiverMutex.Lock()
+	subscribed := c.subscribed
+	c.receiverMutex.Unlock()
+	if subscribed {
+		return nil, fmt.Errorf("messages are dispatched to Subscribe handlers")
+	}
+
+	if c.synchronous {
+		return c.pull(timeout)
+	}
 
 	// Start continuous receiver if not already started
 	c.startContinuousReceiver()
@@ -243,15 +849,10 @@ func (c *PubSubClient) ReceiveMessage(timeout time.Duration) (*pubsub.Message, e
 		if !ok {
 			return nil, fmt.Errorf("message channel closed")
 		}
-		if msg != nil {
-			if c.ackMode == AckModeAck {
-				msg.Ack()
-			} else {
-				msg.Nack()
-			}
-			return msg, nil
+		if msg == nil {
+			return nil, fmt.Errorf("received nil message")
 		}
-		return nil, fmt.Errorf("received nil message")
+		return msg, nil
 	case err, ok := <-c.errorChan:
 		if !ok {
 			return nil, fmt.Errorf("error channel closed")
@@ -259,12 +860,92 @@ func (c *PubSubClient) ReceiveMessage(timeout time.Duration) (*pubsub.Message, e
 		return nil, fmt.Errorf("receiver error: %v", err)
 	case <-ctx.Done():
 		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("timeout waiting for message")
+			return nil, nil
 		}
 		return nil, ctx.Err()
 	}
 }
 
+// popBuffered returns the oldest message put back with BufferMessage, if any
+func (c *PubSubClient) popBuffered() *pubsub.Message {
+	return c.popBufferedMatching(nil)
+}
+
+// popBufferedMatching returns the oldest buffered message satisfying match, any
+// message when match is nil
+func (c *PubSubClient) popBufferedMatching(match func(*pubsub.Message) bool) *pubsub.Message {
+	c.bufferMutex.Lock()
+	defer c.bufferMutex.Unlock()
+	for i, msg := range c.messageBuffer {
+		if match == nil || match(msg) {
+			c.messageBuffer = append(c.messageBuffer[:i:i], c.messageBuffer[i+1:]...)
+			return msg
+		}
+	}
+	return nil
+}
+
+// assemble reassembles chunked payloads and drops duplicates. It returns nil
+// until the payload of a chunk is complete, and for duplicates.
+func (c *PubSubClient) assemble(msg *pubsub.Message) (*pubsub.Message, error) {
+	if IsChunk(msg) {
+		full, complete, err := c.chunks.add(msg)
+		if c.manual(msg) {
+			// Chunks are settled as they arrive, like with Subscribe
+			c.acknowledge(msg, err == nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !complete {
+			return nil, nil
+		}
+		if err := c.verify(full); err != nil {
+			return nil, err
+		}
+		msg = full
+	}
+	if c.duplicate(msg) {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+// deliver acknowledges a message taken from the message channel according to the
+// AckMode and hands it to the caller. Duplicates are acked whatever the AckMode,
+// so that they are not redelivered, and nil is returned.
+func (c *PubSubClient) deliver(msg *pubsub.Message, ok bool) (*pubsub.Message, error) {
+	if !ok {
+		return nil, fmt.Errorf("message channel closed")
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("received nil message")
+	}
+	c.idleMutex.Lock()
+	c.idleReceives = 0
+	c.lastActive = time.Now()
+	c.idleMutex.Unlock()
+
+	if !IsChunk(msg) && c.duplicate(msg) {
+		c.acknowledge(msg, true)
+		return nil, nil
+	}
+	c.settle(msg)
+	if err := c.verify(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// verify checks the payload checksum of msg when checksums are enabled
+func (c *PubSubClient) verify(msg *pubsub.Message) error {
+	// Chunks are verified once reassembled
+	if !c.checksums || IsChunk(msg) {
+		return nil
+	}
+	return verifyChecksum(msg)
+}
+
 // BufferMessage adds a message to the buffer for testing purposes
 func (c *PubSubClient) BufferMessage(msg *pubsub.Message) {
 	c.bufferMutex.Lock()
@@ -274,8 +955,10 @@ func (c *PubSubClient) BufferMessage(msg *pubsub.Message) {
 
 // Close closes the PubSub client and cleans up resources
 func (c *PubSubClient) Close() error {
+	c.flushDelayedNacks()
 	c.cancel()     // This will stop the continuous receiver
 	c.topic.Stop() // Stop accepting new publish requests
+	c.stopTopics()
 
 	// Wait for the receiver to shut down gracefully
 	for i := 0; i < 100; i++ { // Max 1 second wait
@@ -289,6 +972,9 @@ func (c *PubSubClient) Close() error {
 		time.Sleep(10 * time.Millisecond)
 	}
 
+	if c.schema != nil {
+		c.schema.client.Close()
+	}
 	if err := c.client.Close(); err != nil {
 		return fmt.Errorf("failed to close pubsub client: %v", err)
 	}
@@ -1,6 +1,9 @@
 package pubsub
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -186,3 +189,61 @@ func TestPubSubClientErrors(t *testing.T) {
 		t.Errorf("Expected success with negative timeout (treated as no timeout), got error: %v", err)
 	}
 }
+
+func TestPublishError(t *testing.T) {
+	ctx := context.Background()
+	err := publishError(ctx, fmt.Errorf("publish: %w", pubsub.ErrFlowControllerMaxOutstandingBytes))
+	if !errors.Is(err, ErrPublishFlowControl) {
+		t.Errorf("Expected a flow control error, got %v", err)
+	}
+	if err := publishError(ctx, errors.New("unavailable")); errors.Is(err, ErrPublishFlowControl) {
+		t.Errorf("Unexpected flow control error %v", err)
+	}
+}
+
+func TestReceiveSettings(t *testing.T) {
+	client, err := NewPubSubClient(Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-receive-settings",
+		SubscriptionID: "test-sub-receive-settings",
+		DryRun:         true,
+		SubConfig: &SubscriptionConfig{
+			MaxOutstandingMessages: 1,
+			MaxOutstandingBytes:    1024,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	settings := client.subscription.ReceiveSettings
+	if settings.MaxOutstandingMessages != 1 || settings.MaxOutstandingBytes != 1024 {
+		t.Errorf("Expected 1 message and 1024 bytes outstanding, got %d and %d", settings.MaxOutstandingMessages, settings.MaxOutstandingBytes)
+	}
+	// Zero fields are left for Receive to apply the library defaults to
+	if settings.NumGoroutines != 0 {
+		t.Errorf("Expected NumGoroutines to be left unset, got %d", settings.NumGoroutines)
+	}
+}
+
+// newTestClient returns a client without a subscription whose receiver is fed
+// by the test through messageChan and errorChan
+func newTestClient(t *testing.T, ackMode AckMode) *PubSubClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	c := &PubSubClient{
+		ctx:         ctx,
+		cancel:      cancel,
+		ackMode:     ackMode,
+		ackDeadline: 10 * time.Second,
+		deliveries:  newDeliveryLog(),
+		chunks:      newChunkAssembler(),
+		received:    newRecentIDs(0),
+		probes:      make(map[string]chan struct{}),
+		messageChan: make(chan *pubsub.Message, 4),
+		errorChan:   make(chan error, 1),
+	}
+	c.receiverOnce.Do(func() {})
+	return c
+}
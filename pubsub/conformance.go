@@ -0,0 +1,129 @@
+package pubsub
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// BrokerFactory creates the Broker under test for cfg. Every call carries fresh
+// topic and subscription IDs.
+type BrokerFactory func(cfg Config) (Broker, error)
+
+// ConformanceConfig tunes RunConformance to the speed of a backend
+type ConformanceConfig struct {
+	// AckDeadline configured on the subscriptions. Default: 10s, the PubSub minimum.
+	AckDeadline time.Duration
+	// ReceiveTimeout bounds each receive expected to return a message. Default: 5s.
+	ReceiveTimeout time.Duration
+}
+
+// RunConformance checks that a Broker backend delivers messages in publish order,
+// redelivers nacked messages, redelivers unsettled messages after the ack deadline
+// and applies subscription filters, the way the harness expects
+func RunConformance(t *testing.T, newBroker BrokerFactory, cc ConformanceConfig) {
+	if cc.AckDeadline <= 0 {
+		cc.AckDeadline = defaultAckDeadline
+	}
+	if cc.ReceiveTimeout <= 0 {
+		cc.ReceiveTimeout = 5 * time.Second
+	}
+
+	open := func(t *testing.T, mode AckMode, filter string) Broker {
+		t.Helper()
+		id := newID()[:8]
+		b, err := newBroker(Config{
+			ProjectID:      "conformance",
+			TopicID:        "conformance-topic-" + id,
+			SubscriptionID: "conformance-sub-" + id,
+			AckMode:        mode,
+			SubConfig: &SubscriptionConfig{
+				AckDeadline: cc.AckDeadline,
+				Filter:      filter,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create broker: %v", err)
+		}
+		t.Cleanup(func() { b.Close() })
+		return b
+	}
+	publish := func(t *testing.T, b Broker, data string, attrs map[string]string) string {
+		t.Helper()
+		id, err := b.PublishMessage([]byte(data), attrs, cc.ReceiveTimeout)
+		if err != nil {
+			t.Fatalf("Failed to publish %q: %v", data, err)
+		}
+		return id
+	}
+	receive := func(t *testing.T, b Broker) (string, string) {
+		t.Helper()
+		msg, err := b.ReceiveMessage(cc.ReceiveTimeout)
+		if err != nil {
+			t.Fatalf("Failed to receive message: %v", err)
+		}
+		return msg.ID, string(msg.Data)
+	}
+	quiet := func(t *testing.T, b Broker, d time.Duration) {
+		t.Helper()
+		if msg, err := b.ReceiveMessage(d); err == nil {
+			t.Errorf("Expected no delivery, got %q", msg.Data)
+		}
+	}
+
+	t.Run("ordering", func(t *testing.T) {
+		b := open(t, AckModeAck, "")
+		for i := 0; i < 5; i++ {
+			publish(t, b, strconv.Itoa(i), nil)
+		}
+		for i := 0; i < 5; i++ {
+			if _, data := receive(t, b); data != strconv.Itoa(i) {
+				t.Fatalf("Expected message %d, got %s", i, data)
+			}
+		}
+	})
+
+	t.Run("ack", func(t *testing.T) {
+		b := open(t, AckModeAck, "")
+		publish(t, b, "acked", nil)
+		receive(t, b)
+		quiet(t, b, cc.AckDeadline+cc.ReceiveTimeout)
+	})
+
+	t.Run("redelivery", func(t *testing.T) {
+		b := open(t, AckModeNack, "")
+		id := publish(t, b, "nacked", nil)
+		for i := 0; i < 2; i++ {
+			if got, _ := receive(t, b); got != id {
+				t.Fatalf("Expected delivery %d of %s, got %s", i+1, id, got)
+			}
+		}
+	})
+
+	t.Run("ack deadline", func(t *testing.T) {
+		b := open(t, AckModeAck, "")
+		id := publish(t, b, "unsettled", WithAckMode(nil, AckAttrManual))
+		start := time.Now()
+		receive(t, b)
+		msg, err := b.ReceiveMessage(cc.AckDeadline + cc.ReceiveTimeout)
+		if err != nil {
+			t.Fatalf("Expected redelivery after the ack deadline: %v", err)
+		}
+		if msg.ID != id {
+			t.Fatalf("Expected redelivery of %s, got %s", id, msg.ID)
+		}
+		if elapsed := time.Since(start); elapsed < cc.AckDeadline/2 {
+			t.Errorf("Redelivered after %v, before the %v ack deadline", elapsed, cc.AckDeadline)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		b := open(t, AckModeAck, `attributes.kind = "keep"`)
+		publish(t, b, "dropped", map[string]string{"kind": "drop"})
+		publish(t, b, "kept", map[string]string{"kind": "keep"})
+		if _, data := receive(t, b); data != "kept" {
+			t.Fatalf("Expected the filtered message, got %s", data)
+		}
+		quiet(t, b, cc.ReceiveTimeout)
+	})
+}
@@ -0,0 +1,170 @@
+package pubsub
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// ControlAttribute carries the name of a control command. Fault layers must leave
+// messages carrying it alone, so injected chaos cannot wedge the harness.
+const ControlAttribute = "fuzz-control"
+
+// ControlExpiresAttribute carries the expiry of a control command sent with a TTL,
+// in RFC 3339 format
+const ControlExpiresAttribute = "fuzz-control-expires"
+
+// Built-in control commands
+const (
+	// ControlAbort asks the harness to stop the campaign
+	ControlAbort = "abort"
+	// ControlSnapshot asks the harness to snapshot its state
+	ControlSnapshot = "snapshot"
+	// ControlExpired is the reply to a command received after its expiry, which
+	// was not executed. Its arguments are the name (command) and ID (command-id)
+	// of the expired command.
+	ControlExpired = "expired"
+)
+
+// controlPollTimeout bounds each receive of the control receiver, so that it
+// notices Close
+const controlPollTimeout = 500 * time.Millisecond
+
+// ControlCommand is a harness command received on the control channel. Args are
+// the other attributes of the message.
+type ControlCommand struct {
+	ID   string
+	Name string
+	Args map[string]string
+	// Expires is the expiry of the command, zero if it was sent without a TTL
+	Expires time.Time
+}
+
+// IsControl reports whether msg is a control command
+func IsControl(msg *pubsub.Message) bool {
+	_, ok := msg.Attributes[ControlAttribute]
+	return ok
+}
+
+// ControlConfig derives the configuration of the control channel from the data
+// channel configuration: a separate topic and subscription with acked delivery
+// and unbatched publishing
+func ControlConfig(cfg Config) Config {
+	cfg.TopicID += "-control"
+	cfg.SubscriptionID += "-control"
+	cfg.AckMode = AckModeAck
+	cfg.PubConfig = &PublishConfig{CountThreshold: 1}
+	cfg.IdleBackoff = nil
+	cfg.OnIdle = nil
+	return cfg
+}
+
+// ControlChannel exchanges harness-critical commands over a dedicated broker with
+// a dedicated receiver goroutine, so they are never queued behind data messages
+type ControlChannel struct {
+	broker   Broker
+	handlers map[string]func(ControlCommand)
+	mutex    sync.Mutex
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+// NewControlChannel starts receiving commands from broker, typically created with
+// ControlConfig
+func NewControlChannel(broker Broker) *ControlChannel {
+	cc := &ControlChannel{
+		broker:   broker,
+		handlers: make(map[string]func(ControlCommand)),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go cc.receive()
+	return cc
+}
+
+// Handle registers the handler of a command. Commands without a handler are dropped.
+func (cc *ControlChannel) Handle(name string, handler func(ControlCommand)) {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+	cc.handlers[name] = handler
+}
+
+// Send publishes a command with the given arguments
+func (cc *ControlChannel) Send(name string, args map[string]string, timeout time.Duration) (string, error) {
+	return cc.SendWithTTL(name, args, 0, timeout)
+}
+
+// SendWithTTL publishes a command that expires after ttl. A command received
+// after its expiry is not executed; the receiver replies with ControlExpired
+// instead, so the sender can tell it was dropped. A zero ttl never expires.
+func (cc *ControlChannel) SendWithTTL(name string, args map[string]string, ttl time.Duration, timeout time.Duration) (string, error) {
+	for _, reserved := range []string{ControlAttribute, ControlExpiresAttribute} {
+		if _, ok := args[reserved]; ok {
+			return "", fmt.Errorf("argument %s is reserved", reserved)
+		}
+	}
+	attrs := copyAttributes(args)
+	attrs[ControlAttribute] = name
+	if ttl > 0 {
+		attrs[ControlExpiresAttribute] = time.Now().Add(ttl).Format(time.RFC3339Nano)
+	}
+	return cc.broker.PublishMessage(nil, attrs, timeout)
+}
+
+func (cc *ControlChannel) receive() {
+	defer close(cc.stopped)
+	for {
+		select {
+		case <-cc.done:
+			return
+		default:
+		}
+		msg, err := cc.broker.ReceiveMessage(controlPollTimeout)
+		if err != nil || !IsControl(msg) {
+			continue
+		}
+		cmd := ControlCommand{
+			ID:   msg.ID,
+			Name: msg.Attributes[ControlAttribute],
+			Args: copyAttributes(msg.Attributes),
+		}
+		delete(cmd.Args, ControlAttribute)
+		if expires, ok := cmd.Args[ControlExpiresAttribute]; ok {
+			delete(cmd.Args, ControlExpiresAttribute)
+			cmd.Expires, _ = time.Parse(time.RFC3339Nano, expires)
+		}
+		if !cmd.Expires.IsZero() && time.Now().After(cmd.Expires) {
+			cc.replyExpired(cmd)
+			continue
+		}
+
+		cc.mutex.Lock()
+		handler, ok := cc.handlers[cmd.Name]
+		cc.mutex.Unlock()
+		if ok {
+			handler(cmd)
+		}
+	}
+}
+
+// replyExpired reports an expired command back to its sender
+func (cc *ControlChannel) replyExpired(cmd ControlCommand) {
+	if cmd.Name == ControlExpired {
+		return
+	}
+	cc.broker.PublishMessage(nil, map[string]string{
+		ControlAttribute: ControlExpired,
+		"command":        cmd.Name,
+		"command-id":     cmd.ID,
+	}, controlPollTimeout)
+}
+
+// Close stops the receiver and closes the broker
+func (cc *ControlChannel) Close() error {
+	cc.once.Do(func() { close(cc.done) })
+	<-cc.stopped
+	return cc.broker.Close()
+}
@@ -0,0 +1,74 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestControlChannel(t *testing.T) {
+	cfg := ControlConfig(Config{TopicID: "fuzz", SubscriptionID: "fuzz-sub", AckMode: AckModeNack})
+	if cfg.TopicID != "fuzz-control" || cfg.SubscriptionID != "fuzz-sub-control" || cfg.AckMode != AckModeAck || cfg.PubConfig.CountThreshold != 1 {
+		t.Errorf("Unexpected control config %+v", cfg)
+	}
+
+	broker, _ := NewMemoryBroker(cfg)
+	cc := NewControlChannel(broker)
+	defer cc.Close()
+
+	received := make(chan ControlCommand, 1)
+	cc.Handle(ControlSnapshot, func(cmd ControlCommand) { received <- cmd })
+	if _, err := cc.Send(ControlSnapshot, map[string]string{"path": "snap.json"}, time.Second); err != nil {
+		t.Fatalf("Failed to send command: %v", err)
+	}
+
+	select {
+	case cmd := <-received:
+		if cmd.Name != ControlSnapshot || cmd.Args["path"] != "snap.json" || len(cmd.Args) != 1 {
+			t.Errorf("Unexpected command %+v", cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Command not handled")
+	}
+
+	if _, err := cc.Send(ControlAbort, map[string]string{ControlAttribute: "x"}, time.Second); err == nil {
+		t.Error("Expected reserved argument to be rejected")
+	}
+}
+
+func TestControlChannelExpiry(t *testing.T) {
+	broker, _ := NewMemoryBroker(ControlConfig(Config{TopicID: "fuzz", SubscriptionID: "fuzz-sub"}))
+	cc := NewControlChannel(broker)
+	defer cc.Close()
+
+	executed := make(chan ControlCommand, 1)
+	expired := make(chan ControlCommand, 1)
+	cc.Handle(ControlSnapshot, func(cmd ControlCommand) { executed <- cmd })
+	cc.Handle(ControlExpired, func(cmd ControlCommand) { expired <- cmd })
+	id, err := cc.SendWithTTL(ControlSnapshot, nil, time.Nanosecond, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to send command: %v", err)
+	}
+
+	select {
+	case reply := <-expired:
+		if reply.Args["command"] != ControlSnapshot || reply.Args["command-id"] != id {
+			t.Errorf("Unexpected expiry reply %+v", reply)
+		}
+	case cmd := <-executed:
+		t.Fatalf("Expired command executed: %+v", cmd)
+	case <-time.After(2 * time.Second):
+		t.Fatal("No expiry reply")
+	}
+
+	if _, err := cc.SendWithTTL(ControlSnapshot, nil, time.Minute, time.Second); err != nil {
+		t.Fatalf("Failed to send command: %v", err)
+	}
+	select {
+	case cmd := <-executed:
+		if cmd.Expires.IsZero() || len(cmd.Args) != 0 {
+			t.Errorf("Unexpected command %+v", cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Command not handled before its expiry")
+	}
+}
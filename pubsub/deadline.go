@@ -0,0 +1,26 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+const (
+	// defaultAckDeadline is the ack deadline Pub/Sub applies when none is configured
+	defaultAckDeadline = 10 * time.Second
+	// defaultDeadlineMargin is kept free before the ack deadline for acking
+	defaultDeadlineMargin = time.Second
+)
+
+// MessageContext derives a context for handling msg whose deadline is the remaining
+// ack deadline of the message minus the configured margin, so handlers can bail out
+// before the broker redelivers the message behind their back
+func (c *PubSubClient) MessageContext(parent context.Context, msg *pubsub.Message) (context.Context, context.CancelFunc) {
+	received := time.Now()
+	if d, _, ok := c.deliveries.lookup(msg.ID); ok {
+		received = d.received
+	}
+	return context.WithDeadline(parent, received.Add(c.ackDeadline-c.margin))
+}
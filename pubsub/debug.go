@@ -0,0 +1,112 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// DebugSnapshot is a point-in-time view of the client internals, see DebugDump
+type DebugSnapshot struct {
+	Config   DebugConfig
+	Buffer   []BufferedMessage
+	Receiver DebugReceiver
+	// PendingAckResults counts acks and nacks whose outcome is still awaited
+	PendingAckResults int64
+	// TrackedMessages is the number of messages in the delivery log
+	TrackedMessages int
+	OpenProbes      int
+	IdleReceives    int
+	LastActive      time.Time
+	TakenAt         time.Time
+}
+
+type DebugConfig struct {
+	Topic          string
+	Subscription   string
+	AckMode        AckMode
+	AckDeadline    time.Duration
+	DeadlineMargin time.Duration
+	Checksums      bool
+}
+
+// BufferedMessage describes a message of the test buffer without its payload
+type BufferedMessage struct {
+	ID          string
+	Size        int
+	Attributes  map[string]string
+	PublishTime time.Time
+}
+
+type DebugReceiver struct {
+	Started bool
+	// Queued is the number of received messages waiting in the message channel,
+	// i.e. delivered by the broker but neither acked nor nacked yet
+	Queued         int
+	QueueCapacity  int
+	PendingErrors  int
+	ContextExpired bool
+	// Overflows counts the deliveries that found the message channel full
+	Overflows int64
+}
+
+// DebugDump returns a JSON snapshot of the client internals for diagnosing a
+// wedged campaign. Every piece of state is read under the lock guarding it, so
+// it is safe to call concurrently with receives, e.g. from a signal handler.
+func (c *PubSubClient) DebugDump() ([]byte, error) {
+	return json.MarshalIndent(c.debugSnapshot(), "", "  ")
+}
+
+func (c *PubSubClient) debugSnapshot() DebugSnapshot {
+	s := DebugSnapshot{
+		Config: DebugConfig{
+			AckMode:        c.ackMode,
+			AckDeadline:    c.ackDeadline,
+			DeadlineMargin: c.margin,
+			Checksums:      c.checksums,
+		},
+		PendingAckResults: atomic.LoadInt64(&c.pendingAckResults),
+		TakenAt:           time.Now(),
+	}
+	if c.topic != nil {
+		s.Config.Topic = c.topic.ID()
+	}
+	if c.subscription != nil {
+		s.Config.Subscription = c.subscription.ID()
+	}
+
+	c.bufferMutex.Lock()
+	s.Buffer = make([]BufferedMessage, 0, len(c.messageBuffer))
+	for _, msg := range c.messageBuffer {
+		s.Buffer = append(s.Buffer, BufferedMessage{
+			ID:          msg.ID,
+			Size:        len(msg.Data),
+			Attributes:  copyAttributes(msg.Attributes),
+			PublishTime: msg.PublishTime,
+		})
+	}
+	c.bufferMutex.Unlock()
+
+	c.receiverMutex.Lock()
+	s.Receiver.Started = c.receiverStarted
+	c.receiverMutex.Unlock()
+	s.Receiver.Queued = len(c.messageChan)
+	s.Receiver.QueueCapacity = cap(c.messageChan)
+	s.Receiver.Overflows = atomic.LoadInt64(&c.overflows)
+	s.Receiver.PendingErrors = len(c.errorChan)
+	s.Receiver.ContextExpired = c.ctx != nil && c.ctx.Err() != nil
+
+	c.idleMutex.Lock()
+	s.IdleReceives = c.idleReceives
+	s.LastActive = c.lastActive
+	c.idleMutex.Unlock()
+
+	c.probeMutex.Lock()
+	s.OpenProbes = len(c.probes)
+	c.probeMutex.Unlock()
+
+	if c.deliveries != nil {
+		s.TrackedMessages = c.deliveries.size()
+	}
+	return s
+}
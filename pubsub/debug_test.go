@@ -0,0 +1,46 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestDebugDump(t *testing.T) {
+	c := &PubSubClient{
+		ackMode:     AckModeAck,
+		ackDeadline: 10 * time.Second,
+		margin:      time.Second,
+		deliveries:  newDeliveryLog(),
+		probes:      make(map[string]chan struct{}),
+		messageChan: make(chan *pubsub.Message, 4),
+		errorChan:   make(chan error, 1),
+	}
+	c.BufferMessage(&pubsub.Message{ID: "buffered", Data: []byte("abc"), Attributes: map[string]string{"k": "v"}})
+	c.messageChan <- &pubsub.Message{ID: "queued"}
+	c.deliveries.recordPublish("published", time.Now())
+
+	data, err := c.DebugDump()
+	if err != nil {
+		t.Fatalf("Failed to dump client state: %v", err)
+	}
+	var s DebugSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Failed to parse dump: %v", err)
+	}
+
+	if len(s.Buffer) != 1 || s.Buffer[0].ID != "buffered" || s.Buffer[0].Size != 3 {
+		t.Errorf("Expected the buffered message metadata, got %+v", s.Buffer)
+	}
+	if s.Receiver.Queued != 1 || s.Receiver.QueueCapacity != 4 {
+		t.Errorf("Expected 1 of 4 queued messages, got %d of %d", s.Receiver.Queued, s.Receiver.QueueCapacity)
+	}
+	if s.TrackedMessages != 1 {
+		t.Errorf("Expected 1 tracked message, got %d", s.TrackedMessages)
+	}
+	if s.Config.AckMode != AckModeAck || s.Config.AckDeadline != 10*time.Second {
+		t.Errorf("Unexpected config in dump: %+v", s.Config)
+	}
+}
@@ -0,0 +1,102 @@
+package pubsub
+
+import (
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// DedupIDAttribute carries the client-generated ID of a message published with
+// Deduplicate. Publishes and deliveries of the same ID are collapsed.
+const DedupIDAttribute = "fuzz-dedup-id"
+
+// defaultDedupWindow is the default number of IDs remembered on each side
+const defaultDedupWindow = 10000
+
+// NewDedupID returns a fresh dedup ID, to pass with WithDedupID to every attempt
+// of a publish that may be retried
+func NewDedupID() string {
+	return newID()
+}
+
+// WithDedupID returns a copy of attributes carrying the dedup ID
+func WithDedupID(attributes map[string]string, id string) map[string]string {
+	attrs := copyAttributes(attributes)
+	attrs[DedupIDAttribute] = id
+	return attrs
+}
+
+// recentIDs remembers the last size keys with a value, forgetting the oldest
+// first
+type recentIDs struct {
+	mutex sync.Mutex
+	size  int
+	ids   map[string]string
+	order []string
+	next  int
+}
+
+func newRecentIDs(size int) *recentIDs {
+	if size <= 0 {
+		size = defaultDedupWindow
+	}
+	return &recentIDs{size: size, ids: make(map[string]string), order: make([]string, 0, size)}
+}
+
+// reset forgets every ID
+func (r *recentIDs) reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.ids = make(map[string]string)
+	r.order = r.order[:0]
+	r.next = 0
+}
+
+func (r *recentIDs) get(key string) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	value, ok := r.ids[key]
+	return value, ok
+}
+
+// add remembers key and reports whether it was new
+func (r *recentIDs) add(key, value string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.ids[key]; ok {
+		return false
+	}
+	if len(r.order) < r.size {
+		r.order = append(r.order, key)
+	} else {
+		delete(r.ids, r.order[r.next])
+		r.order[r.next] = key
+		r.next = (r.next + 1) % r.size
+	}
+	r.ids[key] = value
+	return true
+}
+
+// stampDedupID returns attributes carrying a dedup ID, keeping the one set by
+// the caller
+func stampDedupID(attributes map[string]string) map[string]string {
+	if _, ok := attributes[DedupIDAttribute]; ok {
+		return attributes
+	}
+	return WithDedupID(attributes, NewDedupID())
+}
+
+// duplicate reports whether a received message carries a dedup ID that was
+// already delivered by another message, i.e. whether it was published again.
+// Redeliveries of the same message are not duplicates.
+func (c *PubSubClient) duplicate(msg *pubsub.Message) bool {
+	id, ok := msg.Attributes[DedupIDAttribute]
+	if c.received == nil || !ok {
+		return false
+	}
+	if c.received.add(id, msg.ID) {
+		return false
+	}
+	first, ok := c.received.get(id)
+	return ok && first != msg.ID
+}
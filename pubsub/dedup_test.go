@@ -0,0 +1,94 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestRecentIDs(t *testing.T) {
+	r := newRecentIDs(2)
+	if !r.add("a", "1") || !r.add("b", "2") {
+		t.Fatal("Expected new IDs to be added")
+	}
+	if r.add("a", "3") {
+		t.Error("Expected a to be a duplicate")
+	}
+	if id, ok := r.get("a"); !ok || id != "1" {
+		t.Errorf("Expected a to map to 1, got %s", id)
+	}
+	r.add("c", "4")
+	if _, ok := r.get("a"); ok {
+		t.Error("Expected the oldest ID to be forgotten")
+	}
+	if _, ok := r.get("b"); !ok {
+		t.Error("Expected b to be remembered")
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	c := &PubSubClient{received: newRecentIDs(0)}
+	attrs := stampDedupID(nil)
+	if stamped := stampDedupID(attrs); stamped[DedupIDAttribute] != attrs[DedupIDAttribute] {
+		t.Error("Expected the dedup ID of the caller to be kept")
+	}
+	if c.duplicate(&pubsub.Message{ID: "m1", Attributes: attrs}) {
+		t.Error("Expected the first delivery not to be a duplicate")
+	}
+	if !c.duplicate(&pubsub.Message{ID: "m2", Attributes: attrs}) {
+		t.Error("Expected a second message with the same dedup ID to be a duplicate")
+	}
+	if c.duplicate(&pubsub.Message{ID: "m1", Attributes: attrs}) {
+		t.Error("Expected a redelivery of the first message not to be a duplicate")
+	}
+	if c.duplicate(&pubsub.Message{ID: "m3"}) {
+		t.Error("Expected messages without dedup ID to be delivered")
+	}
+}
+
+func TestReceiveDuplicateAckModeNack(t *testing.T) {
+	outcomes := make(chan AckOutcome, 4)
+	c := newTestClient(t, AckModeNack)
+	c.onAckOutcome = func(o AckOutcome) { outcomes <- o }
+	outcome := func() AckOutcome {
+		select {
+		case o := <-outcomes:
+			return o
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the message to be settled")
+			return AckOutcome{}
+		}
+	}
+
+	attrs := stampDedupID(nil)
+	c.messageChan <- &pubsub.Message{ID: "m1", Attributes: attrs}
+	c.messageChan <- &pubsub.Message{ID: "m2", Attributes: attrs}
+	c.messageChan <- &pubsub.Message{ID: "m1", Attributes: attrs}
+
+	msg, err := c.ReceiveMessage(100 * time.Millisecond)
+	if err != nil || msg.ID != "m1" {
+		t.Fatalf("Expected m1, got %v, %v", msg, err)
+	}
+	if o := outcome(); o.Ack || o.MessageID != "m1" {
+		t.Errorf("Expected m1 to be nacked, got %+v", o)
+	}
+
+	// The duplicate is acked and skipped, the redelivery of m1 is received again
+	msg, err = c.ReceiveMessage(100 * time.Millisecond)
+	if err != nil || msg.ID != "m1" {
+		t.Fatalf("Expected the redelivery of m1, got %v, %v", msg, err)
+	}
+	// Outcomes are reported asynchronously, in any order
+	acks := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		o := outcome()
+		acks[o.MessageID] = o.Ack
+	}
+	if ack, ok := acks["m2"]; !ok || !ack {
+		t.Errorf("Expected the duplicate m2 to be acked, got %v", acks)
+	}
+	if ack, ok := acks["m1"]; !ok || ack {
+		t.Errorf("Expected the redelivery of m1 to be nacked, got %v", acks)
+	}
+}
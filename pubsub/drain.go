@@ -0,0 +1,77 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// drainQuietPeriod ends a drain once no message arrives for that long
+const drainQuietPeriod = time.Second
+
+// Drain receives until the backlog of the subscription is empty, that is until
+// no message arrives for drainQuietPeriod, so that fuzz iterations can clean up
+// the subscription between runs. Drained messages are settled, verified and
+// reassembled as with ReceiveMessage. With AckModeNack, nacked messages are
+// redelivered and keep the backlog from emptying unless DrainAck is set. When
+// the deadline of ctx comes before the quiet period, Drain returns the messages
+// drained so far once no message arrived until the deadline. When ctx ends while
+// messages keep arriving, they are returned with the error of ctx.
+func (c *PubSubClient) Drain(ctx context.Context) ([]*pubsub.Message, error) {
+	var drained []*pubsub.Message
+	for {
+		if err := ctx.Err(); err != nil {
+			return drained, err
+		}
+		var msg *pubsub.Message
+		var err error
+		if msg = c.popBuffered(); msg != nil {
+			err = c.verify(msg)
+		} else if msg = c.popPeeked(); msg != nil {
+			msg, err = c.drained(msg)
+		} else {
+			quiet := drainQuietPeriod
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < quiet {
+				quiet = time.Until(deadline)
+			}
+			var m *pubsub.Message
+			if m, err = c.next(quiet); err != nil {
+				return drained, err
+			}
+			if m == nil {
+				return drained, nil
+			}
+			msg, err = c.drained(m)
+		}
+		if err == nil && msg != nil {
+			msg, err = c.assemble(msg)
+		}
+		if err != nil {
+			return drained, err
+		}
+		if msg != nil {
+			drained = append(drained, msg)
+		}
+	}
+}
+
+// drained settles msg, taken from the receiver by Drain, and returns it unless it
+// is a duplicate. With DrainAck, msg is acked whatever the AckMode, except for
+// chunks left to the receiver, which assemble settles.
+func (c *PubSubClient) drained(msg *pubsub.Message) (*pubsub.Message, error) {
+	if !c.drainAck {
+		return c.deliver(msg, true)
+	}
+	duplicate := !IsChunk(msg) && c.duplicate(msg)
+	if !IsChunk(msg) || !c.manual(msg) {
+		c.acknowledge(msg, true)
+	}
+	if duplicate {
+		return nil, nil
+	}
+	if err := c.verify(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
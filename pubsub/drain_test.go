@@ -0,0 +1,123 @@
+package pubsub
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestDrain(t *testing.T) {
+	c := newTestClient(t, AckModeAck)
+
+	c.BufferMessage(&pubsub.Message{ID: "buffered"})
+	c.messageChan <- &pubsub.Message{ID: "first"}
+	c.messageChan <- &pubsub.Message{ID: "second"}
+	go func() {
+		// Arrives within the quiet period, so it is drained too
+		time.Sleep(drainQuietPeriod / 2)
+		c.messageChan <- &pubsub.Message{ID: "late"}
+	}()
+
+	drained, err := c.Drain(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to drain: %v", err)
+	}
+	var ids []string
+	for _, msg := range drained {
+		ids = append(ids, msg.ID)
+	}
+	if len(ids) != 4 || ids[0] != "buffered" || ids[1] != "first" || ids[2] != "second" || ids[3] != "late" {
+		t.Errorf("Unexpected drained messages %v", ids)
+	}
+
+	c.messageChan <- &pubsub.Message{ID: "pending"}
+	dctx, dcancel := context.WithTimeout(context.Background(), drainQuietPeriod/4)
+	defer dcancel()
+	start := time.Now()
+	drained, err = c.Drain(dctx)
+	if err != nil {
+		t.Errorf("Expected the drain to end at the deadline of its context, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= drainQuietPeriod/2 {
+		t.Errorf("Expected the drain to end at the deadline, took %s", elapsed)
+	}
+	if len(drained) != 1 || drained[0].ID != "pending" {
+		t.Errorf("Expected the message drained before the deadline, got %v", drained)
+	}
+}
+
+func TestDrainAckModeNack(t *testing.T) {
+	for _, drainAck := range []bool{false, true} {
+		outcomes := make(chan AckOutcome, 4)
+		c := newTestClient(t, AckModeNack)
+		c.drainAck = drainAck
+		c.onAckOutcome = func(o AckOutcome) { outcomes <- o }
+
+		c.messageChan <- &pubsub.Message{ID: "first"}
+		c.messageChan <- &pubsub.Message{ID: "second"}
+		drained, err := c.Drain(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to drain: %v", err)
+		}
+		if len(drained) != 2 {
+			t.Errorf("Expected 2 drained messages, got %d", len(drained))
+		}
+		for i := 0; i < 2; i++ {
+			select {
+			case o := <-outcomes:
+				if o.Ack != drainAck {
+					t.Errorf("Expected drained message %s to be settled with ack %v, got %v", o.MessageID, drainAck, o.Ack)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Expected the drained messages to be settled")
+			}
+		}
+	}
+}
+
+func TestDrainManualChunks(t *testing.T) {
+	outcomes := make(chan AckOutcome, 4)
+	c := newTestClient(t, AckModeManual)
+	c.drainAck = true
+	c.onAckOutcome = func(o AckOutcome) { outcomes <- o }
+
+	for i, part := range []string{"chunked ", "payload"} {
+		c.messageChan <- &pubsub.Message{
+			ID:   "chunk" + strconv.Itoa(i),
+			Data: []byte(part),
+			Attributes: map[string]string{
+				ChunkIDAttribute:    "payload",
+				ChunkIndexAttribute: strconv.Itoa(i),
+				ChunkCountAttribute: "2",
+			},
+		}
+	}
+	drained, err := c.Drain(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to drain: %v", err)
+	}
+	if len(drained) != 1 || string(drained[0].Data) != "chunked payload" {
+		t.Fatalf("Expected the reassembled payload, got %v", drained)
+	}
+	// Every chunk is settled exactly once
+	settled := make(map[string]int)
+	for i := 0; i < 2; i++ {
+		select {
+		case o := <-outcomes:
+			settled[o.MessageID]++
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the chunks to be settled")
+		}
+	}
+	select {
+	case o := <-outcomes:
+		settled[o.MessageID]++
+	case <-time.After(100 * time.Millisecond):
+	}
+	if settled["chunk0"] != 1 || settled["chunk1"] != 1 {
+		t.Errorf("Expected every chunk to be settled once, got %v", settled)
+	}
+}
@@ -0,0 +1,95 @@
+package pubsub
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// Limits of the server on published messages, checked in dry-run mode
+const (
+	maxMessageSize    = 10 << 20
+	maxAttributes     = 100
+	maxAttributeKey   = 256
+	maxAttributeValue = 1024
+)
+
+// DryRunMessage is a message recorded by a client in dry-run mode, as it would
+// have been sent: chunks of large payloads and stamped attributes included
+type DryRunMessage struct {
+	ID          string
+	TopicID     string
+	Data        []byte
+	Attributes  map[string]string
+	OrderingKey string
+	PublishTime time.Time
+}
+
+// dryRunLog records the messages of a client in dry-run mode
+type dryRunLog struct {
+	mutex    sync.Mutex
+	messages []DryRunMessage
+	nextID   int
+}
+
+func (l *dryRunLog) record(topicID string, msg *pubsub.Message, publishedAt time.Time) string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.nextID++
+	id := strconv.Itoa(l.nextID)
+	l.messages = append(l.messages, DryRunMessage{
+		ID:          id,
+		TopicID:     topicID,
+		Data:        append([]byte(nil), msg.Data...),
+		Attributes:  copyAttributes(msg.Attributes),
+		OrderingKey: msg.OrderingKey,
+		PublishTime: publishedAt,
+	})
+	return id
+}
+
+// validateMessage checks msg against the limits the server enforces on publish
+func validateMessage(msg *pubsub.Message) error {
+	if len(msg.Data) == 0 && len(msg.Attributes) == 0 {
+		return fmt.Errorf("message has neither data nor attributes")
+	}
+	if len(msg.Data) > maxMessageSize {
+		return fmt.Errorf("message of %d bytes exceeds %d bytes", len(msg.Data), maxMessageSize)
+	}
+	if len(msg.Attributes) > maxAttributes {
+		return fmt.Errorf("message has %d attributes, at most %d are allowed", len(msg.Attributes), maxAttributes)
+	}
+	for k, v := range msg.Attributes {
+		if k == "" || len(k) > maxAttributeKey {
+			return fmt.Errorf("attribute key %q must be 1 to %d bytes", k, maxAttributeKey)
+		}
+		if len(v) > maxAttributeValue {
+			return fmt.Errorf("value of attribute %s exceeds %d bytes", k, maxAttributeValue)
+		}
+	}
+	return nil
+}
+
+// DryRunMessages returns the messages recorded in dry-run mode, in publish order.
+// It is nil outside dry-run mode.
+func (c *PubSubClient) DryRunMessages() []DryRunMessage {
+	if c.dryRun == nil {
+		return nil
+	}
+	c.dryRun.mutex.Lock()
+	defer c.dryRun.mutex.Unlock()
+	return append([]DryRunMessage(nil), c.dryRun.messages...)
+}
+
+// ClearDryRunMessages discards the messages recorded in dry-run mode
+func (c *PubSubClient) ClearDryRunMessages() {
+	if c.dryRun == nil {
+		return
+	}
+	c.dryRun.mutex.Lock()
+	defer c.dryRun.mutex.Unlock()
+	c.dryRun.messages = nil
+}
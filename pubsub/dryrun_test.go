@@ -0,0 +1,56 @@
+package pubsub
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDryRun(t *testing.T) {
+	client, err := NewPubSubClient(Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-dry-run",
+		SubscriptionID: "test-sub-dry-run",
+		Checksums:      true,
+		ChunkSize:      4,
+		DryRun:         true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	id, err := client.PublishMessage([]byte("sma"), map[string]string{"node": "1"}, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if _, err := client.PublishMessage([]byte("chunked"), nil, time.Second); err != nil {
+		t.Fatalf("Failed to publish chunked payload: %v", err)
+	}
+	if _, err := client.PublishMessage(nil, map[string]string{"key": strings.Repeat("v", maxAttributeValue+1)}, time.Second); err == nil {
+		t.Error("Expected an oversized attribute to fail validation")
+	}
+
+	recorded := client.DryRunMessages()
+	if len(recorded) != 3 {
+		t.Fatalf("Expected the message and 2 chunks to be recorded, got %d", len(recorded))
+	}
+	first := recorded[0]
+	if first.ID != id || first.TopicID != "test-topic-dry-run" || string(first.Data) != "sma" {
+		t.Errorf("Unexpected recorded message %+v", first)
+	}
+	if _, ok := first.Attributes[ChecksumAttribute]; !ok {
+		t.Errorf("Expected the recorded message to carry its checksum, got %v", first.Attributes)
+	}
+	if _, ok := recorded[1].Attributes[ChunkIDAttribute]; !ok {
+		t.Errorf("Expected the chunks of the large payload, got %v", recorded[1].Attributes)
+	}
+
+	if _, err := client.ReceiveMessage(10 * time.Millisecond); err == nil {
+		t.Error("Expected receiving to fail in dry-run mode")
+	}
+	client.ClearDryRunMessages()
+	if n := len(client.DryRunMessages()); n != 0 {
+		t.Errorf("Expected no messages after clearing, got %d", n)
+	}
+}
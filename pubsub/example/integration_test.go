@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -65,17 +67,26 @@ func TestIntegrationWithEmulator(t *testing.T) {
 				},
 			}
 
+			start := time.Now()
 			client, err := pubsub.NewPubSubClient(cfg)
 			if err != nil {
 				t.Fatalf("Failed to create client: %v", err)
 			}
 			defer client.Close()
 
-			// Add a small delay to ensure the subscription is ready
-			time.Sleep(1 * time.Second)
+			// Wait until the subscription is delivering messages
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := client.WaitReady(ctx); err != nil {
+				t.Fatalf("Subscription not ready: %v", err)
+			}
+			cal, err := client.Calibrate(ctx, 3, time.Since(start))
+			if err != nil {
+				t.Fatalf("Failed to calibrate: %v", err)
+			}
 
 			// Publish message
-			msgID, err := client.PublishMessage(tc.data, tc.attributes, 5*time.Second)
+			msgID, err := client.PublishMessage(tc.data, tc.attributes, cal.Scale(5*time.Second))
 			if err != nil {
 				t.Fatalf("Failed to publish message: %v", err)
 			}
@@ -84,11 +95,14 @@ func TestIntegrationWithEmulator(t *testing.T) {
 			}
 
 			// Receive message
-			msg, err := client.ReceiveMessage(5 * time.Second)
+			msg, err := client.ReceiveMessage(cal.Scale(5 * time.Second))
 			if err != nil {
 				t.Fatalf("Failed to receive message: %v", err)
 			}
 
+			// Verify end-to-end delivery latency
+			client.AssertDeliveredWithin(t, msgID, cal.Scale(5*time.Second))
+
 			// Verify message contents
 			if string(msg.Data) != string(tc.data) {
 				t.Errorf("Expected message data %q, got %q", string(tc.data), string(msg.Data))
@@ -151,8 +165,12 @@ func TestIntegrationConcurrent(t *testing.T) {
 		receivedMessages[string(rune('A'+i))] = false
 	}
 
-	// Add longer delay to ensure subscription is fully ready
-	time.Sleep(2 * time.Second)
+	// Wait until the subscription is delivering messages
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.WaitReady(ctx); err != nil {
+		t.Fatalf("Subscription not ready: %v", err)
+	}
 
 	// Create a channel to signal when all messages are received
 	doneCh := make(chan struct{})
@@ -198,9 +216,6 @@ func TestIntegrationConcurrent(t *testing.T) {
 		}
 	}()
 
-	// Add a small delay to ensure receiver is ready
-	time.Sleep(500 * time.Millisecond)
-
 	// Publish messages after receiver is ready
 	for i := 0; i < messageCount; i++ {
 		msgData := []byte(string(rune('A' + i)))
@@ -245,3 +260,714 @@ func getMissingMessages(messages map[string]bool) []string {
 	}
 	return missing
 }
+
+func TestConformanceWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	pubsub.RunConformance(t, func(cfg pubsub.Config) (pubsub.Broker, error) {
+		return pubsub.NewPubSubClient(cfg)
+	}, pubsub.ConformanceConfig{})
+}
+
+func TestBatchPublishWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-batch",
+		SubscriptionID: "test-sub-batch",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	specs := make([]pubsub.MessageSpec, 100)
+	for i := range specs {
+		specs[i] = pubsub.MessageSpec{Data: []byte(fmt.Sprintf("batch-%d", i))}
+	}
+	ids, err := client.PublishMessages(specs, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to publish batch: %v", err)
+	}
+	received := make(map[string]bool)
+	for range specs {
+		msg, err := client.ReceiveMessage(5 * time.Second)
+		if err != nil {
+			t.Fatalf("Failed to receive message: %v", err)
+		}
+		received[msg.ID] = true
+	}
+	for i, id := range ids {
+		if !received[id] {
+			t.Errorf("Message %d (%s) was not received", i, id)
+		}
+	}
+}
+
+func TestOrderedPublishWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:       "test-project",
+		TopicID:         "test-topic-ordered",
+		SubscriptionID:  "test-sub-ordered",
+		AckMode:         pubsub.AckModeAck,
+		MessageOrdering: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("node-%d", i%2)
+		if _, err := client.PublishOrderedMessage([]byte(fmt.Sprintf("%d", i)), nil, key, 5*time.Second); err != nil {
+			t.Fatalf("Failed to publish message %d: %v", i, err)
+		}
+	}
+	last := map[string]int{"node-0": -1, "node-1": -1}
+	for i := 0; i < n; i++ {
+		msg, err := client.ReceiveMessage(5 * time.Second)
+		if err != nil {
+			t.Fatalf("Failed to receive message: %v", err)
+		}
+		var seq int
+		fmt.Sscanf(string(msg.Data), "%d", &seq)
+		if seq <= last[msg.OrderingKey] {
+			t.Errorf("Message %d of %s received after %d", seq, msg.OrderingKey, last[msg.OrderingKey])
+		}
+		last[msg.OrderingKey] = seq
+	}
+}
+
+func TestPublishAsyncWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-async",
+		SubscriptionID: "test-sub-async",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	results := make([]*pubsub.PublishResult, 50)
+	for i := range results {
+		results[i] = client.PublishAsync([]byte(fmt.Sprintf("async-%d", i)), nil)
+	}
+	client.Flush()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ids := make(map[string]bool)
+	for i, r := range results {
+		select {
+		case <-r.Ready():
+		default:
+			t.Errorf("Result %d not ready after Flush", i)
+		}
+		id, err := r.Get(ctx)
+		if err != nil {
+			t.Fatalf("Failed to publish message %d: %v", i, err)
+		}
+		ids[id] = true
+	}
+	for range results {
+		msg, err := client.ReceiveMessage(5 * time.Second)
+		if err != nil {
+			t.Fatalf("Failed to receive message: %v", err)
+		}
+		if !ids[msg.ID] {
+			t.Errorf("Unexpected message %s", msg.ID)
+		}
+	}
+}
+
+func TestSchemaWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-schema",
+		SubscriptionID: "test-sub-schema",
+		AckMode:        pubsub.AckModeAck,
+		Schema: &pubsub.SchemaConfig{
+			ID:         "test-control-envelope",
+			Type:       pubsub.SchemaAvro,
+			Definition: `{"type": "record", "name": "Control", "fields": [{"name": "command", "type": "string"}]}`,
+			Encoding:   pubsub.SchemaEncodingJSON,
+			Validate:   true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.PublishMessage([]byte(`{"command": "abort"}`), nil, 5*time.Second); err != nil {
+		t.Fatalf("Failed to publish valid message: %v", err)
+	}
+	if _, err := client.PublishMessage([]byte(`{"cmd": 1}`), nil, 5*time.Second); !errors.Is(err, pubsub.ErrSchemaValidation) {
+		t.Errorf("Expected a schema validation error, got %v", err)
+	}
+}
+
+func TestPublishToWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	receiver, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-multi-b",
+		SubscriptionID: "test-sub-multi-b",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	defer receiver.Close()
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-multi-a",
+		SubscriptionID: "test-sub-multi-a",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.PublishTo("test-topic-multi-b", []byte(fmt.Sprintf("%d", i)), nil, 5*time.Second); err != nil {
+			t.Fatalf("Failed to publish to other topic: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := receiver.ReceiveMessage(5 * time.Second); err != nil {
+			t.Fatalf("Failed to receive message published to other topic: %v", err)
+		}
+	}
+}
+
+func TestPublishAtWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-scheduled",
+		SubscriptionID: "test-sub-scheduled",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	cancelled := client.PublishAfter([]byte("cancelled"), nil, time.Second)
+	if !cancelled.Cancel() {
+		t.Fatal("Failed to cancel scheduled publish")
+	}
+
+	start := time.Now()
+	scheduled := client.PublishAt([]byte("scheduled"), nil, start.Add(500*time.Millisecond))
+	msg, err := client.ReceiveMessage(5 * time.Second)
+	if err != nil {
+		t.Fatalf("Failed to receive scheduled message: %v", err)
+	}
+	if string(msg.Data) != "scheduled" {
+		t.Fatalf("Expected the scheduled message, got %s", msg.Data)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("Scheduled message received after %v, before its publish time", elapsed)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if id, err := scheduled.Get(ctx); err != nil || id != msg.ID {
+		t.Errorf("Expected scheduled publish of %s, got %s: %v", msg.ID, id, err)
+	}
+}
+
+func TestChunkedPublishWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-chunked",
+		SubscriptionID: "test-sub-chunked",
+		AckMode:        pubsub.AckModeAck,
+		Checksums:      true,
+		ChunkSize:      1024,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	data := []byte(strings.Repeat("snapshot", 1000))
+	if _, err := client.PublishMessage(data, map[string]string{"kind": "snapshot"}, 5*time.Second); err != nil {
+		t.Fatalf("Failed to publish oversized message: %v", err)
+	}
+	msg, err := client.ReceiveMessage(5 * time.Second)
+	if err != nil {
+		t.Fatalf("Failed to receive oversized message: %v", err)
+	}
+	if string(msg.Data) != string(data) || msg.Attributes["kind"] != "snapshot" {
+		t.Errorf("Reassembled message of %d bytes differs from the published %d bytes", len(msg.Data), len(data))
+	}
+}
+
+func TestObserverWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-observed",
+		SubscriptionID: "test-sub-observed",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	observer, err := pubsub.NewObserverClient(pubsub.ObserverConfig{
+		ProjectID: "test-project",
+		TopicIDs:  []string{"test-topic-observed"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create observer: %v", err)
+	}
+	defer observer.Close()
+	observed := make(chan pubsub.ObservedMessage, 1)
+	observer.Observe(func(m pubsub.ObservedMessage) { observed <- m })
+
+	id, err := client.PublishMessage([]byte("watched"), nil, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+	// The primary consumer still receives the message
+	msg, err := client.ReceiveMessage(5 * time.Second)
+	if err != nil || msg.ID != id {
+		t.Fatalf("Primary consumer did not receive the message: %v", err)
+	}
+	select {
+	case m := <-observed:
+		if m.TopicID != "test-topic-observed" || m.Message.ID != id {
+			t.Errorf("Unexpected observed message %s on %s", m.Message.ID, m.TopicID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Observer did not see the message")
+	}
+}
+
+func TestPublishFanOutWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	inboxes := make([]*pubsub.PubSubClient, 0)
+	for i := 1; i <= 2; i++ {
+		inbox, err := pubsub.NewPubSubClient(pubsub.Config{
+			ProjectID:      "test-project",
+			TopicID:        fmt.Sprintf("test-node-%d-inbox", i),
+			SubscriptionID: fmt.Sprintf("test-sub-node-%d-inbox", i),
+			AckMode:        pubsub.AckModeAck,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create inbox %d: %v", i, err)
+		}
+		defer inbox.Close()
+		inboxes = append(inboxes, inbox)
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-fanout",
+		SubscriptionID: "test-sub-fanout",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ids, err := client.PublishFanOut("test-node-*-inbox", []byte("crash"), nil, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to fan out: %v", err)
+	}
+	if len(ids) != len(inboxes) {
+		t.Fatalf("Expected to publish to %d topics, got %v", len(inboxes), ids)
+	}
+	for i, inbox := range inboxes {
+		if _, err := inbox.ReceiveMessage(5 * time.Second); err != nil {
+			t.Errorf("Inbox %d did not receive the fan-out message: %v", i+1, err)
+		}
+	}
+}
+
+func TestPublishMessageCtxWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-ctx",
+		SubscriptionID: "test-sub-ctx",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.PublishMessageCtx(context.Background(), []byte("kept"), nil); err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.PublishMessageCtx(ctx, []byte("aborted"), nil); err == nil {
+		t.Error("Expected publish with a cancelled context to fail")
+	}
+}
+
+func TestBackfillWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	cfg := pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-backfill",
+		SubscriptionID: "test-sub-backfill",
+		AckMode:        pubsub.AckModeAck,
+	}
+	client, err := pubsub.NewPubSubClient(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	if _, err := client.PublishMessage([]byte("frozen"), nil, 5*time.Second); err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+
+	snapshots, err := pubsub.Backfill(context.Background(), cfg, 10)
+	if err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	var snapshot *pubsub.MemorySnapshot
+	for _, s := range snapshots {
+		if s.SubscriptionID == cfg.SubscriptionID {
+			snapshot = s
+		}
+	}
+	if snapshot == nil {
+		t.Fatalf("Subscription %s missing from backfill", cfg.SubscriptionID)
+	}
+
+	broker, err := pubsub.RestoreMemoryBroker(pubsub.Config{AckMode: pubsub.AckModeAck}, snapshot)
+	if err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	defer broker.Close()
+	msg, err := broker.ReceiveMessage(time.Second)
+	if err != nil || string(msg.Data) != "frozen" {
+		t.Errorf("Expected the backfilled message, got %v (%v)", msg, err)
+	}
+
+	// The live subscription still holds the message
+	if msg, err := client.ReceiveMessage(5 * time.Second); err != nil || string(msg.Data) != "frozen" {
+		t.Errorf("Expected the message to remain on the subscription, got %v (%v)", msg, err)
+	}
+}
+
+func TestSubscribeWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:          "test-project",
+		TopicID:            "test-topic-subscribe",
+		SubscriptionID:     "test-sub-subscribe",
+		AckMode:            pubsub.AckModeAck,
+		HandlerConcurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.PublishMessage([]byte("retried"), nil, 5*time.Second); err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+
+	// The first delivery fails and is redelivered
+	var attempts int32
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err = client.Subscribe(ctx, func(ctx context.Context, msg *pubsub.ReceivedMessage) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return errors.New("transient failure")
+		}
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Errorf("Expected the message to be handled twice, got %d", n)
+	}
+}
+
+func TestMessagesChannelWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-messages",
+		SubscriptionID: "test-sub-messages",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.PublishMessage([]byte("selected"), nil, 5*time.Second); err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+	select {
+	case msg := <-client.Messages():
+		if string(msg.Data) != "selected" {
+			t.Errorf("Expected the published message, got %s", msg.Data)
+		}
+	case err := <-client.Errors():
+		t.Fatalf("Receive failed: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for message")
+	}
+}
+
+func TestSynchronousPullWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:       "test-project",
+		TopicID:         "test-topic-sync-pull",
+		SubscriptionID:  "test-sub-sync-pull",
+		AckMode:         pubsub.AckModeAck,
+		SynchronousPull: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	for _, data := range []string{"first", "second"} {
+		if _, err := client.PublishMessage([]byte(data), nil, 5*time.Second); err != nil {
+			t.Fatalf("Failed to publish message: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.ReceiveMessage(10 * time.Second); err != nil {
+			t.Fatalf("Failed to pull message %d: %v", i, err)
+		}
+	}
+	if _, err := client.ReceiveMessage(time.Second); err == nil {
+		t.Errorf("Expected no message left after pulling both")
+	}
+}
+
+func TestConcurrentSynchronousPullsWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:       "test-project",
+		TopicID:         "test-topic-sync-pull-concurrent",
+		SubscriptionID:  "test-sub-sync-pull-concurrent",
+		AckMode:         pubsub.AckModeAck,
+		SynchronousPull: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	const published = 6
+	for i := 0; i < published; i++ {
+		if _, err := client.PublishMessage([]byte(fmt.Sprintf("message-%d", i)), nil, 5*time.Second); err != nil {
+			t.Fatalf("Failed to publish message: %v", err)
+		}
+	}
+
+	// The Messages pump pulls while ReceiveMessage does
+	received := make(map[string]bool)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	messages, errs := client.Messages(), client.Errors()
+	deadline := time.After(30 * time.Second)
+	for {
+		mutex.Lock()
+		count := len(received)
+		mutex.Unlock()
+		if count == published {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg, err := client.ReceiveMessage(time.Second)
+			if err != nil {
+				if !strings.Contains(err.Error(), "timeout") {
+					t.Errorf("Unexpected pull error: %v", err)
+				}
+				return
+			}
+			mutex.Lock()
+			received[string(msg.Data)] = true
+			mutex.Unlock()
+		}()
+		select {
+		case msg := <-messages:
+			mutex.Lock()
+			received[string(msg.Data)] = true
+			mutex.Unlock()
+		case err := <-errs:
+			t.Fatalf("Unexpected pull error: %v", err)
+		case <-time.After(time.Second):
+		case <-deadline:
+			t.Fatalf("Received %d of %d messages", count, published)
+		}
+	}
+}
+
+func TestSeekToTimeWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-seek-time",
+		SubscriptionID: "test-sub-seek-time",
+		AckMode:        pubsub.AckModeAck,
+		SubConfig:      &pubsub.SubscriptionConfig{RetainAckedMessages: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	id, err := client.PublishMessage([]byte("replayed"), nil, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+	if _, err := client.ReceiveMessage(10 * time.Second); err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+
+	if err := client.SeekToTime(context.Background(), start.Add(-time.Second)); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	msg, err := client.ReceiveMessage(10 * time.Second)
+	if err != nil {
+		t.Fatalf("Failed to receive replayed message: %v", err)
+	}
+	if msg.ID != id {
+		t.Errorf("Expected message %s to be replayed, got %s", id, msg.ID)
+	}
+}
+
+func TestSnapshotsWithEmulator(t *testing.T) {
+	// Skip if not running with emulator
+	if os.Getenv("PUBSUB_EMULATOR_HOST") == "" {
+		t.Skip("Skipping integration test: PUBSUB_EMULATOR_HOST not set")
+	}
+
+	client, err := pubsub.NewPubSubClient(pubsub.Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-snapshots",
+		SubscriptionID: "test-sub-snapshots",
+		AckMode:        pubsub.AckModeAck,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	id, err := client.PublishMessage([]byte("checkpointed"), nil, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to publish message: %v", err)
+	}
+	if err := client.CreateSnapshot(ctx, "test-snapshot"); err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	defer client.DeleteSnapshot(ctx, "test-snapshot")
+
+	// The experiment consumes the message, rolling back makes it pending again
+	if _, err := client.ReceiveMessage(10 * time.Second); err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+	if err := client.SeekToSnapshot(ctx, "test-snapshot"); err != nil {
+		t.Fatalf("Failed to seek to snapshot: %v", err)
+	}
+	msg, err := client.ReceiveMessage(10 * time.Second)
+	if err != nil {
+		t.Fatalf("Failed to receive message after rollback: %v", err)
+	}
+	if msg.ID != id {
+		t.Errorf("Expected message %s after rollback, got %s", id, msg.ID)
+	}
+}
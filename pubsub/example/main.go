@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -27,12 +28,32 @@ func main() {
 		},
 	}
 
+	start := time.Now()
 	client, err := pubsub.NewPubSubClient(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 	defer client.Close()
 
+	// Wait until the subscription is delivering messages
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.WaitReady(ctx); err != nil {
+		log.Fatalf("Subscription not ready: %v", err)
+	}
+
+	// Scale the timeouts below to the speed of this environment
+	cal, err := client.Calibrate(ctx, 5, time.Since(start))
+	if err != nil {
+		log.Fatalf("Failed to calibrate: %v", err)
+	}
+	fmt.Printf("Calibrated timeouts by a factor of %.2f\n", cal.Factor)
+	if manifest := os.Getenv("RUN_MANIFEST"); manifest != "" {
+		if err := cal.Record(manifest); err != nil {
+			log.Fatalf("Failed to record calibration: %v", err)
+		}
+	}
+
 	// Publish a message
 	msgData := []byte("Hello, PubSub Emulator!")
 	attrs := map[string]string{
@@ -40,14 +61,14 @@ func main() {
 		"time":   time.Now().Format(time.RFC3339),
 	}
 
-	msgID, err := client.PublishMessage(msgData, attrs, 5*time.Second)
+	msgID, err := client.PublishMessage(msgData, attrs, cal.Scale(5*time.Second))
 	if err != nil {
 		log.Fatalf("Failed to publish message: %v", err)
 	}
 	fmt.Printf("Published message with ID: %s\n", msgID)
 
 	// Receive the message
-	msg, err := client.ReceiveMessage(5 * time.Second)
+	msg, err := client.ReceiveMessage(cal.Scale(5 * time.Second))
 	if err != nil {
 		log.Fatalf("Failed to receive message: %v", err)
 	}
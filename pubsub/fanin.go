@@ -0,0 +1,226 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// fanInPollTimeout bounds each receive a FanIn issues on its sources
+const fanInPollTimeout = 100 * time.Millisecond
+
+// FairnessPolicy decides which subscription a FanIn takes the next message from
+type FairnessPolicy int
+
+const (
+	// FairRoundRobin cycles through the subscriptions that have a message
+	FairRoundRobin FairnessPolicy = iota
+	// FairWeighted serves subscriptions in proportion to their weights
+	FairWeighted
+	// FairOldestFirst takes the pending message with the earliest publish time
+	FairOldestFirst
+)
+
+// FanInConfig holds the configuration of a FanIn
+type FanInConfig struct {
+	Policy FairnessPolicy
+	// Weights of the sources for FairWeighted. Default: 1 each.
+	Weights []int
+}
+
+// FanIn merges the messages of several subscriptions into the single stream the
+// checker consumes. A source is only received from once its previous message was
+// returned, so each source has at most one message in the FanIn at a time and a
+// chatty source cannot starve the quiet ones. Sources settle messages as they
+// receive them; the messages not returned yet when the FanIn is closed are
+// nacked, which only redelivers those left unsettled, see AckModeManual.
+type FanIn struct {
+	sources []Broker
+	policy  FairnessPolicy
+	weights []int
+
+	heads   []chan *pubsub.Message
+	ready   []chan struct{}
+	pending []*pubsub.Message
+	notify  chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	// Selection state
+	mutex   sync.Mutex
+	next    int
+	current []int
+}
+
+// NewFanIn starts receiving from sources
+func NewFanIn(sources []Broker, cfg FanInConfig) (*FanIn, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("fan-in needs at least one source")
+	}
+	weights := make([]int, len(sources))
+	for i := range weights {
+		weights[i] = 1
+	}
+	if cfg.Weights != nil {
+		if len(cfg.Weights) != len(sources) {
+			return nil, fmt.Errorf("got %d weights for %d sources", len(cfg.Weights), len(sources))
+		}
+		for i, w := range cfg.Weights {
+			if w <= 0 {
+				return nil, fmt.Errorf("weight of source %d must be positive, got %d", i, w)
+			}
+			weights[i] = w
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &FanIn{
+		sources: sources,
+		policy:  cfg.Policy,
+		weights: weights,
+		heads:   make([]chan *pubsub.Message, len(sources)),
+		ready:   make([]chan struct{}, len(sources)),
+		pending: make([]*pubsub.Message, len(sources)),
+		notify:  make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		current: make([]int, len(sources)),
+	}
+	for i := range sources {
+		f.heads[i] = make(chan *pubsub.Message, 1)
+		f.ready[i] = make(chan struct{}, 1)
+		f.ready[i] <- struct{}{}
+		f.wg.Add(1)
+		go f.pump(i)
+	}
+	return f, nil
+}
+
+// pump hands the messages of source i to the FanIn one at a time, receiving the
+// next one once the previous one was returned
+func (f *FanIn) pump(i int) {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-f.ready[i]:
+		case <-f.ctx.Done():
+			return
+		}
+
+		var msg *pubsub.Message
+		for msg == nil {
+			if f.ctx.Err() != nil {
+				return
+			}
+			msg, _ = f.sources[i].ReceiveMessage(fanInPollTimeout)
+		}
+		// The head is empty until the message is returned, so that it is not lost
+		// if the FanIn is closed meanwhile
+		f.heads[i] <- msg
+		select {
+		case f.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ReceiveMessage returns the next message chosen by the fairness policy and the
+// index of the source it came from
+func (f *FanIn) ReceiveMessage(timeout time.Duration) (*pubsub.Message, int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		for i, head := range f.heads {
+			if f.pending[i] != nil {
+				continue
+			}
+			select {
+			case msg := <-head:
+				f.pending[i] = msg
+			default:
+			}
+		}
+
+		if i := f.choose(); i >= 0 {
+			msg := f.pending[i]
+			f.pending[i] = nil
+			f.ready[i] <- struct{}{}
+			return msg, i, nil
+		}
+
+		select {
+		case <-f.notify:
+		case <-timer.C:
+			return nil, -1, fmt.Errorf("timeout waiting for message")
+		case <-f.ctx.Done():
+			return nil, -1, fmt.Errorf("fan-in closed")
+		}
+	}
+}
+
+// choose returns the source whose pending message goes next, or -1 if none is pending
+func (f *FanIn) choose() int {
+	chosen := -1
+	switch f.policy {
+	case FairWeighted:
+		// Smooth weighted round-robin over the sources with a pending message
+		total := 0
+		for i, msg := range f.pending {
+			if msg == nil {
+				continue
+			}
+			f.current[i] += f.weights[i]
+			total += f.weights[i]
+			if chosen < 0 || f.current[i] > f.current[chosen] {
+				chosen = i
+			}
+		}
+		if chosen >= 0 {
+			f.current[chosen] -= total
+		}
+	case FairOldestFirst:
+		for i, msg := range f.pending {
+			if msg != nil && (chosen < 0 || msg.PublishTime.Before(f.pending[chosen].PublishTime)) {
+				chosen = i
+			}
+		}
+	default:
+		for k := 0; k < len(f.pending); k++ {
+			i := (f.next + k) % len(f.pending)
+			if f.pending[i] != nil {
+				chosen = i
+				f.next = i + 1
+				break
+			}
+		}
+	}
+	return chosen
+}
+
+// Close stops receiving from the sources and nacks the messages not returned
+// yet. The sources themselves are not closed.
+func (f *FanIn) Close() error {
+	f.cancel()
+	f.wg.Wait()
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, head := range f.heads {
+		select {
+		case msg := <-head:
+			msg.Nack()
+		default:
+		}
+		if f.pending[i] != nil {
+			f.pending[i].Nack()
+			f.pending[i] = nil
+		}
+	}
+	return nil
+}
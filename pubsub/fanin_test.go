@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestFanInChoose(t *testing.T) {
+	start := time.Now()
+	pending := func() []*pubsub.Message {
+		return []*pubsub.Message{
+			{ID: "chatty", PublishTime: start.Add(time.Second)},
+			{ID: "quiet", PublishTime: start},
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		policy  FairnessPolicy
+		weights []int
+		want    []int
+	}{
+		{"round-robin", FairRoundRobin, []int{1, 1}, []int{0, 1, 0, 1}},
+		{"weighted", FairWeighted, []int{2, 1}, []int{0, 1, 0, 0, 1, 0}},
+		{"oldest-first", FairOldestFirst, []int{1, 1}, []int{1, 1, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &FanIn{policy: tc.policy, weights: tc.weights, current: make([]int, 2)}
+			for step, want := range tc.want {
+				// Both sources always have a message pending
+				f.pending = pending()
+				if got := f.choose(); got != want {
+					t.Fatalf("Step %d: expected source %d, got %d", step, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFanInMerge(t *testing.T) {
+	chatty, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+	quiet, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+	for i := 0; i < 10; i++ {
+		chatty.PublishMessage([]byte("chatty"), nil, 0)
+	}
+	quiet.PublishMessage([]byte("quiet"), nil, 0)
+
+	f, err := NewFanIn([]Broker{chatty, quiet}, FanInConfig{Policy: FairRoundRobin})
+	if err != nil {
+		t.Fatalf("Failed to create fan-in: %v", err)
+	}
+	defer f.Close()
+
+	counts := make([]int, 2)
+	for i := 0; i < 11; i++ {
+		_, source, err := f.ReceiveMessage(time.Second)
+		if err != nil {
+			t.Fatalf("Failed to receive message %d: %v", i, err)
+		}
+		counts[source]++
+	}
+	if counts[0] != 10 || counts[1] != 1 {
+		t.Errorf("Expected 10 chatty and 1 quiet message, got %v", counts)
+	}
+	if _, _, err := f.ReceiveMessage(50 * time.Millisecond); err == nil {
+		t.Error("Expected no more messages")
+	}
+
+	if _, err := NewFanIn([]Broker{chatty}, FanInConfig{Weights: []int{1, 2}}); err == nil {
+		t.Error("Expected mismatched weights to be rejected")
+	}
+}
+
+func TestFanInHoldsOneMessagePerSource(t *testing.T) {
+	source, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+	for i := 0; i < 10; i++ {
+		source.PublishMessage([]byte("message"), nil, 0)
+	}
+	f, err := NewFanIn([]Broker{source}, FanInConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create fan-in: %v", err)
+	}
+	defer f.Close()
+
+	pending := func() int {
+		// Give the pump time to receive more than it should
+		time.Sleep(50 * time.Millisecond)
+		messages, err := source.Peek(context.Background(), 10)
+		if err != nil {
+			t.Fatalf("Failed to peek: %v", err)
+		}
+		return len(messages)
+	}
+	if n := pending(); n != 9 {
+		t.Errorf("Expected the fan-in to hold 1 message, %d are left in the source", n)
+	}
+	if _, _, err := f.ReceiveMessage(time.Second); err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+	if n := pending(); n != 8 {
+		t.Errorf("Expected the fan-in to hold 1 more message, %d are left in the source", n)
+	}
+}
@@ -0,0 +1,130 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// ErrInjectedFault is returned by the publishes a FaultyBroker fails on purpose
+var ErrInjectedFault = errors.New("injected broker fault")
+
+// FaultProfile is the seeded behavior of a FaultyBroker. Rates are probabilities
+// between 0 and 1 applied independently to every call.
+type FaultProfile struct {
+	Seed int64
+	// PublishErrorRate fails publishes with ErrInjectedFault, without publishing
+	PublishErrorRate float64
+	// SlowAckRate holds received messages for SlowAckDelay before returning them,
+	// as if settling them took that long
+	SlowAckRate  float64
+	SlowAckDelay time.Duration
+	// DuplicateRate delivers received messages a second time on the next receive
+	DuplicateRate float64
+}
+
+// InjectedFaults counts the faults a FaultyBroker injected
+type InjectedFaults struct {
+	PublishErrors int
+	SlowAcks      int
+	Duplicates    int
+}
+
+// FaultyBroker decorates a Broker with faults of the broker API itself, so the
+// resilience of the harness (retries, deduplication, checkpointing) is fuzzed
+// too. Faults follow the seeded profile, so a run can be reproduced. Control
+// commands are left alone.
+type FaultyBroker struct {
+	Broker
+	profile FaultProfile
+
+	mutex      sync.Mutex
+	rand       *rand.Rand
+	duplicates []*pubsub.Message
+	injected   InjectedFaults
+}
+
+var _ Broker = (*FaultyBroker)(nil)
+
+func NewFaultyBroker(broker Broker, profile FaultProfile) *FaultyBroker {
+	return &FaultyBroker{
+		Broker:  broker,
+		profile: profile,
+		rand:    rand.New(rand.NewSource(profile.Seed)),
+	}
+}
+
+// roll draws whether a fault of the given rate is injected
+func (b *FaultyBroker) roll(rate float64) bool {
+	return rate > 0 && b.rand.Float64() < rate
+}
+
+func (b *FaultyBroker) PublishMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
+	if _, ok := attributes[ControlAttribute]; !ok {
+		b.mutex.Lock()
+		fail := b.roll(b.profile.PublishErrorRate)
+		if fail {
+			b.injected.PublishErrors++
+		}
+		b.mutex.Unlock()
+		if fail {
+			return "", fmt.Errorf("failed to publish message: %w", ErrInjectedFault)
+		}
+	}
+	return b.Broker.PublishMessage(data, attributes, timeout)
+}
+
+func (b *FaultyBroker) ReceiveMessage(timeout time.Duration) (*pubsub.Message, error) {
+	b.mutex.Lock()
+	if len(b.duplicates) > 0 {
+		msg := b.duplicates[0]
+		b.duplicates = b.duplicates[1:]
+		b.mutex.Unlock()
+		return msg, nil
+	}
+	b.mutex.Unlock()
+
+	msg, err := b.Broker.ReceiveMessage(timeout)
+	if err != nil || IsControl(msg) {
+		return msg, err
+	}
+
+	b.mutex.Lock()
+	slow := b.roll(b.profile.SlowAckRate)
+	duplicate := b.roll(b.profile.DuplicateRate)
+	if slow {
+		b.injected.SlowAcks++
+	}
+	if duplicate {
+		b.injected.Duplicates++
+		b.duplicates = append(b.duplicates, copyMessage(msg))
+	}
+	b.mutex.Unlock()
+	if slow {
+		time.Sleep(b.profile.SlowAckDelay)
+	}
+	return msg, nil
+}
+
+// Injected returns the faults injected so far
+func (b *FaultyBroker) Injected() InjectedFaults {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.injected
+}
+
+// copyMessage copies the content of msg, keeping its ID
+func copyMessage(msg *pubsub.Message) *pubsub.Message {
+	return &pubsub.Message{
+		ID:              msg.ID,
+		Data:            append([]byte(nil), msg.Data...),
+		Attributes:      copyAttributes(msg.Attributes),
+		PublishTime:     msg.PublishTime,
+		DeliveryAttempt: msg.DeliveryAttempt,
+		OrderingKey:     msg.OrderingKey,
+	}
+}
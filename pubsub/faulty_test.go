@@ -0,0 +1,58 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFaultyBroker(t *testing.T) {
+	memory, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+	b := NewFaultyBroker(memory, FaultProfile{Seed: 1, PublishErrorRate: 1})
+	if _, err := b.PublishMessage([]byte("a"), nil, 0); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("Expected an injected publish error, got %v", err)
+	}
+	if _, err := b.PublishMessage(nil, map[string]string{ControlAttribute: ControlAbort}, 0); err != nil {
+		t.Fatalf("Expected control commands to be left alone, got %v", err)
+	}
+
+	b = NewFaultyBroker(memory, FaultProfile{Seed: 1, DuplicateRate: 1})
+	first, err := b.ReceiveMessage(time.Second)
+	if err != nil || !IsControl(first) {
+		t.Fatalf("Failed to receive control command: %v", err)
+	}
+	if _, err := b.ReceiveMessage(100 * time.Millisecond); err == nil {
+		t.Fatal("Expected control commands not to be duplicated")
+	}
+	b.PublishMessage([]byte("b"), nil, 0)
+	msg, err := b.ReceiveMessage(time.Second)
+	if err != nil {
+		t.Fatalf("Failed to receive message: %v", err)
+	}
+	dup, err := b.ReceiveMessage(time.Second)
+	if err != nil || dup.ID != msg.ID || string(dup.Data) != "b" {
+		t.Fatalf("Expected a duplicate of %s, got %v: %v", msg.ID, dup, err)
+	}
+	if injected := b.Injected(); injected.Duplicates != 1 {
+		t.Errorf("Expected 1 injected duplicate, got %+v", injected)
+	}
+}
+
+func TestFaultyBrokerSeeded(t *testing.T) {
+	draws := func() []bool {
+		memory, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+		b := NewFaultyBroker(memory, FaultProfile{Seed: 42, PublishErrorRate: 0.5})
+		failed := make([]bool, 20)
+		for i := range failed {
+			_, err := b.PublishMessage([]byte("x"), nil, 0)
+			failed[i] = err != nil
+		}
+		return failed
+	}
+	a, b := draws(), draws()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Publish %d differs between runs with the same seed", i)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pubsub
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// SenderAttribute carries the virtual identity of the SUT node that sent a message
+const SenderAttribute = "fuzz-sender"
+
+// WithSender returns a copy of attributes identifying sender as the sending node
+func WithSender(attributes map[string]string, sender string) map[string]string {
+	attrs := copyAttributes(attributes)
+	attrs[SenderAttribute] = sender
+	return attrs
+}
+
+// Sender returns the node that sent msg, as it claims
+func Sender(msg *pubsub.Message) string {
+	return msg.Attributes[SenderAttribute]
+}
+
+// IsImpersonated reports whether the sender of msg was forged by an impersonation fault
+func IsImpersonated(msg *pubsub.Message) bool {
+	_, ok := msg.Attributes[AnnotationPrefix+AnnotationImpersonated]
+	return ok
+}
+
+// impersonate returns a copy of attributes claiming to be sent by sender,
+// annotated with the true sender
+func impersonate(attributes map[string]string, sender string) (map[string]string, error) {
+	if _, ok := attributes[ControlAttribute]; ok {
+		return nil, fmt.Errorf("control commands are not subject to impersonation")
+	}
+	if attributes[SenderAttribute] == sender {
+		return nil, fmt.Errorf("message is already sent by %s", sender)
+	}
+	attrs := Annotate(attributes, AnnotationImpersonated, attributes[SenderAttribute])
+	attrs[SenderAttribute] = sender
+	return attrs, nil
+}
+
+// PublishImpersonatedMessage publishes a message, typically an injected message or
+// the duplicate of a delivered one, whose sender identity is forged to be another
+// node. The true sender is kept in the AnnotationImpersonated annotation so the
+// trace shows the identity confusion was injected.
+func (c *PubSubClient) PublishImpersonatedMessage(data []byte, attributes map[string]string, sender string, timeout time.Duration) (string, error) {
+	attrs, err := impersonate(attributes, sender)
+	if err != nil {
+		return "", err
+	}
+	if err := c.allowFault("impersonation"); err != nil {
+		return "", err
+	}
+	if err := c.validatePayload(data); err != nil {
+		return "", err
+	}
+	if c.checksums {
+		attrs = stampChecksum(data, attrs)
+	}
+	return c.publish(data, attrs, "", timeout)
+}
@@ -0,0 +1,33 @@
+package pubsub
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestImpersonate(t *testing.T) {
+	attrs := WithSender(map[string]string{"type": "MsgVote"}, "1")
+	forged, err := impersonate(attrs, "2")
+	if err != nil {
+		t.Fatalf("Failed to impersonate: %v", err)
+	}
+	if attrs[SenderAttribute] != "1" {
+		t.Errorf("Expected the original attributes to be left untouched, got %v", attrs)
+	}
+
+	msg := &pubsub.Message{Attributes: forged}
+	if Sender(msg) != "2" || !IsImpersonated(msg) || Annotations(msg)[AnnotationImpersonated] != "1" {
+		t.Errorf("Unexpected impersonated attributes %v", forged)
+	}
+	if IsImpersonated(&pubsub.Message{Attributes: attrs}) {
+		t.Error("Genuine message reported as impersonated")
+	}
+
+	if _, err := impersonate(attrs, "1"); err == nil {
+		t.Error("Expected impersonating the true sender to fail")
+	}
+	if _, err := impersonate(map[string]string{ControlAttribute: ControlAbort}, "2"); err == nil {
+		t.Error("Expected control commands to be refused")
+	}
+}
@@ -0,0 +1,160 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// maxTrackedMessages bounds how many message IDs the delivery log remembers
+const maxTrackedMessages = 10000
+
+// delivery records when a message was published, when it first reached this client
+// and when it was most recently (re)delivered
+type delivery struct {
+	published time.Time
+	delivered time.Time
+	received  time.Time
+	count     int
+	// outcome is the outcome of the last ack or nack of the message
+	outcome *AckOutcome
+}
+
+// deliveryLog keeps publish and delivery times of recent messages, forgetting the
+// oldest entries once maxTrackedMessages is exceeded
+type deliveryLog struct {
+	mutex     sync.Mutex
+	published map[string]time.Time
+	delivered map[string]delivery
+	order     []string
+}
+
+func newDeliveryLog() *deliveryLog {
+	return &deliveryLog{
+		published: make(map[string]time.Time),
+		delivered: make(map[string]delivery),
+		order:     make([]string, 0),
+	}
+}
+
+// track remembers id and evicts the oldest entry when the log is full.
+// Must be called with the mutex held.
+func (l *deliveryLog) track(id string) {
+	if _, ok := l.published[id]; ok {
+		return
+	}
+	if _, ok := l.delivered[id]; ok {
+		return
+	}
+	l.order = append(l.order, id)
+	if len(l.order) > maxTrackedMessages {
+		oldest := l.order[0]
+		l.order = l.order[1:]
+		delete(l.published, oldest)
+		delete(l.delivered, oldest)
+	}
+}
+
+// recordPublish stamps the time a message was handed to the topic
+func (l *deliveryLog) recordPublish(id string, at time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.track(id)
+	l.published[id] = at
+}
+
+// recordDelivery stamps the time msg reached the client. The first delivery is kept
+// for latency measurements. The server publish time is used when the message was
+// not published by this client.
+func (l *deliveryLog) recordDelivery(msg *pubsub.Message, at time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if d, ok := l.delivered[msg.ID]; ok {
+		d.received = at
+		d.count++
+		l.delivered[msg.ID] = d
+		return
+	}
+	l.track(msg.ID)
+	published, ok := l.published[msg.ID]
+	if !ok {
+		published = msg.PublishTime
+	}
+	l.delivered[msg.ID] = delivery{published: published, delivered: at, received: at, count: 1}
+}
+
+// recordOutcome stores the outcome of an ack or nack of a delivered message
+func (l *deliveryLog) recordOutcome(o AckOutcome) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	d, ok := l.delivered[o.MessageID]
+	if !ok {
+		return
+	}
+	d.outcome = &o
+	l.delivered[o.MessageID] = d
+}
+
+// lookup returns the delivery of id and the publish time known for it
+func (l *deliveryLog) lookup(id string) (delivery, time.Time, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	d, ok := l.delivered[id]
+	return d, l.published[id], ok
+}
+
+// size returns the number of messages tracked by the log
+func (l *deliveryLog) size() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.order)
+}
+
+// DeliveryLatency returns the time between publishing and receiving msgID, if the
+// message has reached this client
+func (c *PubSubClient) DeliveryLatency(msgID string) (time.Duration, bool) {
+	d, _, ok := c.deliveries.lookup(msgID)
+	if !ok {
+		return 0, false
+	}
+	return d.delivered.Sub(d.published), true
+}
+
+// TestingT is the subset of testing.TB used by the assertion helpers
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertDeliveredWithin fails the test unless msgID reaches this client within d
+// of being published. It waits for the delivery if it has not happened yet.
+func (c *PubSubClient) AssertDeliveredWithin(t TestingT, msgID string, d time.Duration) {
+	t.Helper()
+	c.startContinuousReceiver()
+
+	_, published, _ := c.deliveries.lookup(msgID)
+	deadline := time.Now().Add(d)
+	if !published.IsZero() {
+		deadline = published.Add(d)
+	}
+
+	for {
+		if latency, ok := c.DeliveryLatency(msgID); ok {
+			if latency > d {
+				t.Errorf("Message %s delivered after %v, expected within %v", msgID, latency, d)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("Message %s not delivered within %v", msgID, d)
+			return
+		}
+		select {
+		case <-c.ctx.Done():
+			t.Errorf("Client closed before message %s was delivered", msgID)
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestDeliveryLog(t *testing.T) {
+	log := newDeliveryLog()
+	start := time.Now()
+
+	// Published by this client: latency is measured from the local publish stamp
+	log.recordPublish("local", start)
+	log.recordDelivery(&pubsub.Message{ID: "local", PublishTime: start.Add(time.Second)}, start.Add(2*time.Second))
+	d, _, ok := log.lookup("local")
+	if !ok {
+		t.Fatal("Expected delivery of 'local' to be recorded")
+	}
+	if latency := d.delivered.Sub(d.published); latency != 2*time.Second {
+		t.Errorf("Expected latency 2s, got %v", latency)
+	}
+
+	// Published elsewhere: the server publish time is used
+	log.recordDelivery(&pubsub.Message{ID: "remote", PublishTime: start}, start.Add(time.Second))
+	d, _, _ = log.lookup("remote")
+	if latency := d.delivered.Sub(d.published); latency != time.Second {
+		t.Errorf("Expected latency 1s, got %v", latency)
+	}
+
+	// Redeliveries keep the first delivery time
+	log.recordDelivery(&pubsub.Message{ID: "remote", PublishTime: start}, start.Add(time.Minute))
+	d, _, _ = log.lookup("remote")
+	if latency := d.delivered.Sub(d.published); latency != time.Second {
+		t.Errorf("Expected redelivery to be ignored, got latency %v", latency)
+	}
+	if !d.received.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected redelivery time to be recorded, got %v", d.received)
+	}
+	if d.count != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", d.count)
+	}
+
+	// The oldest entries are forgotten once the log is full
+	for i := 0; i < maxTrackedMessages; i++ {
+		log.recordPublish(fmt.Sprintf("filler-%d", i), start)
+	}
+	if _, _, ok := log.lookup("local"); ok {
+		t.Error("Expected the oldest delivery to be evicted")
+	}
+}
@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// maxManualLease bounds how long the receiver may hold a message it settles
+// itself, counted from its receipt
+const maxManualLease = time.Hour
+
+// ReceiveManual receives a single message like ReceiveMessage and returns it
+// for the receiver to settle, see AckModeManual
+func (c *PubSubClient) ReceiveManual(timeout time.Duration) (*ReceivedMessage, error) {
+	msg, err := c.ReceiveMessage(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapReceived(msg), nil
+}
+
+// manual reports whether msg is left for the receiver to settle
+func (c *PubSubClient) manual(msg *pubsub.Message) bool {
+	_, ok := ackDecision(msg.Attributes, 0, c.ackMode)
+	return !ok
+}
+
+// maxExtension is how long the leases of received messages are extended for.
+// Messages the receiver settles itself are held until their ack deadline, which
+// ModifyAckDeadline can move up to maxManualLease.
+func (c *PubSubClient) maxExtension() time.Duration {
+	if c.ackMode == AckModeManual {
+		return maxManualLease
+	}
+	return c.ackDeadline
+}
+
+// wrapReceived wraps msg for the receiver. A message left to the receiver is nacked
+// at its ack deadline unless settled before.
+func (c *PubSubClient) wrapReceived(msg *pubsub.Message) *ReceivedMessage {
+	rm := &ReceivedMessage{Message: msg, client: c, received: time.Now()}
+	if d, _, ok := c.deliveries.lookup(msg.ID); ok {
+		rm.received = d.received
+	}
+	if !c.manual(msg) {
+		rm.auto = true
+		rm.settled = true
+		return rm
+	}
+	// The lease is set before its timer can settle the message
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.lease = time.AfterFunc(time.Until(rm.received.Add(c.ackDeadline)), rm.Nack)
+	return rm
+}
+
+// Ack acknowledges the message. It has no effect once the message is settled,
+// including by the client according to the AckMode.
+func (m *ReceivedMessage) Ack() {
+	m.settle(true)
+}
+
+// Nack releases the message for redelivery. It has no effect once the message
+// is settled, including by the client according to the AckMode.
+func (m *ReceivedMessage) Nack() {
+	m.settle(false)
+}
+
+func (m *ReceivedMessage) settle(ack bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.settled {
+		return
+	}
+	m.settled = true
+	if m.lease != nil {
+		m.lease.Stop()
+	}
+	m.client.acknowledge(m.Message, ack)
+}
+
+// ModifyAckDeadline moves the ack deadline of the message to d from now, after
+// which it is redelivered unless settled. A zero d nacks it. The deadline cannot
+// be moved beyond maxManualLease after the receipt of the message.
+func (m *ReceivedMessage) ModifyAckDeadline(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("negative ack deadline %v", d)
+	}
+	if d == 0 {
+		m.Nack()
+		return nil
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.settled {
+		return fmt.Errorf("message %s is already settled", m.ID)
+	}
+	if time.Since(m.received)+d > m.client.maxExtension() {
+		return fmt.Errorf("ack deadline %v exceeds the maximum lease of %v", d, m.client.maxExtension())
+	}
+	if m.lease == nil {
+		return fmt.Errorf("message %s has no lease", m.ID)
+	}
+	m.lease.Reset(d)
+	return nil
+}
@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestReceivedMessageManual(t *testing.T) {
+	c := &PubSubClient{
+		ackMode:     AckModeManual,
+		ackDeadline: 50 * time.Millisecond,
+		deliveries:  newDeliveryLog(),
+	}
+	if _, ok := ackDecision(nil, 1, AckModeManual); ok {
+		t.Errorf("Expected messages to be left to the receiver with AckModeManual")
+	}
+
+	acked := c.wrapReceived(&pubsub.Message{ID: "acked"})
+	if err := acked.ModifyAckDeadline(2 * maxManualLease); err == nil {
+		t.Errorf("Expected a deadline beyond the maximum lease to be refused")
+	}
+	if err := acked.ModifyAckDeadline(time.Second); err != nil {
+		t.Fatalf("Failed to modify the ack deadline: %v", err)
+	}
+	acked.Ack()
+	if err := acked.ModifyAckDeadline(time.Second); err == nil {
+		t.Errorf("Expected modifying the deadline of an acked message to fail")
+	}
+
+	expired := c.wrapReceived(&pubsub.Message{ID: "expired"})
+	time.Sleep(100 * time.Millisecond)
+	if err := expired.ModifyAckDeadline(time.Second); err == nil {
+		t.Errorf("Expected the message to be released at its ack deadline")
+	}
+
+	// The lease of a message received past its ack deadline fires right away
+	late := &pubsub.Message{ID: "late"}
+	c.deliveries.recordDelivery(late, time.Now().Add(-time.Minute))
+	c.wrapReceived(late).Ack()
+
+	c.ackMode = AckModeAck
+	auto := c.wrapReceived(&pubsub.Message{ID: "auto"})
+	if err := auto.ModifyAckDeadline(time.Second); err == nil {
+		t.Errorf("Expected messages settled by the client to be settled")
+	}
+	manual := c.wrapReceived(&pubsub.Message{ID: "manual", Attributes: WithAckMode(nil, AckAttrManual)})
+	if err := manual.ModifyAckDeadline(time.Minute); err == nil {
+		t.Errorf("Expected the lease to be bounded by the ack deadline outside AckModeManual")
+	}
+	manual.Nack()
+}
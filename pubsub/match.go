@@ -0,0 +1,27 @@
+package pubsub
+
+import (
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// ReceiveMessageMatching receives the first message satisfying pred, so that
+// tests sharing a subscription do not consume each other's messages. Other
+// messages are nacked before they are settled, so they are redelivered to the
+// other receivers of the subscription. Reassembled payloads and buffered
+// messages cannot be nacked; those not satisfying pred are kept buffered for
+// later receives instead.
+func (c *PubSubClient) ReceiveMessageMatching(pred func(*pubsub.Message) bool, timeout time.Duration) (*pubsub.Message, error) {
+	return c.receiveMessage(timeout, pred)
+}
+
+// deliverMatching delivers msg if it satisfies match, and nacks it otherwise.
+// Chunks are delivered regardless, the reassembled payload is matched.
+func (c *PubSubClient) deliverMatching(msg *pubsub.Message, ok bool, match func(*pubsub.Message) bool) (*pubsub.Message, error) {
+	if ok && msg != nil && match != nil && !IsChunk(msg) && !match(msg) {
+		c.acknowledge(msg, false)
+		return nil, nil
+	}
+	return c.deliver(msg, ok)
+}
@@ -0,0 +1,37 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestReceiveMessageMatching(t *testing.T) {
+	c := newTestClient(t, AckModeAck)
+	mine := func(msg *pubsub.Message) bool { return msg.Attributes["test"] == "mine" }
+
+	c.BufferMessage(&pubsub.Message{ID: "other-buffered", Attributes: map[string]string{"test": "other"}})
+	c.BufferMessage(&pubsub.Message{ID: "mine-buffered", Attributes: map[string]string{"test": "mine"}})
+	c.messageChan <- &pubsub.Message{ID: "other", Attributes: map[string]string{"test": "other"}}
+	c.messageChan <- &pubsub.Message{ID: "mine", Attributes: map[string]string{"test": "mine"}}
+
+	for _, expected := range []string{"mine-buffered", "mine"} {
+		msg, err := c.ReceiveMessageMatching(mine, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("Failed to receive %s: %v", expected, err)
+		}
+		if msg.ID != expected {
+			t.Errorf("Expected %s, got %s", expected, msg.ID)
+		}
+	}
+	if _, err := c.ReceiveMessageMatching(mine, 100*time.Millisecond); err == nil {
+		t.Errorf("Expected no matching message left")
+	}
+
+	// The non-matching buffered message is left to other receives
+	msg, err := c.ReceiveMessage(100 * time.Millisecond)
+	if err != nil || msg.ID != "other-buffered" {
+		t.Errorf("Expected the non-matching buffered message to stay buffered, got %v, %v", msg, err)
+	}
+}
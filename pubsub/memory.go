@@ -0,0 +1,281 @@
+package pubsub
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// memoryPollInterval bounds how long a receive waits before re-checking expired
+// ack deadlines
+const memoryPollInterval = 10 * time.Millisecond
+
+// seededEpoch is the publish time of the first message of a seeded MemoryBroker.
+// Later messages are published a millisecond apart.
+var seededEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// memoryMessage is a message held by the MemoryBroker
+type memoryMessage struct {
+	id         string
+	data       []byte
+	attributes map[string]string
+	published  time.Time
+	deliveries int
+	deadline   time.Time
+}
+
+// MemoryBroker is an in-process Broker with a single topic and subscription.
+// Messages are delivered in publish order, nacked messages are redelivered after
+// the pending ones, or after the backoff of the RetryPolicy, and messages left
+// unsettled are redelivered once their ack deadline expires.
+type MemoryBroker struct {
+	ackMode     AckMode
+	ackDeadline time.Duration
+	checksums   bool
+	filter      func(map[string]string) bool
+	filterExpr  string
+	retry       *RetryPolicy
+	// ids derives the message IDs of a seeded broker
+	ids *rand.Rand
+
+	mutex  sync.Mutex
+	nextID int
+	ready  []*memoryMessage
+	// outstanding holds the unsettled and backing off messages in the order they
+	// were delivered, so that redeliveries follow a stable order
+	outstanding []*memoryMessage
+	notify      chan struct{}
+	closed      bool
+}
+
+// NewMemoryBroker creates a MemoryBroker. ProjectID, TopicID, SubscriptionID and
+// Credentials are ignored. Filters support equality, inequality, hasPrefix and
+// presence checks on attributes, joined with AND.
+func NewMemoryBroker(cfg Config) (*MemoryBroker, error) {
+	b := &MemoryBroker{
+		ackMode:     cfg.AckMode,
+		ackDeadline: defaultAckDeadline,
+		checksums:   cfg.Checksums,
+		filter:      func(map[string]string) bool { return true },
+		notify:      make(chan struct{}, 1),
+	}
+	if cfg.SubConfig != nil {
+		if cfg.SubConfig.AckDeadline > 0 {
+			b.ackDeadline = cfg.SubConfig.AckDeadline
+		}
+		if cfg.SubConfig.Filter != "" {
+			filter, err := parseFilter(cfg.SubConfig.Filter)
+			if err != nil {
+				return nil, err
+			}
+			b.filter = filter
+			b.filterExpr = cfg.SubConfig.Filter
+		}
+		if policy := cfg.SubConfig.RetryPolicy; policy != nil {
+			if err := policy.validate(); err != nil {
+				return nil, err
+			}
+			b.retry = policy
+		}
+	}
+	return b, nil
+}
+
+// NewSeededMemoryBroker creates a MemoryBroker whose message IDs are derived from
+// seed and whose publish times follow a logical clock instead of the wall clock,
+// so that recordings of a run are byte-stable, e.g. for golden files
+func NewSeededMemoryBroker(cfg Config, seed int64) (*MemoryBroker, error) {
+	b, err := NewMemoryBroker(cfg)
+	if err != nil {
+		return nil, err
+	}
+	b.ids = rand.New(rand.NewSource(seed))
+	return b, nil
+}
+
+// PublishMessage adds a message to the subscription unless the filter rejects it.
+// The timeout is ignored.
+func (b *MemoryBroker) PublishMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
+	if b.checksums {
+		attributes = stampChecksum(data, attributes)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return "", fmt.Errorf("failed to publish message: broker closed")
+	}
+	b.nextID++
+	id := strconv.Itoa(b.nextID)
+	published := time.Now()
+	if b.ids != nil {
+		id = fmt.Sprintf("%016x", b.ids.Uint64())
+		published = seededEpoch.Add(time.Duration(b.nextID-1) * time.Millisecond)
+	}
+	if !b.filter(attributes) {
+		return id, nil
+	}
+	b.ready = append(b.ready, &memoryMessage{
+		id:         id,
+		data:       append([]byte(nil), data...),
+		attributes: copyAttributes(attributes),
+		published:  published,
+	})
+	b.wake()
+	return id, nil
+}
+
+// wake signals a waiting receive
+func (b *MemoryBroker) wake() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// ReceiveMessage returns the next message, settling it like PubSubClient does
+func (b *MemoryBroker) ReceiveMessage(timeout time.Duration) (*pubsub.Message, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		msg, err := b.next()
+		if err != nil || msg != nil {
+			return msg, err
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, fmt.Errorf("timeout waiting for message")
+		}
+		if wait > memoryPollInterval {
+			wait = memoryPollInterval
+		}
+		select {
+		case <-b.notify:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// next pops and settles the next deliverable message, if any
+func (b *MemoryBroker) next() (*pubsub.Message, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		return nil, fmt.Errorf("message channel closed")
+	}
+
+	now := time.Now()
+	pending := b.outstanding[:0]
+	for _, m := range b.outstanding {
+		if now.After(m.deadline) {
+			b.ready = append(b.ready, m)
+		} else {
+			pending = append(pending, m)
+		}
+	}
+	b.outstanding = pending
+	if len(b.ready) == 0 {
+		return nil, nil
+	}
+
+	m := b.ready[0]
+	b.ready = b.ready[1:]
+	m.deliveries++
+	if ack, ok := ackDecision(m.attributes, m.deliveries, b.ackMode); !ok {
+		m.deadline = now.Add(b.ackDeadline)
+		b.outstanding = append(b.outstanding, m)
+	} else if !ack && b.retry != nil {
+		m.deadline = now.Add(b.retry.backoff(m.deliveries))
+		b.outstanding = append(b.outstanding, m)
+	} else if !ack {
+		b.ready = append(b.ready, m)
+	}
+
+	attempt := m.deliveries
+	msg := &pubsub.Message{
+		ID:              m.id,
+		Data:            append([]byte(nil), m.data...),
+		Attributes:      copyAttributes(m.attributes),
+		PublishTime:     m.published,
+		DeliveryAttempt: &attempt,
+	}
+	if b.checksums {
+		if err := verifyChecksum(msg); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Close drops all messages held by the broker
+func (b *MemoryBroker) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	b.ready = nil
+	b.outstanding = nil
+	return nil
+}
+
+var (
+	filterEquals   = regexp.MustCompile(`^attributes\.([\w-]+)\s*(=|!=)\s*"([^"]*)"$`)
+	filterPrefix   = regexp.MustCompile(`^hasPrefix\(\s*attributes\.([\w-]+)\s*,\s*"([^"]*)"\s*\)$`)
+	filterPresence = regexp.MustCompile(`^attributes:([\w-]+)$`)
+)
+
+// parseFilter compiles the subset of the PubSub filter syntax supported by the
+// MemoryBroker
+func parseFilter(expr string) (func(map[string]string) bool, error) {
+	var clauses []func(map[string]string) bool
+	for _, clause := range strings.Split(expr, " AND ") {
+		clause = strings.TrimSpace(clause)
+		negate := strings.HasPrefix(clause, "NOT ")
+		clause = strings.TrimSpace(strings.TrimPrefix(clause, "NOT "))
+
+		var match func(map[string]string) bool
+		if m := filterEquals.FindStringSubmatch(clause); m != nil {
+			key, op, value := m[1], m[2], m[3]
+			match = func(attrs map[string]string) bool {
+				v, ok := attrs[key]
+				if op == "=" {
+					return ok && v == value
+				}
+				return !ok || v != value
+			}
+		} else if m := filterPrefix.FindStringSubmatch(clause); m != nil {
+			key, prefix := m[1], m[2]
+			match = func(attrs map[string]string) bool {
+				v, ok := attrs[key]
+				return ok && strings.HasPrefix(v, prefix)
+			}
+		} else if m := filterPresence.FindStringSubmatch(clause); m != nil {
+			key := m[1]
+			match = func(attrs map[string]string) bool {
+				_, ok := attrs[key]
+				return ok
+			}
+		} else {
+			return nil, fmt.Errorf("unsupported filter clause %q", clause)
+		}
+
+		if negate {
+			inner := match
+			match = func(attrs map[string]string) bool { return !inner(attrs) }
+		}
+		clauses = append(clauses, match)
+	}
+
+	return func(attrs map[string]string) bool {
+		for _, match := range clauses {
+			if !match(attrs) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
@@ -0,0 +1,198 @@
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerConformance(t *testing.T) {
+	RunConformance(t, func(cfg Config) (Broker, error) {
+		return NewMemoryBroker(cfg)
+	}, ConformanceConfig{
+		AckDeadline:    100 * time.Millisecond,
+		ReceiveTimeout: 200 * time.Millisecond,
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	testCases := []struct {
+		filter string
+		attrs  map[string]string
+		match  bool
+	}{
+		{`attributes.kind = "keep"`, map[string]string{"kind": "keep"}, true},
+		{`attributes.kind = "keep"`, map[string]string{"kind": "drop"}, false},
+		{`attributes.kind != "keep"`, map[string]string{}, true},
+		{`hasPrefix(attributes.node, "n1")`, map[string]string{"node": "n12"}, true},
+		{`attributes:node AND NOT attributes.kind = "probe"`, map[string]string{"node": "1", "kind": "probe"}, false},
+		{`attributes:node AND NOT attributes.kind = "probe"`, map[string]string{"node": "1"}, true},
+	}
+
+	for _, tc := range testCases {
+		filter, err := parseFilter(tc.filter)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tc.filter, err)
+		}
+		if got := filter(tc.attrs); got != tc.match {
+			t.Errorf("%q on %v: expected %v, got %v", tc.filter, tc.attrs, tc.match, got)
+		}
+	}
+
+	if _, err := parseFilter(`attributes.kind = "a" OR attributes.kind = "b"`); err == nil {
+		t.Error("Expected OR to be rejected")
+	}
+}
+
+func TestMemoryBrokerPeek(t *testing.T) {
+	b, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+	for _, data := range []string{"a", "b", "c"} {
+		b.PublishMessage([]byte(data), nil, 0)
+	}
+
+	peeked, err := b.Peek(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Failed to peek: %v", err)
+	}
+	if len(peeked) != 2 || string(peeked[0].Data) != "a" || string(peeked[1].Data) != "b" {
+		t.Fatalf("Expected to peek a and b, got %v", peeked)
+	}
+
+	// Peeking does not consume
+	msg, err := b.ReceiveMessage(time.Second)
+	if err != nil || string(msg.Data) != "a" {
+		t.Errorf("Expected to receive a after peeking, got %v (%v)", msg, err)
+	}
+}
+
+func TestSeededMemoryBroker(t *testing.T) {
+	record := func(seed int64) []string {
+		b, _ := NewSeededMemoryBroker(Config{AckMode: AckModeAck}, seed)
+		defer b.Close()
+		for _, data := range []string{"a", "b"} {
+			b.PublishMessage([]byte(data), nil, 0)
+		}
+		recorded := make([]string, 0)
+		for i := 0; i < 2; i++ {
+			msg, err := b.ReceiveMessage(time.Second)
+			if err != nil {
+				t.Fatalf("Failed to receive: %v", err)
+			}
+			recorded = append(recorded, msg.ID+"@"+msg.PublishTime.Format(time.RFC3339Nano))
+		}
+		return recorded
+	}
+
+	first, second := record(7), record(7)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("Message %d differs between runs: %s and %s", i, first[i], second[i])
+		}
+	}
+	if other := record(8); other[0] == first[0] {
+		t.Errorf("Expected another seed to assign other IDs, got %s", other[0])
+	}
+}
+
+func TestSeededMemoryBrokerRedeliveryOrder(t *testing.T) {
+	record := func() []byte {
+		b, _ := NewSeededMemoryBroker(Config{AckMode: AckModeAck, SubConfig: &SubscriptionConfig{AckDeadline: 20 * time.Millisecond}}, 7)
+		defer b.Close()
+		for i := 0; i < 8; i++ {
+			b.PublishMessage([]byte{byte('a' + i)}, WithAckMode(nil, AckAttrManual), 0)
+		}
+		var recorded bytes.Buffer
+		for round := 0; round < 3; round++ {
+			for i := 0; i < 8; i++ {
+				msg, err := b.ReceiveMessage(time.Second)
+				if err != nil {
+					t.Fatalf("Failed to receive: %v", err)
+				}
+				fmt.Fprintf(&recorded, "%s %s %d\n", msg.ID, msg.Data, *msg.DeliveryAttempt)
+			}
+			// Let the unsettled messages expire together
+			time.Sleep(50 * time.Millisecond)
+		}
+		return recorded.Bytes()
+	}
+
+	first, second := record(), record()
+	if !bytes.Equal(first, second) {
+		t.Errorf("Redeliveries differ between runs:\n%s\nand\n%s", first, second)
+	}
+}
+
+func TestMemoryBrokerSnapshotOrder(t *testing.T) {
+	snapshot := func() []byte {
+		b, _ := NewSeededMemoryBroker(Config{AckMode: AckModeAck}, 7)
+		defer b.Close()
+		for i := 0; i < 8; i++ {
+			b.PublishMessage([]byte{byte('a' + i)}, WithAckMode(nil, AckAttrManual), 0)
+		}
+		// Leave the messages unsettled
+		for i := 0; i < 8; i++ {
+			if _, err := b.ReceiveMessage(time.Second); err != nil {
+				t.Fatalf("Failed to receive: %v", err)
+			}
+		}
+		b.PublishMessage([]byte("pending"), nil, 0)
+		data, err := json.Marshal(b.Snapshot())
+		if err != nil {
+			t.Fatalf("Failed to encode snapshot: %v", err)
+		}
+		return data
+	}
+
+	first, second := snapshot(), snapshot()
+	if !bytes.Equal(first, second) {
+		t.Errorf("Snapshots differ between runs:\n%s\nand\n%s", first, second)
+	}
+}
+
+func TestMemoryBrokerSnapshotRestore(t *testing.T) {
+	b, _ := NewMemoryBroker(Config{AckMode: AckModeAck, SubConfig: &SubscriptionConfig{Filter: `attributes:node`}})
+	b.PublishMessage([]byte("a"), WithAckMode(map[string]string{"node": "1"}, AckAttrManual), 0)
+	b.PublishMessage([]byte("b"), map[string]string{"node": "2"}, 0)
+	if _, err := b.ReceiveMessage(time.Second); err != nil {
+		t.Fatalf("Failed to receive: %v", err)
+	}
+
+	// The unsettled message is part of the snapshot
+	path := t.TempDir() + "/snapshot.json"
+	if err := SaveMemorySnapshot(path, b.Snapshot()); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	s, err := LoadMemorySnapshot(path)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if len(s.Messages) != 2 || s.Filter != `attributes:node` {
+		t.Fatalf("Expected 2 messages and the filter in the snapshot, got %+v", s)
+	}
+
+	restored, err := RestoreMemoryBroker(Config{AckMode: AckModeAck}, s)
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	defer restored.Close()
+	received := ""
+	for i := 0; i < 2; i++ {
+		msg, err := restored.ReceiveMessage(time.Second)
+		if err != nil {
+			t.Fatalf("Failed to receive restored message: %v", err)
+		}
+		received += string(msg.Data)
+	}
+	if received != "ba" {
+		t.Errorf("Expected to receive b then a, got %s", received)
+	}
+	id, _ := restored.PublishMessage([]byte("c"), map[string]string{"node": "3"}, 0)
+	for _, m := range s.Messages {
+		if m.ID == id {
+			t.Errorf("New message reuses restored ID %s", id)
+		}
+	}
+}
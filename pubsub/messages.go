@@ -0,0 +1,101 @@
+package pubsub
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// Messages returns a channel of the received messages, so consumers can select
+// on it alongside their own channels. Messages are settled, verified and
+// reassembled as with ReceiveMessage, which competes with the channel for
+// messages. A message held by PeekMessage is sent on the channel before the
+// next received one. With AckModeManual, the receiver settles the messages. The channel
+// is closed when the client is closed.
+func (c *PubSubClient) Messages() <-chan *ReceivedMessage {
+	c.startPump()
+	return c.messagesOut
+}
+
+// Errors returns a channel of the errors of receiving and processing messages
+// for Messages. Errors are dropped while the channel is full. The channel is
+// closed when the client is closed.
+func (c *PubSubClient) Errors() <-chan error {
+	c.startPump()
+	return c.errorsOut
+}
+
+// startPump starts moving received messages to the Messages channel
+func (c *PubSubClient) startPump() {
+	c.pumpOnce.Do(func() {
+		c.messagesOut = make(chan *ReceivedMessage)
+		c.errorsOut = make(chan error, 10)
+		c.peekMutex.Lock()
+		c.peekReady = make(chan struct{}, 1)
+		c.peekMutex.Unlock()
+		if !c.synchronous {
+			c.startContinuousReceiver()
+		}
+		go c.pump()
+	})
+}
+
+func (c *PubSubClient) pump() {
+	defer close(c.messagesOut)
+	defer close(c.errorsOut)
+	for {
+		var msg *pubsub.Message
+		var err error
+		if msg = c.popBuffered(); msg != nil {
+			err = c.verify(msg)
+		} else if m := c.popPeeked(); m != nil {
+			msg, err = c.deliver(m, true)
+		} else if c.synchronous {
+			if c.ctx.Err() != nil {
+				return
+			}
+			var m *pubsub.Message
+			if m, err = c.pull(syncPollTimeout); err == nil {
+				if m == nil {
+					continue
+				}
+				msg, err = c.deliver(m, true)
+			}
+		} else {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-c.peekReady:
+				continue
+			case m, ok := <-c.messageChan:
+				if !ok {
+					return
+				}
+				msg, err = c.deliver(m, ok)
+			case e, ok := <-c.errorChan:
+				if !ok {
+					return
+				}
+				err = fmt.Errorf("receiver error: %v", e)
+			}
+		}
+		if err == nil && msg != nil {
+			msg, err = c.assemble(msg)
+		}
+		if err != nil {
+			select {
+			case c.errorsOut <- err:
+			default:
+			}
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+		select {
+		case c.messagesOut <- c.wrapReceived(msg):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pubsub
+
+import (
+	"math/rand"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// PublishMutator rewrites the payload and attributes of a message in flight. It
+// is given copies it may modify.
+type PublishMutator func(data []byte, attributes map[string]string) ([]byte, map[string]string)
+
+// mutation applies a PublishMutator to published messages with a probability
+type mutation struct {
+	mutex   sync.Mutex
+	mutator PublishMutator
+	rate    float64
+	rand    *rand.Rand
+}
+
+func newMutation(mutator PublishMutator, rate float64, seed int64) *mutation {
+	return &mutation{mutator: mutator, rate: rate, rand: rand.New(rand.NewSource(seed))}
+}
+
+// apply returns the mutated message, or msg when it is not picked. Control
+// commands and probes are never mutated.
+func (m *mutation) apply(msg *pubsub.Message) (*pubsub.Message, bool) {
+	if _, ok := msg.Attributes[ControlAttribute]; ok {
+		return msg, false
+	}
+	if _, ok := msg.Attributes[ProbeAttribute]; ok {
+		return msg, false
+	}
+	m.mutex.Lock()
+	mutator := m.mutator
+	picked := mutator != nil && m.rate > 0 && m.rand.Float64() < m.rate
+	m.mutex.Unlock()
+	if !picked {
+		return msg, false
+	}
+
+	data, attrs := mutator(append([]byte(nil), msg.Data...), copyAttributes(msg.Attributes))
+	return &pubsub.Message{
+		Data:        data,
+		Attributes:  Annotate(attrs, AnnotationMutated, "true"),
+		OrderingKey: msg.OrderingKey,
+	}, true
+}
+
+// mutate applies the mutator of the client to msg, once the safety interlock
+// allows it
+func (c *PubSubClient) mutate(msg *pubsub.Message) (*pubsub.Message, error) {
+	mutated, ok := c.mutation.apply(msg)
+	if !ok {
+		return msg, nil
+	}
+	if err := c.allowFault("mutation"); err != nil {
+		return nil, err
+	}
+	return mutated, nil
+}
+
+// SetPublishMutator replaces the mutator of the client and its probability,
+// e.g. between fuzz iterations. A nil mutator disables mutation.
+func (c *PubSubClient) SetPublishMutator(mutator PublishMutator, rate float64) {
+	c.mutation.mutex.Lock()
+	defer c.mutation.mutex.Unlock()
+	c.mutation.mutator = mutator
+	c.mutation.rate = rate
+}
@@ -0,0 +1,79 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestPublishMutator(t *testing.T) {
+	t.Setenv("PUBSUB_EMULATOR_HOST", "localhost:8085")
+	flip := func(data []byte, attrs map[string]string) ([]byte, map[string]string) {
+		data[0] ^= 0xff
+		return data, attrs
+	}
+	client, err := NewPubSubClient(Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-mutate",
+		SubscriptionID: "test-sub-mutate",
+		Checksums:      true,
+		PublishMutator: flip,
+		MutationRate:   1,
+		DryRun:         true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	payload := []byte("payload")
+	if _, err := client.PublishMessage(payload, nil, time.Second); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if _, err := client.PublishMessage(nil, map[string]string{ControlAttribute: ControlAbort}, time.Second); err != nil {
+		t.Fatalf("Failed to publish control command: %v", err)
+	}
+	client.SetPublishMutator(flip, 0)
+	if _, err := client.PublishMessage(payload, nil, time.Second); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	recorded := client.DryRunMessages()
+	if string(payload) != "payload" {
+		t.Errorf("Mutator modified the caller payload: %s", payload)
+	}
+	mutated := recorded[0]
+	if string(mutated.Data) == "payload" || mutated.Attributes[AnnotationPrefix+AnnotationMutated] == "" {
+		t.Errorf("Expected an annotated mutation, got %+v", mutated)
+	}
+	if err := verifyChecksum(&pubsub.Message{Data: mutated.Data, Attributes: mutated.Attributes}); err == nil {
+		t.Error("Expected the mutation to break the checksum")
+	}
+	for i, msg := range recorded[1:] {
+		if _, ok := msg.Attributes[AnnotationPrefix+AnnotationMutated]; ok {
+			t.Errorf("Message %d should not be mutated: %+v", i+1, msg)
+		}
+	}
+}
+
+func TestPublishMutatorSafety(t *testing.T) {
+	t.Setenv("PUBSUB_EMULATOR_HOST", "")
+	client, err := NewPubSubClient(Config{
+		ProjectID:      "test-project",
+		TopicID:        "test-topic-mutate",
+		SubscriptionID: "test-sub-mutate",
+		PublishMutator: func(data []byte, attrs map[string]string) ([]byte, map[string]string) { return data, attrs },
+		MutationRate:   1,
+		DryRun:         true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.PublishMessage([]byte("payload"), nil, time.Second); !errors.Is(err, ErrFaultRefused) {
+		t.Errorf("Expected the interlock to refuse the mutation, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+)
+
+// observerExpiration expires the subscriptions of observers that were not closed.
+// It is the shortest expiration PubSub accepts.
+const observerExpiration = 24 * time.Hour
+
+// ObserverConfig holds the configuration of an ObserverClient
+type ObserverConfig struct {
+	ProjectID   string
+	Credentials string // Path to service account JSON file
+	// TopicIDs are the existing topics to observe
+	TopicIDs []string
+	// SubscriptionPrefix names the subscriptions of the observer, one per topic.
+	// Default: observer-<random ID>.
+	SubscriptionPrefix string
+	// Filter optionally restricts the observed messages
+	Filter string
+	// RunID labels the subscriptions of the observer
+	RunID string
+}
+
+// ObservedMessage is a message seen by an observer on one of its topics
+type ObservedMessage struct {
+	TopicID string
+	Message *pubsub.Message
+}
+
+// ObserverClient watches topics of a live campaign through subscriptions of its
+// own, so dashboards and recorders can be attached without perturbing delivery:
+// the primary consumers keep their subscriptions and ack state, and the observer
+// acks its own copies as soon as they are handled. Probes are skipped and chunked
+// payloads are reassembled.
+type ObserverClient struct {
+	client        *pubsub.Client
+	topics        []string
+	subscriptions []*pubsub.Subscription
+	chunks        *chunkAssembler
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	once          sync.Once
+}
+
+// NewObserverClient creates the subscriptions of the observer. The topics must
+// exist, the observer never creates them.
+func NewObserverClient(cfg ObserverConfig) (*ObserverClient, error) {
+	if len(cfg.TopicIDs) == 0 {
+		return nil, fmt.Errorf("observer needs at least one topic")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var opts []option.ClientOption
+	if cfg.Credentials != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.Credentials))
+	}
+	client, err := pubsub.NewClient(ctx, cfg.ProjectID, opts...)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create pubsub client: %v", err)
+	}
+	o := &ObserverClient{
+		client: client,
+		topics: cfg.TopicIDs,
+		chunks: newChunkAssembler(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	prefix := cfg.SubscriptionPrefix
+	if prefix == "" {
+		prefix = "observer-" + newID()
+	}
+	for _, topicID := range cfg.TopicIDs {
+		topic := client.Topic(topicID)
+		exists, err := topic.Exists(ctx)
+		if err != nil {
+			o.Close()
+			return nil, fmt.Errorf("failed to check topic existence: %v", err)
+		}
+		if !exists {
+			o.Close()
+			return nil, fmt.Errorf("topic %s does not exist", topicID)
+		}
+		subCfg := pubsub.SubscriptionConfig{
+			Topic:            topic,
+			Filter:           cfg.Filter,
+			ExpirationPolicy: observerExpiration,
+		}
+		if cfg.RunID != "" {
+			subCfg.Labels = map[string]string{RunIDLabel: cfg.RunID}
+		}
+		sub, err := client.CreateSubscription(ctx, prefix+"-"+topicID, subCfg)
+		if err != nil {
+			o.Close()
+			return nil, fmt.Errorf("failed to create observer subscription: %v", err)
+		}
+		o.subscriptions = append(o.subscriptions, sub)
+	}
+	return o, nil
+}
+
+// Observe calls handler with every message published on the observed topics from
+// now on, until Close. It is called once; handlers of different topics may run
+// concurrently.
+func (o *ObserverClient) Observe(handler func(ObservedMessage)) {
+	for i, sub := range o.subscriptions {
+		o.wg.Add(1)
+		go func(topicID string, sub *pubsub.Subscription) {
+			defer o.wg.Done()
+			sub.Receive(o.ctx, func(_ context.Context, msg *pubsub.Message) {
+				// Acking only settles the copy of the observer
+				msg.Ack()
+				if _, probe := msg.Attributes[ProbeAttribute]; probe {
+					return
+				}
+				if IsChunk(msg) {
+					full, complete, err := o.chunks.add(msg)
+					if err != nil || !complete {
+						return
+					}
+					msg = full
+				}
+				handler(ObservedMessage{TopicID: topicID, Message: msg})
+			})
+		}(o.topics[i], sub)
+	}
+}
+
+// Close stops observing and deletes the subscriptions of the observer
+func (o *ObserverClient) Close() error {
+	var err error
+	o.once.Do(func() {
+		o.cancel()
+		o.wg.Wait()
+		for _, sub := range o.subscriptions {
+			if derr := sub.Delete(context.Background()); derr != nil && err == nil {
+				err = fmt.Errorf("failed to delete observer subscription: %v", derr)
+			}
+		}
+		if cerr := o.client.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close pubsub client: %v", cerr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,60 @@
+package pubsub
+
+import "sync"
+
+// orderedItem is a message waiting for the earlier messages of its ordering key
+type orderedItem struct {
+	run  func() error
+	drop func()
+}
+
+// orderedDispatcher runs the messages of an ordering key one at a time, in the
+// order they were received, whichever goroutines receive them. Messages without
+// an ordering key run right away.
+type orderedDispatcher struct {
+	mutex sync.Mutex
+	// pending holds the waiting messages of the keys being run
+	pending map[string][]orderedItem
+}
+
+func newOrderedDispatcher() *orderedDispatcher {
+	return &orderedDispatcher{pending: make(map[string][]orderedItem)}
+}
+
+// dispatch runs run once the earlier messages of key ran, in the calling
+// goroutine if no message of key is running and in the goroutine running them
+// otherwise. When a run fails, the waiting messages of key are dropped, so that
+// they are redelivered after the failed one and stay in order.
+func (d *orderedDispatcher) dispatch(key string, run func() error, drop func()) {
+	if key == "" {
+		run()
+		return
+	}
+	d.mutex.Lock()
+	if items, running := d.pending[key]; running {
+		d.pending[key] = append(items, orderedItem{run: run, drop: drop})
+		d.mutex.Unlock()
+		return
+	}
+	d.pending[key] = nil
+	d.mutex.Unlock()
+
+	for {
+		err := run()
+		d.mutex.Lock()
+		items := d.pending[key]
+		if err != nil || len(items) == 0 {
+			delete(d.pending, key)
+			d.mutex.Unlock()
+			if err != nil {
+				for _, item := range items {
+					item.drop()
+				}
+			}
+			return
+		}
+		run = items[0].run
+		d.pending[key] = items[1:]
+		d.mutex.Unlock()
+	}
+}
@@ -0,0 +1,94 @@
+package pubsub
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrderedDispatcher(t *testing.T) {
+	d := newOrderedDispatcher()
+
+	var mutex sync.Mutex
+	var order []int
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.dispatch("key", func() error {
+			close(started)
+			<-release
+			mutex.Lock()
+			order = append(order, 0)
+			mutex.Unlock()
+			return nil
+		}, func() {})
+	}()
+	<-started
+	for i := 1; i <= 3; i++ {
+		i := i
+		// queued behind the running message, so dispatch returns right away
+		d.dispatch("key", func() error {
+			mutex.Lock()
+			order = append(order, i)
+			mutex.Unlock()
+			return nil
+		}, func() {})
+	}
+	other := false
+	d.dispatch("other", func() error {
+		other = true
+		return nil
+	}, func() {})
+	if !other {
+		t.Fatalf("message of another key did not run right away")
+	}
+	close(release)
+	wg.Wait()
+	if fmt.Sprint(order) != "[0 1 2 3]" {
+		t.Fatalf("unexpected order %v", order)
+	}
+
+	release = make(chan struct{})
+	started = make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.dispatch("key", func() error {
+			close(started)
+			<-release
+			return fmt.Errorf("failed")
+		}, func() {})
+	}()
+	<-started
+	ran, dropped := 0, 0
+	for i := 0; i < 2; i++ {
+		d.dispatch("key", func() error {
+			ran++
+			return nil
+		}, func() {
+			dropped++
+		})
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("dispatch did not return")
+	}
+	if ran != 0 || dropped != 2 {
+		t.Fatalf("expected the waiting messages to be dropped, ran %d dropped %d", ran, dropped)
+	}
+
+	empty := false
+	d.dispatch("", func() error {
+		empty = true
+		return nil
+	}, func() {})
+	if !empty {
+		t.Fatalf("message without ordering key did not run")
+	}
+}
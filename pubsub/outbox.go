@@ -0,0 +1,187 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrQueued is returned when a message could not be published and was queued in
+// the outbox instead. It is published once the broker is reachable again.
+var ErrQueued = errors.New("message queued in outbox")
+
+// defaultOutboxRetry is the default interval between flushes of the outbox
+const defaultOutboxRetry = time.Second
+
+// outboxEntry is a message waiting in the outbox
+type outboxEntry struct {
+	Data       []byte
+	Attributes map[string]string
+	QueuedAt   time.Time
+}
+
+// OutboxConfig holds the configuration of an Outbox
+type OutboxConfig struct {
+	// Path of the file persisting the queued messages
+	Path string
+	// RetryInterval is the interval between attempts to flush the queued
+	// messages. Default: 1s.
+	RetryInterval time.Duration
+	// OnFlush is optionally called with the number of messages published by
+	// every flush that published some
+	OnFlush func(n int)
+}
+
+// Outbox is a Broker that queues the messages it fails to publish in a file and
+// publishes them once the underlying broker is reachable again, so messages
+// survive emulator restarts and harness restarts alike. Messages are published in
+// order: while messages are queued, new ones are queued behind them. Schema
+// validation and flow control failures are not queued, they would fail again.
+type Outbox struct {
+	Broker
+	path    string
+	onFlush func(n int)
+
+	mutex   sync.Mutex
+	pending []outboxEntry
+
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+var _ Broker = (*Outbox)(nil)
+
+// NewOutbox wraps broker with an outbox persisted at cfg.Path. Messages queued by
+// an earlier run are loaded and flushed first.
+func NewOutbox(broker Broker, cfg OutboxConfig) (*Outbox, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("outbox path is required")
+	}
+	retry := cfg.RetryInterval
+	if retry <= 0 {
+		retry = defaultOutboxRetry
+	}
+	o := &Outbox{
+		Broker:  broker,
+		path:    cfg.Path,
+		onFlush: cfg.OnFlush,
+		pending: make([]outboxEntry, 0),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read outbox: %v", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &o.pending); err != nil {
+			return nil, fmt.Errorf("failed to parse outbox: %v", err)
+		}
+	}
+	go o.flushLoop(retry)
+	return o, nil
+}
+
+// PublishMessage publishes the message, or queues it if the broker is
+// unreachable or earlier messages are still queued. A queued message is reported
+// with ErrQueued.
+func (o *Outbox) PublishMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if len(o.pending) == 0 {
+		id, err := o.Broker.PublishMessage(data, attributes, timeout)
+		if err == nil || errors.Is(err, ErrSchemaValidation) || errors.Is(err, ErrPublishFlowControl) {
+			return id, err
+		}
+	}
+	o.pending = append(o.pending, outboxEntry{
+		Data:       data,
+		Attributes: copyAttributes(attributes),
+		QueuedAt:   time.Now(),
+	})
+	if err := o.persist(); err != nil {
+		return "", err
+	}
+	return "", ErrQueued
+}
+
+// Pending returns the number of queued messages
+func (o *Outbox) Pending() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return len(o.pending)
+}
+
+// Flush publishes the queued messages in order, stopping at the first failure.
+// It returns the number of messages published.
+func (o *Outbox) Flush(timeout time.Duration) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	published := 0
+	var err error
+	for _, entry := range o.pending {
+		_, err = o.Broker.PublishMessage(entry.Data, entry.Attributes, timeout)
+		if err != nil && !errors.Is(err, ErrSchemaValidation) {
+			break
+		}
+		// Messages failing validation are dropped, they can never be published
+		err = nil
+		published++
+	}
+	if published == 0 {
+		return 0, err
+	}
+	o.pending = o.pending[published:]
+	if perr := o.persist(); perr != nil {
+		return published, perr
+	}
+	if o.onFlush != nil {
+		o.onFlush(published)
+	}
+	return published, err
+}
+
+// persist writes the queued messages to the outbox file. The file is replaced
+// atomically so a crash never leaves a truncated outbox.
+func (o *Outbox) persist() error {
+	data, err := json.Marshal(o.pending)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox: %v", err)
+	}
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write outbox: %v", err)
+	}
+	if err := os.Rename(tmp, o.path); err != nil {
+		return fmt.Errorf("failed to write outbox: %v", err)
+	}
+	return nil
+}
+
+func (o *Outbox) flushLoop(retry time.Duration) {
+	defer close(o.stopped)
+	ticker := time.NewTicker(retry)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.done:
+			return
+		case <-ticker.C:
+			if o.Pending() > 0 {
+				o.Flush(retry)
+			}
+		}
+	}
+}
+
+// Close stops flushing and closes the broker. Messages still queued stay in the
+// outbox file for the next run.
+func (o *Outbox) Close() error {
+	o.once.Do(func() { close(o.done) })
+	<-o.stopped
+	return o.Broker.Close()
+}
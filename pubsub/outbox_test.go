@@ -0,0 +1,72 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unreachableBroker fails to publish while down
+type unreachableBroker struct {
+	*MemoryBroker
+	down int32
+}
+
+func (b *unreachableBroker) PublishMessage(data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
+	if atomic.LoadInt32(&b.down) == 1 {
+		return "", fmt.Errorf("failed to publish message: connection refused")
+	}
+	return b.MemoryBroker.PublishMessage(data, attributes, timeout)
+}
+
+func TestOutbox(t *testing.T) {
+	memory, _ := NewMemoryBroker(Config{AckMode: AckModeAck})
+	broker := &unreachableBroker{MemoryBroker: memory, down: 1}
+	path := filepath.Join(t.TempDir(), "outbox.json")
+
+	outbox, err := NewOutbox(broker, OutboxConfig{Path: path, RetryInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+	for _, data := range []string{"a", "b"} {
+		if _, err := outbox.PublishMessage([]byte(data), nil, 0); !errors.Is(err, ErrQueued) {
+			t.Fatalf("Expected %s to be queued, got %v", data, err)
+		}
+	}
+	outbox.Close()
+
+	// The queued messages survive a restart and are flushed in order once the
+	// broker is reachable
+	memory, _ = NewMemoryBroker(Config{AckMode: AckModeAck})
+	broker = &unreachableBroker{MemoryBroker: memory, down: 1}
+	outbox, err = NewOutbox(broker, OutboxConfig{Path: path, RetryInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to reopen outbox: %v", err)
+	}
+	defer outbox.Close()
+	if outbox.Pending() != 2 {
+		t.Fatalf("Expected 2 pending messages after restart, got %d", outbox.Pending())
+	}
+	atomic.StoreInt32(&broker.down, 0)
+	if _, err := outbox.PublishMessage([]byte("c"), nil, 0); !errors.Is(err, ErrQueued) {
+		t.Fatalf("Expected c to be queued behind the pending messages, got %v", err)
+	}
+	if n, err := outbox.Flush(0); n != 3 || err != nil {
+		t.Fatalf("Expected to flush 3 messages, got %d: %v", n, err)
+	}
+	for _, expected := range []string{"a", "b", "c"} {
+		msg, err := outbox.ReceiveMessage(time.Second)
+		if err != nil {
+			t.Fatalf("Failed to receive %s: %v", expected, err)
+		}
+		if string(msg.Data) != expected {
+			t.Fatalf("Expected %s, got %s", expected, msg.Data)
+		}
+	}
+	if _, err := outbox.PublishMessage([]byte("d"), nil, 0); err != nil {
+		t.Fatalf("Expected d to be published directly, got %v", err)
+	}
+}
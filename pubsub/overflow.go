@@ -0,0 +1,77 @@
+package pubsub
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// defaultBufferSize is the number of received messages queued for ReceiveMessage
+const defaultBufferSize = 100
+
+// OverflowPolicy is what the receiver does with a delivery once the receive
+// buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowNack nacks the delivered message, so that it is redelivered
+	OverflowNack OverflowPolicy = iota
+	// OverflowBlock waits for room in the buffer, holding the message unsettled
+	// and, once MaxOutstandingMessages are held, the deliveries that follow
+	OverflowBlock
+	// OverflowDropOldest acks the oldest buffered message to make room for the
+	// delivered one, so that it is lost
+	OverflowDropOldest
+	// OverflowDropNewest acks the delivered message, so that it is lost
+	OverflowDropNewest
+)
+
+// Overflows returns the number of deliveries that found the receive buffer full
+func (c *PubSubClient) Overflows() int64 {
+	return atomic.LoadInt64(&c.overflows)
+}
+
+// overflow handles a delivery finding the receive buffer full according to the
+// overflow policy
+func (c *PubSubClient) overflow(ctx context.Context, msg *pubsub.Message, now time.Time) {
+	atomic.AddInt64(&c.overflows, 1)
+	switch c.overflowPolicy {
+	case OverflowBlock:
+		select {
+		case c.messageChan <- msg:
+			if c.scaler != nil {
+				c.scaler.observe(msg, now, false)
+			}
+			return
+		case <-c.ctx.Done():
+		case <-ctx.Done():
+		}
+		c.acknowledge(msg, false)
+		return
+	case OverflowDropOldest:
+		select {
+		case oldest := <-c.messageChan:
+			c.acknowledge(oldest, true)
+		default:
+		}
+		select {
+		case c.messageChan <- msg:
+			if c.scaler != nil {
+				c.scaler.observe(msg, now, false)
+			}
+			return
+		default:
+			// Another delivery took the room
+		}
+		c.acknowledge(msg, true)
+	case OverflowDropNewest:
+		c.acknowledge(msg, true)
+	default:
+		c.acknowledge(msg, false)
+	}
+	if c.scaler != nil {
+		c.scaler.observe(msg, now, true)
+	}
+}
@@ -0,0 +1,82 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestOverflowPolicy(t *testing.T) {
+	newClient := func(policy OverflowPolicy) *PubSubClient {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		return &PubSubClient{
+			ctx:            ctx,
+			cancel:         cancel,
+			deliveries:     newDeliveryLog(),
+			probes:         make(map[string]chan struct{}),
+			messageChan:    make(chan *pubsub.Message, 1),
+			errorChan:      make(chan error, 1),
+			overflowPolicy: policy,
+		}
+	}
+	queued := func(c *PubSubClient) string {
+		select {
+		case msg := <-c.messageChan:
+			return msg.ID
+		default:
+			return ""
+		}
+	}
+
+	for _, policy := range []OverflowPolicy{OverflowNack, OverflowDropNewest} {
+		c := newClient(policy)
+		c.handleReceived(context.Background(), &pubsub.Message{ID: "first"})
+		c.handleReceived(context.Background(), &pubsub.Message{ID: "second"})
+		if id := queued(c); id != "first" {
+			t.Errorf("policy %d: expected the first message to stay queued, got %q", policy, id)
+		}
+		if c.Overflows() != 1 {
+			t.Errorf("policy %d: expected 1 overflow, got %d", policy, c.Overflows())
+		}
+	}
+
+	c := newClient(OverflowDropOldest)
+	c.handleReceived(context.Background(), &pubsub.Message{ID: "first"})
+	c.handleReceived(context.Background(), &pubsub.Message{ID: "second"})
+	if id := queued(c); id != "second" {
+		t.Errorf("Expected the oldest message to be dropped, got %q queued", id)
+	}
+	if c.Overflows() != 1 {
+		t.Errorf("Expected 1 overflow, got %d", c.Overflows())
+	}
+
+	c = newClient(OverflowBlock)
+	c.handleReceived(context.Background(), &pubsub.Message{ID: "first"})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.handleReceived(context.Background(), &pubsub.Message{ID: "second"})
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Expected the overflowing delivery to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if id := queued(c); id != "first" {
+		t.Errorf("Expected the first message to be queued, got %q", id)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the blocked delivery to be queued once room was made")
+	}
+	if id := queued(c); id != "second" {
+		t.Errorf("Expected the blocked message to be queued, got %q", id)
+	}
+	if s := c.debugSnapshot(); s.Receiver.Overflows != 1 {
+		t.Errorf("Expected 1 overflow in the debug snapshot, got %d", s.Receiver.Overflows)
+	}
+}
@@ -0,0 +1,161 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// peekIdleTimeout ends a peek once the auxiliary subscription stays quiet that long
+const peekIdleTimeout = time.Second
+
+// peekPrefix names the temporary snapshots and subscriptions of peeks
+const peekPrefix = "peek-"
+
+// Peek returns up to n messages pending on the subscription without consuming
+// them. The backlog is captured in a temporary snapshot that an auxiliary
+// subscription seeks to, so the receiver and the ack state of the subscription
+// are left untouched.
+func (c *PubSubClient) Peek(ctx context.Context, n int) ([]*pubsub.Message, error) {
+	return peekSubscription(ctx, c.client, c.subscription, n)
+}
+
+// peekSubscription returns up to n messages pending on sub without consuming them
+func peekSubscription(ctx context.Context, client *pubsub.Client, sub *pubsub.Subscription, n int) ([]*pubsub.Message, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	subCfg, err := sub.Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription config: %v", err)
+	}
+
+	id := peekPrefix + newID()
+	snapshot, err := sub.CreateSnapshot(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %v", err)
+	}
+	defer snapshot.Delete(context.Background())
+
+	aux, err := client.CreateSubscription(ctx, id, pubsub.SubscriptionConfig{
+		Topic:  subCfg.Topic,
+		Filter: subCfg.Filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auxiliary subscription: %v", err)
+	}
+	defer aux.Delete(context.Background())
+	if err := aux.SeekToSnapshot(ctx, snapshot.Snapshot); err != nil {
+		return nil, fmt.Errorf("failed to seek to snapshot: %v", err)
+	}
+
+	var mutex sync.Mutex
+	messages := make([]*pubsub.Message, 0, n)
+	received := make(chan struct{}, 1)
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	aux.ReceiveSettings.MaxOutstandingMessages = n
+	go func() {
+		for {
+			select {
+			case <-received:
+			case <-time.After(peekIdleTimeout):
+				cancel()
+				return
+			case <-rctx.Done():
+				return
+			}
+		}
+	}()
+	err = aux.Receive(rctx, func(_ context.Context, msg *pubsub.Message) {
+		msg.Nack()
+		mutex.Lock()
+		defer mutex.Unlock()
+		if _, probe := msg.Attributes[ProbeAttribute]; probe || len(messages) >= n {
+			return
+		}
+		messages = append(messages, msg)
+		if len(messages) == n {
+			cancel()
+		}
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil && err != context.Canceled {
+		return nil, fmt.Errorf("failed to peek messages: %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return messages, nil
+}
+
+// Peek returns copies of up to n messages awaiting delivery, in delivery order
+func (b *MemoryBroker) Peek(ctx context.Context, n int) ([]*pubsub.Message, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	messages := make([]*pubsub.Message, 0, n)
+	for _, m := range b.ready {
+		if len(messages) == n {
+			break
+		}
+		messages = append(messages, &pubsub.Message{
+			ID:          m.id,
+			Data:        append([]byte(nil), m.data...),
+			Attributes:  copyAttributes(m.attributes),
+			PublishTime: m.published,
+		})
+	}
+	return messages, nil
+}
+
+// PeekMessage returns the message the next ReceiveMessage returns, without
+// settling it or taking it from the client, so that assertions can inspect the
+// pending traffic without disturbing the stream under test. Unlike Peek, it
+// goes through the receiver of the client. Buffered messages are peeked first;
+// chunks of large payloads are peeked as they arrive, since reassembling them
+// consumes them. A peeked message is held unsettled until it is received.
+func (c *PubSubClient) PeekMessage(timeout time.Duration) (*pubsub.Message, error) {
+	c.bufferMutex.Lock()
+	if len(c.messageBuffer) > 0 {
+		msg := c.messageBuffer[0]
+		c.bufferMutex.Unlock()
+		return msg, nil
+	}
+	c.bufferMutex.Unlock()
+
+	c.peekMutex.Lock()
+	defer c.peekMutex.Unlock()
+	if c.peeked != nil {
+		return c.peeked, nil
+	}
+	msg, err := c.next(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("timeout waiting for message")
+	}
+	c.peeked = msg
+	if c.peekReady != nil {
+		select {
+		case c.peekReady <- struct{}{}:
+		default:
+		}
+	}
+	return msg, nil
+}
+
+// popPeeked returns the message held by PeekMessage, if any
+func (c *PubSubClient) popPeeked() *pubsub.Message {
+	c.peekMutex.Lock()
+	defer c.peekMutex.Unlock()
+	msg := c.peeked
+	c.peeked = nil
+	return msg
+}
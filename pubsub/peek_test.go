@@ -0,0 +1,61 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+func TestPeekMessage(t *testing.T) {
+	c := newTestClient(t, AckModeAck)
+
+	if _, err := c.PeekMessage(10 * time.Millisecond); err == nil {
+		t.Fatalf("Expected peeking an empty subscription to time out")
+	}
+
+	c.BufferMessage(&pubsub.Message{ID: "buffered"})
+	c.messageChan <- &pubsub.Message{ID: "first"}
+	c.messageChan <- &pubsub.Message{ID: "second"}
+
+	for _, expected := range []string{"buffered", "first", "second"} {
+		for i := 0; i < 2; i++ {
+			msg, err := c.PeekMessage(100 * time.Millisecond)
+			if err != nil {
+				t.Fatalf("Failed to peek %s: %v", expected, err)
+			}
+			if msg.ID != expected {
+				t.Errorf("Expected to peek %s, got %s", expected, msg.ID)
+			}
+		}
+		msg, err := c.ReceiveMessage(100 * time.Millisecond)
+		if err != nil {
+			t.Fatalf("Failed to receive %s: %v", expected, err)
+		}
+		if msg.ID != expected {
+			t.Errorf("Expected to receive %s, got %s", expected, msg.ID)
+		}
+	}
+}
+
+func TestMessagesAfterPeek(t *testing.T) {
+	c := newTestClient(t, AckModeAck)
+
+	c.messageChan <- &pubsub.Message{ID: "first"}
+	if _, err := c.PeekMessage(100 * time.Millisecond); err != nil {
+		t.Fatalf("Failed to peek: %v", err)
+	}
+	c.messageChan <- &pubsub.Message{ID: "second"}
+
+	messages := c.Messages()
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case msg := <-messages:
+			if msg.ID != expected {
+				t.Errorf("Expected %s, got %s", expected, msg.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %s", expected)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// ProbeAttribute tags probe messages published by the client itself. Probes are
+// consumed by the receiver and never handed to callers.
+const ProbeAttribute = "fuzz-probe"
+
+// probeAttemptTimeout bounds how long a single readiness probe is awaited before
+// another one is published
+const probeAttemptTimeout = 500 * time.Millisecond
+
+// newID returns a random identifier for tagging messages
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// interceptProbe acknowledges msg and wakes up its waiter if it is a probe.
+// Returns true if the message was a probe.
+func (c *PubSubClient) interceptProbe(msg *pubsub.Message) bool {
+	id, ok := msg.Attributes[ProbeAttribute]
+	if !ok {
+		return false
+	}
+	msg.Ack()
+
+	c.probeMutex.Lock()
+	defer c.probeMutex.Unlock()
+	if done, ok := c.probes[id]; ok {
+		close(done)
+		delete(c.probes, id)
+	}
+	return true
+}
+
+// RoundTripProbe publishes a uniquely tagged probe and waits until it comes back on
+// the subscription, returning the measured round-trip time. The probe is published
+// as-is, bypassing the PublishMutator, and is received the way the client
+// receives: by a synchronous pull with SynchronousPull, by the running Subscribe,
+// otherwise by the continuous receiver. With Subscribe, probe while it runs: a
+// probe sent before starts the continuous receiver, and Subscribe then fails.
+// Probes are not supported in dry-run mode.
+func (c *PubSubClient) RoundTripProbe(ctx context.Context) (time.Duration, error) {
+	if c.dryRun != nil {
+		return 0, fmt.Errorf("probes are not supported in dry-run mode")
+	}
+	id := newID()
+	done := make(chan struct{})
+	c.probeMutex.Lock()
+	c.probes[id] = done
+	c.probeMutex.Unlock()
+	defer func() {
+		c.probeMutex.Lock()
+		delete(c.probes, id)
+		c.probeMutex.Unlock()
+	}()
+
+	c.receiverMutex.Lock()
+	subscribed := c.subscribed
+	c.receiverMutex.Unlock()
+	if !subscribed && !c.synchronous {
+		c.startContinuousReceiver()
+	}
+	start := time.Now()
+	if err := c.publishProbe(ctx, id); err != nil {
+		return 0, err
+	}
+	if !subscribed && c.synchronous {
+		if err := c.pullProbe(ctx, done); err != nil {
+			return 0, err
+		}
+	}
+
+	select {
+	case <-done:
+		return time.Since(start), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-c.ctx.Done():
+		return 0, fmt.Errorf("client closed")
+	}
+}
+
+// publishProbe publishes the probe with the given ID to the topic, without
+// mutation
+func (c *PubSubClient) publishProbe(ctx context.Context, id string) error {
+	ctx, cancel := c.clientContext(ctx)
+	defer cancel()
+	result := c.topic.Publish(ctx, &pubsub.Message{
+		Attributes: map[string]string{ProbeAttribute: id},
+	})
+	if _, err := result.Get(ctx); err != nil {
+		return publishError(ctx, err)
+	}
+	return nil
+}
+
+// pullProbe pulls until the probe signals done or ctx ends. The messages pulled
+// meanwhile are queued for the next receives.
+func (c *PubSubClient) pullProbe(ctx context.Context, done chan struct{}) error {
+	ctx, cancel := c.clientContext(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for ctx.Err() == nil {
+		if err := c.pullBatch(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitReady blocks until the subscription delivers to the client, the streaming
+// pull being established unless SynchronousPull is set, by publishing probes
+// until one of them is received. Subscriptions with a Filter must
+// let messages carrying ProbeAttribute through.
+func (c *PubSubClient) WaitReady(ctx context.Context) error {
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, probeAttemptTimeout)
+		_, err := c.RoundTripProbe(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("subscription not ready: %v", ctx.Err())
+		}
+		if attemptCtx.Err() == nil {
+			return fmt.Errorf("failed to probe subscription: %v", err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// syncPollTimeout bounds each pull of the Messages pump in synchronous mode, so
+// that it notices Close
+const syncPollTimeout = 500 * time.Millisecond
+
+// pull receives the next message with a synchronous pull of at most
+// pullMaxMessages messages, without a receiver running in the background. The
+// other messages of the pull are queued for the next receives. It returns nil
+// without an error when no message arrived within timeout, including while
+// messages of the previous pull are left unsettled.
+func (c *PubSubClient) pull(timeout time.Duration) (*pubsub.Message, error) {
+	if msg := c.popPulled(); msg != nil {
+		return msg, nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+	if err := c.pullBatch(ctx); err != nil {
+		return nil, err
+	}
+	return c.popPulled(), nil
+}
+
+// pullBatch pulls messages until the first one arrives or ctx is done, and
+// queues them for the next receives. Probes are consumed on the way.
+func (c *PubSubClient) pullBatch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// A subscription is received by one pull at a time: the slot is held until
+	// Receive returns, that is until the messages of the pull are settled
+	c.pullMutex.Lock()
+	if c.pullSlot == nil {
+		c.pullSlot = make(chan struct{}, 1)
+	}
+	slot := c.pullSlot
+	c.pullMutex.Unlock()
+	select {
+	case slot <- struct{}{}:
+	case <-ctx.Done():
+		return nil
+	}
+	c.subscription.ReceiveSettings.Synchronous = true
+	c.subscription.ReceiveSettings.NumGoroutines = 1
+	c.subscription.ReceiveSettings.MaxOutstandingMessages = c.pullMaxMessages
+	c.subscription.ReceiveSettings.MaxExtension = c.maxExtension()
+
+	// Messages arriving once the pull stopped accepting them are nacked, so that
+	// Receive does not wait for them
+	var mutex sync.Mutex
+	accepting := true
+	messages := make(chan *pubsub.Message, c.pullMaxMessages)
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-slot }()
+		// Receive returns once the pulled messages are settled
+		done <- c.subscription.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
+			if c.interceptProbe(msg) {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			if !accepting || ctx.Err() != nil {
+				c.acknowledge(msg, false)
+				return
+			}
+			c.deliveries.recordDelivery(msg, time.Now())
+			select {
+			case messages <- msg:
+			default:
+				c.acknowledge(msg, false)
+			}
+		})
+	}()
+	// stop stops accepting messages and queues the accepted ones, after first if
+	// set, for the next receives
+	stop := func(first *pubsub.Message) {
+		cancel()
+		mutex.Lock()
+		defer mutex.Unlock()
+		accepting = false
+		c.pullMutex.Lock()
+		defer c.pullMutex.Unlock()
+		if first != nil {
+			c.pulled = append(c.pulled, first)
+		}
+		for {
+			select {
+			case m := <-messages:
+				c.pulled = append(c.pulled, m)
+			default:
+				return
+			}
+		}
+	}
+
+	select {
+	case msg := <-messages:
+		stop(msg)
+		return nil
+	case err := <-done:
+		stop(nil)
+		if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+			return fmt.Errorf("failed to pull messages: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		stop(nil)
+		return nil
+	}
+}
+
+// popPulled returns the oldest message queued by an earlier pull, if any
+func (c *PubSubClient) popPulled() *pubsub.Message {
+	c.pullMutex.Lock()
+	defer c.pullMutex.Unlock()
+	if len(c.pulled) == 0 {
+		return nil
+	}
+	msg := c.pulled[0]
+	c.pulled = c.pulled[1:]
+	return msg
+}
@@ -0,0 +1,124 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when the rate limit would hold a publish beyond its
+// timeout
+var ErrRateLimited = errors.New("publish rate limit exceeded")
+
+// RateLimit shapes the load published by the client. Each limit is a token
+// bucket refilled at the given rate that holds up to one second worth of
+// tokens, so bursts never exceed the rate. Zero fields are unlimited.
+type RateLimit struct {
+	MessagesPerSecond float64
+	BytesPerSecond    float64
+}
+
+// tokenBucket is refilled at rate tokens per second up to burst tokens. Tokens
+// may go negative: a reservation larger than the available tokens waits until
+// the debt is refilled.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	burst := math.Max(rate, 1)
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// wait returns how long a reservation of n tokens waits at now
+func (b *tokenBucket) wait(n float64, now time.Time) time.Duration {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// rateLimiter applies a RateLimit to the publishes of a client
+type rateLimiter struct {
+	mutex    sync.Mutex
+	messages *tokenBucket
+	bytes    *tokenBucket
+}
+
+func newRateLimiter(limit *RateLimit) *rateLimiter {
+	if limit == nil || (limit.MessagesPerSecond <= 0 && limit.BytesPerSecond <= 0) {
+		return nil
+	}
+	now := time.Now()
+	l := &rateLimiter{}
+	if limit.MessagesPerSecond > 0 {
+		l.messages = newTokenBucket(limit.MessagesPerSecond, now)
+	}
+	if limit.BytesPerSecond > 0 {
+		l.bytes = newTokenBucket(limit.BytesPerSecond, now)
+	}
+	return l
+}
+
+// reserve takes the tokens of a message of size bytes and returns how long the
+// message must wait for them. A reservation waiting longer than maxWait is
+// refused, without taking tokens. A zero maxWait waits as long as needed.
+func (l *rateLimiter) reserve(size int, maxWait time.Duration) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now()
+	var wait time.Duration
+	if l.messages != nil {
+		wait = l.messages.wait(1, now)
+	}
+	if l.bytes != nil {
+		if w := l.bytes.wait(float64(size), now); w > wait {
+			wait = w
+		}
+	}
+	if maxWait > 0 && wait > maxWait {
+		return wait, false
+	}
+	if l.messages != nil {
+		l.messages.tokens--
+	}
+	if l.bytes != nil {
+		l.bytes.tokens -= float64(size)
+	}
+	return wait, true
+}
+
+// limit holds a publish of size bytes until the rate limit allows it, failing
+// with ErrRateLimited if that is past the deadline of ctx
+func (c *PubSubClient) limit(ctx context.Context, size int) error {
+	if c.limiter == nil {
+		return nil
+	}
+	var maxWait time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		if maxWait = time.Until(deadline); maxWait <= 0 {
+			return fmt.Errorf("%w: deadline exceeded", ErrRateLimited)
+		}
+	}
+	wait, ok := c.limiter.reserve(size, maxWait)
+	if !ok {
+		return fmt.Errorf("%w: would wait %v, timeout %v", ErrRateLimited, wait, maxWait)
+	}
+	if wait == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to publish message: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,34 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if newRateLimiter(&RateLimit{}) != nil {
+		t.Error("Expected no limiter without limits")
+	}
+
+	l := newRateLimiter(&RateLimit{MessagesPerSecond: 10})
+	for i := 0; i < 10; i++ {
+		if wait, ok := l.reserve(100, 0); !ok || wait != 0 {
+			t.Fatalf("Expected message %d of the burst to pass, waited %v", i, wait)
+		}
+	}
+	wait, ok := l.reserve(100, 0)
+	if !ok || wait < 90*time.Millisecond || wait > 100*time.Millisecond {
+		t.Errorf("Expected to wait about 100ms after the burst, got %v", wait)
+	}
+	if _, ok := l.reserve(100, 50*time.Millisecond); ok {
+		t.Error("Expected a reservation beyond the timeout to be refused")
+	}
+
+	l = newRateLimiter(&RateLimit{BytesPerSecond: 1000})
+	if wait, ok := l.reserve(1000, 0); !ok || wait != 0 {
+		t.Fatalf("Expected a second worth of bytes to pass, waited %v", wait)
+	}
+	if wait, _ := l.reserve(500, 0); wait < 490*time.Millisecond {
+		t.Errorf("Expected to wait about 500ms for 500 bytes, got %v", wait)
+	}
+}
@@ -0,0 +1,63 @@
+package pubsub
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// maxRetryBackoff is the longest backoff PubSub accepts in a retry policy
+const maxRetryBackoff = 600 * time.Second
+
+// RetryPolicy delays the redelivery of a nacked message exponentially, from
+// MinimumBackoff after the first nack up to MaximumBackoff. Zero fields keep the
+// PubSub defaults of 10s and 600s.
+type RetryPolicy struct {
+	MinimumBackoff time.Duration
+	MaximumBackoff time.Duration
+}
+
+func (p *RetryPolicy) validate() error {
+	if p.MinimumBackoff < 0 || p.MinimumBackoff > maxRetryBackoff {
+		return fmt.Errorf("minimum backoff %v is not within [0, %v]", p.MinimumBackoff, maxRetryBackoff)
+	}
+	if p.MaximumBackoff < 0 || p.MaximumBackoff > maxRetryBackoff {
+		return fmt.Errorf("maximum backoff %v is not within [0, %v]", p.MaximumBackoff, maxRetryBackoff)
+	}
+	if p.MaximumBackoff > 0 && p.MinimumBackoff > p.MaximumBackoff {
+		return fmt.Errorf("minimum backoff %v exceeds maximum backoff %v", p.MinimumBackoff, p.MaximumBackoff)
+	}
+	return nil
+}
+
+func (p *RetryPolicy) pubsub() *pubsub.RetryPolicy {
+	policy := &pubsub.RetryPolicy{}
+	if p.MinimumBackoff > 0 {
+		policy.MinimumBackoff = p.MinimumBackoff
+	}
+	if p.MaximumBackoff > 0 {
+		policy.MaximumBackoff = p.MaximumBackoff
+	}
+	return policy
+}
+
+// backoff returns the redelivery delay of a message nacked after the given
+// number of deliveries
+func (p *RetryPolicy) backoff(deliveries int) time.Duration {
+	min, max := p.MinimumBackoff, p.MaximumBackoff
+	if min == 0 {
+		min = 10 * time.Second
+	}
+	if max == 0 {
+		max = maxRetryBackoff
+	}
+	delay := min
+	for i := 1; i < deliveries && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
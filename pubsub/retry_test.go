@@ -0,0 +1,41 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{MinimumBackoff: 50 * time.Millisecond, MaximumBackoff: 150 * time.Millisecond}
+	for deliveries, expected := range map[int]time.Duration{
+		1: 50 * time.Millisecond,
+		2: 100 * time.Millisecond,
+		3: 150 * time.Millisecond,
+		9: 150 * time.Millisecond,
+	} {
+		if backoff := policy.backoff(deliveries); backoff != expected {
+			t.Errorf("Expected a backoff of %v after %d deliveries, got %v", expected, deliveries, backoff)
+		}
+	}
+	if err := (&RetryPolicy{MinimumBackoff: time.Minute, MaximumBackoff: time.Second}).validate(); err == nil {
+		t.Errorf("Expected a minimum above the maximum to be refused")
+	}
+
+	b, err := NewMemoryBroker(Config{AckMode: AckModeNack, SubConfig: &SubscriptionConfig{RetryPolicy: policy}})
+	if err != nil {
+		t.Fatalf("Failed to create broker: %v", err)
+	}
+	defer b.Close()
+	if _, err := b.PublishMessage([]byte("retried"), nil, time.Second); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if _, err := b.ReceiveMessage(time.Second); err != nil {
+		t.Fatalf("Failed to receive: %v", err)
+	}
+	if _, err := b.ReceiveMessage(20 * time.Millisecond); err == nil {
+		t.Errorf("Expected the nacked message to be held back")
+	}
+	if _, err := b.ReceiveMessage(time.Second); err != nil {
+		t.Errorf("Expected the nacked message to be redelivered after the backoff: %v", err)
+	}
+}
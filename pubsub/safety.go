@@ -0,0 +1,82 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// RunIDLabel labels the topics and subscriptions created for a campaign with its
+// run ID
+const RunIDLabel = "fuzz-run-id"
+
+// ErrFaultRefused is returned when the safety interlock refuses to inject a fault
+var ErrFaultRefused = errors.New("fault injection refused")
+
+// SafetyPolicy restricts fault injection to the resources of a campaign, so that
+// a misconfigured campaign cannot chaos-test production PubSub
+type SafetyPolicy struct {
+	// EmulatorHosts are the hosts faults may be injected on, matched against
+	// PUBSUB_EMULATOR_HOST. Default: localhost, 127.0.0.1 and ::1.
+	EmulatorHosts []string
+	// RequireRunID only allows faults on a topic labelled with the run ID of the client
+	RequireRunID bool
+}
+
+// DefaultSafetyPolicy only allows faults on a local emulator
+var DefaultSafetyPolicy = SafetyPolicy{
+	EmulatorHosts: []string{"localhost", "127.0.0.1", "::1"},
+}
+
+// allowFault returns an error wrapping ErrFaultRefused unless the safety policy
+// allows injecting the fault on the resources of the client. The resources are
+// checked once, the outcome is reused for later faults.
+func (c *PubSubClient) allowFault(fault string) error {
+	c.safetyOnce.Do(func() {
+		c.safetyErr = c.checkSafety()
+	})
+	if c.safetyErr != nil {
+		return fmt.Errorf("%w: %s: %v", ErrFaultRefused, fault, c.safetyErr)
+	}
+	return nil
+}
+
+func (c *PubSubClient) checkSafety() error {
+	addr := os.Getenv("PUBSUB_EMULATOR_HOST")
+	if addr == "" {
+		return fmt.Errorf("not connected to an emulator")
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	hosts := c.safety.EmulatorHosts
+	if len(hosts) == 0 {
+		hosts = DefaultSafetyPolicy.EmulatorHosts
+	}
+	allowed := false
+	for _, h := range hosts {
+		if h == host {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("emulator host %s is not allowed", host)
+	}
+
+	if c.safety.RequireRunID {
+		if c.runID == "" {
+			return fmt.Errorf("no run ID configured")
+		}
+		cfg, err := c.topic.Config(c.ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get topic config: %v", err)
+		}
+		if label := cfg.Labels[RunIDLabel]; label != c.runID {
+			return fmt.Errorf("topic is labelled with run ID %q, expected %q", label, c.runID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAllowFault(t *testing.T) {
+	tests := []struct {
+		host    string
+		policy  SafetyPolicy
+		allowed bool
+	}{
+		{"localhost:8085", DefaultSafetyPolicy, true},
+		{"127.0.0.1:8085", SafetyPolicy{}, true},
+		{"[::1]:8085", DefaultSafetyPolicy, true},
+		{"", DefaultSafetyPolicy, false},
+		{"pubsub.googleapis.com:443", DefaultSafetyPolicy, false},
+		{"emulator:8085", SafetyPolicy{EmulatorHosts: []string{"emulator"}}, true},
+		{"localhost:8085", SafetyPolicy{RequireRunID: true}, false},
+	}
+	for _, test := range tests {
+		t.Setenv("PUBSUB_EMULATOR_HOST", test.host)
+		c := &PubSubClient{safety: test.policy}
+		err := c.allowFault("corruption")
+		if test.allowed && err != nil {
+			t.Errorf("Fault on %q refused: %v", test.host, err)
+		}
+		if !test.allowed && !errors.Is(err, ErrFaultRefused) {
+			t.Errorf("Fault on %q not refused, got %v", test.host, err)
+		}
+	}
+}
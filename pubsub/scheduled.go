@@ -0,0 +1,78 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ScheduledPublish is the handle of a message published with PublishAt
+type ScheduledPublish struct {
+	// PublishAt is when the message is handed to the topic
+	PublishAt time.Time
+
+	timer *time.Timer
+	done  chan struct{}
+	once  sync.Once
+	id    string
+	err   error
+}
+
+func (s *ScheduledPublish) complete(id string, err error) {
+	s.once.Do(func() {
+		s.id, s.err = id, err
+		close(s.done)
+	})
+}
+
+// Done is closed once the message is published, failed to publish or was cancelled
+func (s *ScheduledPublish) Done() <-chan struct{} {
+	return s.done
+}
+
+// Get waits for the publish and returns the server ID of the message
+func (s *ScheduledPublish) Get(ctx context.Context) (string, error) {
+	select {
+	case <-s.done:
+		return s.id, s.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Cancel stops a publish that has not started yet. It reports whether the
+// publish was stopped.
+func (s *ScheduledPublish) Cancel() bool {
+	if s.timer == nil || !s.timer.Stop() {
+		return false
+	}
+	s.complete("", fmt.Errorf("scheduled publish cancelled"))
+	return true
+}
+
+// PublishAt publishes a message at the given time, or right away if it is in the
+// past, so schedules with absolute delivery times can be injected without timers
+// in the caller. The message is published with PublishMessage; its payload is
+// validated when it is scheduled.
+func (c *PubSubClient) PublishAt(data []byte, attributes map[string]string, publishAt time.Time) *ScheduledPublish {
+	s := &ScheduledPublish{PublishAt: publishAt, done: make(chan struct{})}
+	if err := c.validatePayload(data); err != nil {
+		s.complete("", err)
+		return s
+	}
+	attributes = copyAttributes(attributes)
+	s.timer = time.AfterFunc(time.Until(publishAt), func() {
+		if c.ctx.Err() != nil {
+			s.complete("", fmt.Errorf("failed to publish message: client closed"))
+			return
+		}
+		s.complete(c.PublishMessage(data, attributes, 0))
+	})
+	return s
+}
+
+// PublishAfter publishes a message once delay has elapsed, see PublishAt
+func (c *PubSubClient) PublishAfter(data []byte, attributes map[string]string, delay time.Duration) *ScheduledPublish {
+	return c.PublishAt(data, attributes, time.Now().Add(delay))
+}
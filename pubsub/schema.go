@@ -0,0 +1,130 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SchemaType is the language of a topic schema
+type SchemaType int
+
+const (
+	// SchemaProtobuf is a Protocol Buffer schema
+	SchemaProtobuf SchemaType = iota
+	// SchemaAvro is an Avro schema
+	SchemaAvro
+)
+
+// SchemaEncoding is the encoding of the messages of a topic with a schema
+type SchemaEncoding int
+
+const (
+	// SchemaEncodingJSON encodes messages as JSON
+	SchemaEncodingJSON SchemaEncoding = iota
+	// SchemaEncodingBinary encodes messages in the binary format of the schema
+	SchemaEncodingBinary
+)
+
+// SchemaConfig attaches a schema to the topic of the client
+type SchemaConfig struct {
+	// ID of the schema, created from Definition if it does not exist
+	ID         string
+	Type       SchemaType
+	Definition string
+	Encoding   SchemaEncoding
+
+	// Validate checks payloads against the schema before publishing, so malformed
+	// messages fail at the client. Each check is a call to the schema service.
+	Validate bool
+}
+
+// ErrSchemaValidation is returned when a payload does not match the topic schema
+var ErrSchemaValidation = errors.New("message does not match the topic schema")
+
+// topicSchema is the schema attached to the topic of a client
+type topicSchema struct {
+	client   *pubsub.SchemaClient
+	config   pubsub.SchemaConfig
+	encoding pubsub.SchemaEncoding
+	validate bool
+}
+
+// attachSchema gets or creates the schema and attaches it to topic. A topic with
+// another schema attached is an error.
+func attachSchema(ctx context.Context, projectID string, opts []option.ClientOption, topic *pubsub.Topic, cfg *SchemaConfig) (*topicSchema, error) {
+	client, err := pubsub.NewSchemaClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema client: %v", err)
+	}
+	schema, err := client.Schema(ctx, cfg.ID, pubsub.SchemaViewFull)
+	if err != nil {
+		schemaType := pubsub.SchemaProtocolBuffer
+		if cfg.Type == SchemaAvro {
+			schemaType = pubsub.SchemaAvro
+		}
+		schema, err = client.CreateSchema(ctx, cfg.ID, pubsub.SchemaConfig{
+			Type:       schemaType,
+			Definition: cfg.Definition,
+		})
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to create schema: %v", err)
+		}
+	}
+
+	encoding := pubsub.EncodingJSON
+	if cfg.Encoding == SchemaEncodingBinary {
+		encoding = pubsub.EncodingBinary
+	}
+	topicCfg, err := topic.Config(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get topic config: %v", err)
+	}
+	if settings := topicCfg.SchemaSettings; settings != nil {
+		if settings.Schema != schema.Name || settings.Encoding != encoding {
+			client.Close()
+			return nil, fmt.Errorf("topic already has schema %s attached", settings.Schema)
+		}
+	} else {
+		_, err = topic.Update(ctx, pubsub.TopicConfigToUpdate{
+			SchemaSettings: &pubsub.SchemaSettings{Schema: schema.Name, Encoding: encoding},
+		})
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to attach schema: %v", err)
+		}
+	}
+
+	return &topicSchema{
+		client:   client,
+		config:   *schema,
+		encoding: encoding,
+		validate: cfg.Validate,
+	}, nil
+}
+
+// validatePayload checks data against the topic schema when validation is enabled
+func (c *PubSubClient) validatePayload(data []byte) error {
+	if c.schema == nil || !c.schema.validate {
+		return nil
+	}
+	_, err := c.schema.client.ValidateMessageWithConfig(c.ctx, data, c.schema.encoding, c.schema.config)
+	return validationError(err)
+}
+
+// validationError maps the rejection of a payload to ErrSchemaValidation. Other
+// errors, e.g. of an unreachable server, are returned unchanged, so that they are
+// not mistaken for invalid payloads.
+func validationError(err error) error {
+	if err != nil && status.Code(err) == codes.InvalidArgument {
+		return fmt.Errorf("%w: %v", ErrSchemaValidation, err)
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidatePayloadWithoutSchema(t *testing.T) {
+	c := &PubSubClient{}
+	if err := c.validatePayload([]byte("anything")); err != nil {
+		t.Errorf("Unexpected validation error without schema: %v", err)
+	}
+	c.schema = &topicSchema{validate: false}
+	if err := c.validatePayload([]byte("anything")); err != nil {
+		t.Errorf("Unexpected validation error with validation disabled: %v", err)
+	}
+}
+
+func TestFailedPublishResult(t *testing.T) {
+	r := &PublishResult{err: ErrSchemaValidation}
+	select {
+	case <-r.Ready():
+	default:
+		t.Fatal("Failed result not ready")
+	}
+	if _, err := r.Get(context.Background()); !errors.Is(err, ErrSchemaValidation) {
+		t.Errorf("Expected the validation error, got %v", err)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	if err := validationError(nil); err != nil {
+		t.Errorf("Unexpected error for a valid payload: %v", err)
+	}
+	if err := validationError(status.Error(codes.InvalidArgument, "bad payload")); !errors.Is(err, ErrSchemaValidation) {
+		t.Errorf("Expected a rejected payload to fail validation, got %v", err)
+	}
+	for _, code := range []codes.Code{codes.Unavailable, codes.DeadlineExceeded} {
+		err := status.Error(code, "outage")
+		if got := validationError(err); errors.Is(got, ErrSchemaValidation) || got != err {
+			t.Errorf("Expected the %v error to be returned unchanged, got %v", code, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SeekToTime rewinds the subscription to t, so that the traffic of a fuzz
+// iteration can be replayed: messages published after t are delivered again and
+// those published before are acked. Acked messages are only redelivered when
+// the subscription retains them, see RetainAckedMessages.
+func (c *PubSubClient) SeekToTime(ctx context.Context, t time.Time) error {
+	if c.dryRun != nil {
+		return fmt.Errorf("seeking is not supported in dry-run mode")
+	}
+	if err := c.subscription.SeekToTime(ctx, t); err != nil {
+		return fmt.Errorf("failed to seek to %v: %v", t, err)
+	}
+	c.resetReceived()
+	return nil
+}
+
+// resetReceived drops what the client received before a seek: the messages
+// received but not returned yet are nacked, partial chunked payloads are
+// dropped and received dedup IDs are forgotten, so that replayed messages are
+// not mistaken for duplicates
+func (c *PubSubClient) resetReceived() {
+	c.bufferMutex.Lock()
+	c.messageBuffer = nil
+	c.bufferMutex.Unlock()
+
+	if msg := c.popPeeked(); msg != nil {
+		c.acknowledge(msg, false)
+	}
+
+	c.pullMutex.Lock()
+	pulled := c.pulled
+	c.pulled = nil
+	c.pullMutex.Unlock()
+	for _, msg := range pulled {
+		c.acknowledge(msg, false)
+	}
+	for drained := false; !drained; {
+		select {
+		case msg, ok := <-c.messageChan:
+			if !ok {
+				drained = true
+			} else if msg != nil {
+				c.acknowledge(msg, false)
+			}
+		default:
+			drained = true
+		}
+	}
+
+	c.chunks.reset()
+	if c.received != nil {
+		c.received.reset()
+	}
+}
+
+// CreateSnapshot captures the ack state of the subscription in a snapshot, so it
+// can be restored with SeekToSnapshot after an experiment
+func (c *PubSubClient) CreateSnapshot(ctx context.Context, name string) error {
+	if c.dryRun != nil {
+		return fmt.Errorf("snapshots are not supported in dry-run mode")
+	}
+	if _, err := c.subscription.CreateSnapshot(ctx, name); err != nil {
+		return fmt.Errorf("failed to create snapshot %s: %v", name, err)
+	}
+	return nil
+}
+
+// SeekToSnapshot restores the ack state of the subscription captured in the
+// snapshot: messages unacked when it was created are delivered again. Like with
+// SeekToTime, what the client received before is dropped.
+func (c *PubSubClient) SeekToSnapshot(ctx context.Context, name string) error {
+	if c.dryRun != nil {
+		return fmt.Errorf("seeking is not supported in dry-run mode")
+	}
+	if err := c.subscription.SeekToSnapshot(ctx, c.client.Snapshot(name)); err != nil {
+		return fmt.Errorf("failed to seek to snapshot %s: %v", name, err)
+	}
+	c.resetReceived()
+	return nil
+}
+
+// DeleteSnapshot deletes the snapshot
+func (c *PubSubClient) DeleteSnapshot(ctx context.Context, name string) error {
+	if c.dryRun != nil {
+		return fmt.Errorf("snapshots are not supported in dry-run mode")
+	}
+	if err := c.client.Snapshot(name).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete snapshot %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// defaultHandlerConcurrency bounds the Subscribe handlers running at once
+const defaultHandlerConcurrency = 10
+
+// ReceivedMessage is a received message, settled by the receiver with Ack, Nack
+// and ModifyAckDeadline when the client does not settle it, see AckModeManual
+type ReceivedMessage struct {
+	*pubsub.Message
+	client *PubSubClient
+	// auto is set when the client settles the message according to the AckMode
+	auto     bool
+	received time.Time
+	mutex    sync.Mutex
+	settled  bool
+	lease    *time.Timer
+}
+
+// Subscribe runs the streaming receive of the subscription and dispatches its
+// messages to handler until ctx is done or the client is closed, which returns
+// nil. At most HandlerConcurrency handlers run at once. The context of a handler
+// ends before the ack deadline of its message, see MessageContext. A message is
+// settled once its handler returns: nacked on error, otherwise acked or nacked
+// according to the AckMode. With AckModeManual, the handler settles the message
+// itself and an error only nacks it if it did not. Chunks of large payloads are
+// acked as they arrive and the reassembled payload is dispatched, so its
+// redelivery cannot be requested. The messages of an ordering key are handled
+// one at a time in the order they were received; when a handler fails, the
+// waiting messages of its key are nacked too, so that they are redelivered after
+// the failed one. ReceiveMessage fails while Subscribe runs, and Subscribe fails
+// once ReceiveMessage started receiving.
+func (c *PubSubClient) Subscribe(ctx context.Context, handler func(context.Context, *ReceivedMessage) error) error {
+	c.receiverMutex.Lock()
+	if c.receiverStarted || c.subscribed {
+		c.receiverMutex.Unlock()
+		return fmt.Errorf("subscription is already being received")
+	}
+	c.subscribed = true
+	c.receiverMutex.Unlock()
+	defer func() {
+		c.receiverMutex.Lock()
+		c.subscribed = false
+		c.receiverMutex.Unlock()
+	}()
+
+	ctx, cancel := c.clientContext(ctx)
+	defer cancel()
+	c.subscription.ReceiveSettings.MaxExtension = c.maxExtension()
+	c.subscription.ReceiveSettings.MaxOutstandingMessages = c.handlerConcurrency
+	ordered := newOrderedDispatcher()
+	err := c.subscription.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		if c.interceptProbe(msg) {
+			return
+		}
+		c.deliveries.recordDelivery(msg, time.Now())
+		if IsChunk(msg) {
+			full, complete, err := c.chunks.add(msg)
+			c.acknowledge(msg, err == nil)
+			if !complete {
+				return
+			}
+			msg = full
+		}
+		if err := c.verify(msg); err != nil {
+			c.acknowledge(msg, false)
+			return
+		}
+		if c.duplicate(msg) {
+			c.acknowledge(msg, true)
+			return
+		}
+
+		ordered.dispatch(msg.OrderingKey, func() error {
+			hctx, hcancel := c.MessageContext(ctx, msg)
+			defer hcancel()
+			received := c.wrapReceived(msg)
+			err := handler(hctx, received)
+			if !received.auto {
+				if err != nil {
+					received.Nack()
+				}
+				return err
+			}
+			if err != nil {
+				c.acknowledge(msg, false)
+				return err
+			}
+			c.settle(msg)
+			return nil
+		}, func() {
+			c.acknowledge(msg, false)
+		})
+	})
+	if err != nil && err != context.Canceled {
+		return fmt.Errorf("failed to receive messages: %v", err)
+	}
+	return nil
+}
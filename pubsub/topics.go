@@ -0,0 +1,136 @@
+package pubsub
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/iterator"
+)
+
+// PublishTo publishes a message to another topic over the connection of the
+// client. The topic is created on first use, with the run ID label and the
+// publish settings of the configured topic, and cached for later publishes. The
+// schema of the configured topic does not apply.
+func (c *PubSubClient) PublishTo(topicID string, data []byte, attributes map[string]string, timeout time.Duration) (string, error) {
+	if topicID == c.topic.ID() {
+		return c.PublishMessage(data, attributes, timeout)
+	}
+	topic, err := c.topicFor(topicID)
+	if err != nil {
+		return "", err
+	}
+	if c.checksums {
+		attributes = stampChecksum(data, attributes)
+	}
+	ctx, cancel := c.publishContext(timeout)
+	defer cancel()
+	return c.publishOn(ctx, topic, data, attributes, "")
+}
+
+// topicFor returns the cached topic with the given ID, creating it if needed
+func (c *PubSubClient) topicFor(topicID string) (*pubsub.Topic, error) {
+	c.topicsMutex.Lock()
+	defer c.topicsMutex.Unlock()
+	if topic, ok := c.topics[topicID]; ok {
+		return topic, nil
+	}
+
+	topic := c.client.Topic(topicID)
+	if c.dryRun == nil {
+		exists, err := topic.Exists(c.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check topic existence: %v", err)
+		}
+		if !exists {
+			topicCfg := &pubsub.TopicConfig{}
+			if c.runID != "" {
+				topicCfg.Labels = map[string]string{RunIDLabel: c.runID}
+			}
+			topic, err = c.client.CreateTopicWithConfig(c.ctx, topicID, topicCfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create topic %s: %v", topicID, err)
+			}
+		}
+	}
+	topic.PublishSettings = c.topic.PublishSettings
+	topic.EnableMessageOrdering = c.topic.EnableMessageOrdering
+	c.topics[topicID] = topic
+	return topic, nil
+}
+
+// stopTopics flushes and stops the cached topics
+func (c *PubSubClient) stopTopics() {
+	c.topicsMutex.Lock()
+	defer c.topicsMutex.Unlock()
+	for _, topic := range c.topics {
+		topic.Stop()
+	}
+}
+
+// FanOutError reports the topics a fan-out publish failed on
+type FanOutError struct {
+	Errors map[string]error
+}
+
+func (e *FanOutError) Error() string {
+	topics := make([]string, 0, len(e.Errors))
+	for topicID := range e.Errors {
+		topics = append(topics, topicID)
+	}
+	sort.Strings(topics)
+	return fmt.Sprintf("failed to publish to %d topics, %s: %v", len(topics), topics[0], e.Errors[topics[0]])
+}
+
+// PublishFanOut publishes a message to every topic of the project whose ID
+// matches the glob pattern, e.g. node-*-inbox, as with PublishTo. It returns the
+// message IDs by topic; if some publishes failed, the error is a *FanOutError.
+func (c *PubSubClient) PublishFanOut(pattern string, data []byte, attributes map[string]string, timeout time.Duration) (map[string]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid topic pattern %s: %v", pattern, err)
+	}
+	matched := make([]string, 0)
+	it := c.client.Topics(c.ctx)
+	for {
+		topic, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list topics: %v", err)
+		}
+		if ok, _ := path.Match(pattern, topic.ID()); ok {
+			matched = append(matched, topic.ID())
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no topic matches %s", pattern)
+	}
+
+	ids := make(map[string]string, len(matched))
+	errs := make(map[string]error)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, topicID := range matched {
+		wg.Add(1)
+		go func(topicID string) {
+			defer wg.Done()
+			id, err := c.PublishTo(topicID, data, attributes, timeout)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs[topicID] = err
+				return
+			}
+			ids[topicID] = id
+		}(topicID)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return ids, &FanOutError{Errors: errs}
+	}
+	return ids, nil
+}
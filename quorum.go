@@ -0,0 +1,148 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/ds-testing-user/etcd-fuzzing/raft"
+)
+
+// Membership returns the voters of the cluster as seen by the running node with
+// the most recent view: the leader of the highest term, else the node with the
+// highest term
+func (r *RaftEnvironment) Membership() []uint64 {
+	var view *raft.Status
+	for id := range r.nodes {
+		s := r.curStates[id]
+		if view == nil || s.Term > view.Term || (s.Term == view.Term && s.RaftState == raft.StateLeader) {
+			view = &s
+		}
+	}
+	if view == nil {
+		return []uint64{}
+	}
+	voters := view.Config.Voters[0].Slice()
+	if len(voters) == 0 {
+		// Outgoing only, the node has not applied its initial configuration yet
+		voters = view.Config.Voters[1].Slice()
+	}
+	return voters
+}
+
+// Leader returns the running node that believes to be leader of the highest term
+func (r *RaftEnvironment) Leader() (uint64, bool) {
+	var leader uint64
+	var term uint64
+	for id := range r.nodes {
+		s := r.curStates[id]
+		if s.RaftState == raft.StateLeader && s.Term >= term {
+			leader, term = id, s.Term
+		}
+	}
+	return leader, leader != 0
+}
+
+// quorumLossPlan returns the voters to crash so that fewer than a majority of them
+// is up. Down voters count as lost already. The leader goes first, the others by
+// ID, so the plan is deterministic and disrupts the cluster the most.
+func quorumLossPlan(voters []uint64, down map[uint64]bool, leader uint64) []uint64 {
+	up := make([]uint64, 0, len(voters))
+	for _, v := range voters {
+		if !down[v] {
+			up = append(up, v)
+		}
+	}
+	sort.Slice(up, func(i, j int) bool {
+		if up[i] == leader || up[j] == leader {
+			return up[i] == leader
+		}
+		return up[i] < up[j]
+	})
+	majority := len(voters)/2 + 1
+	if len(up) < majority {
+		return []uint64{}
+	}
+	return up[:len(up)-majority+1]
+}
+
+// IsQuorumLoss returns the duration of the quorum loss starting at step, if any
+func (t *traceCtx) IsQuorumLoss(step int) (int, bool) {
+	duration, ok := t.quorumLosses[step]
+	if ok {
+		t.trace.Append(&SchedulingChoice{
+			Type:          LoseQuorum,
+			Step:          step,
+			IntegerChoice: duration,
+		})
+	}
+	return duration, ok
+}
+
+// loseQuorum crashes the voters needed to break the quorum of the current
+// membership and schedules their restart after duration steps. Exactly what was
+// done is published as a FaultInjected event.
+func (f *Fuzzer) loseQuorum(fCtx *FuzzContext, iteration string, step, duration int, crashed map[uint64]bool) {
+	tCtx := fCtx.traceCtx
+	voters := f.raftEnvironment.Membership()
+	leader, _ := f.raftEnvironment.Leader()
+	plan := quorumLossPlan(voters, crashed, leader)
+	restoreAt := step + duration
+	for _, node := range plan {
+		tCtx.eventTrace.Append(&Event{
+			Name: "Remove",
+			Node: node,
+			Params: map[string]interface{}{
+				"i": int(node),
+			},
+		})
+		f.raftEnvironment.Stop(fCtx, node)
+		crashed[node] = true
+		tCtx.restores[restoreAt] = append(tCtx.restores[restoreAt], node)
+	}
+	f.bus.Publish(&CampaignEvent{
+		Type:      FaultInjected,
+		Iteration: iteration,
+		Step:      step,
+		Params: map[string]interface{}{
+			"fault":    "lose-quorum",
+			"voters":   voters,
+			"crashed":  plan,
+			"restored": restoreAt,
+		},
+	})
+}
+
+// restoreQuorum restarts the voters whose quorum loss ends at step
+func (f *Fuzzer) restoreQuorum(fCtx *FuzzContext, iteration string, step int, crashed map[uint64]bool) {
+	tCtx := fCtx.traceCtx
+	nodes, ok := tCtx.restores[step]
+	if !ok {
+		return
+	}
+	delete(tCtx.restores, step)
+	restarted := make([]uint64, 0, len(nodes))
+	for _, node := range nodes {
+		if !crashed[node] {
+			// Restarted by the schedule in the meantime
+			continue
+		}
+		tCtx.eventTrace.Append(&Event{
+			Name: "Add",
+			Node: node,
+			Params: map[string]interface{}{
+				"i": int(node),
+			},
+		})
+		f.raftEnvironment.Start(fCtx, node)
+		delete(crashed, node)
+		restarted = append(restarted, node)
+	}
+	f.bus.Publish(&CampaignEvent{
+		Type:      FaultInjected,
+		Iteration: iteration,
+		Step:      step,
+		Params: map[string]interface{}{
+			"fault":     "restore-quorum",
+			"restarted": restarted,
+		},
+	})
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"io"
 	"log"
-	"strconv"
 
 	"github.com/ds-testing-user/etcd-fuzzing/raft"
 	pb "github.com/ds-testing-user/etcd-fuzzing/raft/raftpb"
@@ -112,7 +111,7 @@ func (r *RaftEnvironment) Step(ctx *FuzzContext, m pb.Message) {
 		}
 		if haveLeader {
 			m.To = leader
-			request, _ := strconv.Atoi(string(m.Entries[0].Data))
+			request, _ := decodeRequest(m.Entries[0].Data)
 			ctx.AddEvent(&Event{
 				Name: "ClientRequest",
 				Node: leader,
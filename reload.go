@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ConfigUpdate changes campaign parameters at runtime. Nil fields are left unchanged.
+type ConfigUpdate struct {
+	MutPerTrace     *int  `json:",omitempty"`
+	CrashQuota      *int  `json:",omitempty"`
+	NumberRequests  *int  `json:",omitempty"`
+	MaxMessages     *int  `json:",omitempty"`
+	ReseedFrequency *int  `json:",omitempty"`
+	CostAware       *bool `json:",omitempty"`
+}
+
+func (u ConfigUpdate) validate() error {
+	if u.MutPerTrace != nil && *u.MutPerTrace < 0 {
+		return fmt.Errorf("MutPerTrace must not be negative")
+	}
+	if u.CrashQuota != nil && *u.CrashQuota < 0 {
+		return fmt.Errorf("CrashQuota must not be negative")
+	}
+	if u.NumberRequests != nil && *u.NumberRequests < 0 {
+		return fmt.Errorf("NumberRequests must not be negative")
+	}
+	if u.MaxMessages != nil && *u.MaxMessages <= 0 {
+		return fmt.Errorf("MaxMessages must be positive")
+	}
+	if u.ReseedFrequency != nil && *u.ReseedFrequency <= 0 {
+		return fmt.Errorf("ReseedFrequency must be positive")
+	}
+	return nil
+}
+
+// validate checks the budget of the campaign and its runtime tunable parameters
+func (c *FuzzerConfig) validate() error {
+	if c.Iterations <= 0 {
+		return fmt.Errorf("Iterations must be positive")
+	}
+	if c.Steps <= 0 {
+		return fmt.Errorf("Steps must be positive")
+	}
+	if c.RaftEnvironmentConfig.Replicas <= 0 {
+		return fmt.Errorf("Replicas must be positive")
+	}
+	return c.tunables().validate()
+}
+
+// merge overrides the fields of u set in other
+func (u ConfigUpdate) merge(other ConfigUpdate) ConfigUpdate {
+	if other.MutPerTrace != nil {
+		u.MutPerTrace = other.MutPerTrace
+	}
+	if other.CrashQuota != nil {
+		u.CrashQuota = other.CrashQuota
+	}
+	if other.NumberRequests != nil {
+		u.NumberRequests = other.NumberRequests
+	}
+	if other.MaxMessages != nil {
+		u.MaxMessages = other.MaxMessages
+	}
+	if other.ReseedFrequency != nil {
+		u.ReseedFrequency = other.ReseedFrequency
+	}
+	if other.CostAware != nil {
+		u.CostAware = other.CostAware
+	}
+	return u
+}
+
+// reconfigurations holds the update waiting for the next iteration
+type reconfigurations struct {
+	lock    *sync.Mutex
+	pending *ConfigUpdate
+}
+
+func newReconfigurations() *reconfigurations {
+	return &reconfigurations{lock: new(sync.Mutex)}
+}
+
+func (r *reconfigurations) add(u ConfigUpdate) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.pending == nil {
+		r.pending = &ConfigUpdate{}
+	}
+	merged := r.pending.merge(u)
+	r.pending = &merged
+}
+
+func (r *reconfigurations) take() *ConfigUpdate {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	u := r.pending
+	r.pending = nil
+	return u
+}
+
+// Reconfigure tunes a running campaign without restarting it. The update takes
+// effect at the start of the next iteration, where ConfigChanged is published
+// with the old and new values of the changed parameters.
+func (f *Fuzzer) Reconfigure(u ConfigUpdate) error {
+	if err := u.validate(); err != nil {
+		return fmt.Errorf("invalid config update: %s", err)
+	}
+	f.reconfigurations.add(u)
+	return nil
+}
+
+// applyReconfigure applies the pending update, if any, before iteration runs
+func (f *Fuzzer) applyReconfigure(iteration string) {
+	u := f.reconfigurations.take()
+	if u == nil {
+		return
+	}
+	changes := make(map[string]interface{})
+	setInt := func(name string, field *int, value *int) {
+		if value != nil && *field != *value {
+			changes[name] = map[string]int{"old": *field, "new": *value}
+			*field = *value
+		}
+	}
+	setInt("MutPerTrace", &f.config.MutPerTrace, u.MutPerTrace)
+	setInt("CrashQuota", &f.config.CrashQuota, u.CrashQuota)
+	setInt("NumberRequests", &f.config.NumberRequests, u.NumberRequests)
+	setInt("MaxMessages", &f.config.MaxMessages, u.MaxMessages)
+	setInt("ReseedFrequency", &f.config.ReseedFrequency, u.ReseedFrequency)
+	if u.CostAware != nil && f.config.CostAware != *u.CostAware {
+		changes["CostAware"] = map[string]bool{"old": f.config.CostAware, "new": *u.CostAware}
+		f.config.CostAware = *u.CostAware
+	}
+	if len(changes) == 0 {
+		return
+	}
+	f.bus.Publish(&CampaignEvent{
+		Type:      ConfigChanged,
+		Iteration: iteration,
+		Params: map[string]interface{}{
+			"changes": changes,
+		},
+	})
+}
+
+func (f *Fuzzer) recordConfigChanges(e *CampaignEvent) {
+	log, _ := f.stats["config_changes"].([]map[string]interface{})
+	f.stats["config_changes"] = append(log, map[string]interface{}{
+		"iteration": e.Iteration,
+		"changes":   e.Params["changes"],
+	})
+}
+
+// currentConfig returns a copy of the runtime tunable parameters of the campaign
+func (f *Fuzzer) currentConfig() ConfigUpdate {
+	return f.config.tunables()
+}
+
+// tunables returns a copy of the runtime tunable parameters of the config
+func (c *FuzzerConfig) tunables() ConfigUpdate {
+	cfg := *c
+	return ConfigUpdate{
+		MutPerTrace:     &cfg.MutPerTrace,
+		CrashQuota:      &cfg.CrashQuota,
+		NumberRequests:  &cfg.NumberRequests,
+		MaxMessages:     &cfg.MaxMessages,
+		ReseedFrequency: &cfg.ReseedFrequency,
+		CostAware:       &cfg.CostAware,
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Bundle is everything needed to reproduce an execution: the environment the
+// schedule ran against and the schedule itself
+type Bundle struct {
+	// FormatVersion is the bundleFormatVersion the bundle was written with
+	FormatVersion         int
+	Iteration             string
+	Steps                 int
+	RaftEnvironmentConfig RaftEnvironmentConfig
+	Network               NetworkConfig
+	Schedule              *List[*SchedulingChoice]
+	// Events is the event trace observed when the bundle was recorded
+	Events *List[*Event] `json:",omitempty"`
+	// States is the abstract state after every step when the bundle was recorded
+	States []string `json:",omitempty"`
+	// Triggers are the rules of the triggers active when the bundle was recorded,
+	// re-applied when it is replayed, see ParseTrigger
+	Triggers []string `json:",omitempty"`
+}
+
+func SaveBundle(filePath string, bundle *Bundle) error {
+	bundle.FormatVersion = bundleFormatVersion
+	data, err := json.MarshalIndent(bundle, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error marshalling bundle: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return fmt.Errorf("error writing bundle: %s", err)
+	}
+	return nil
+}
+
+func LoadBundle(filePath string) (*Bundle, error) {
+	r, err := openArtifact(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle: %s", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle: %s", err)
+	}
+	data, err = migrateBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("error migrating bundle %s: %s", filePath, err)
+	}
+	bundle := &Bundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("error parsing bundle: %s", err)
+	}
+	if bundle.Schedule == nil {
+		return nil, fmt.Errorf("bundle %s has no schedule", filePath)
+	}
+	for _, rule := range bundle.Triggers {
+		if _, err := ParseTrigger(rule); err != nil {
+			return nil, fmt.Errorf("bundle %s has an invalid trigger: %s", filePath, err)
+		}
+	}
+	return bundle, nil
+}
+
+// fuzzerConfig returns the config of a fuzzer re-executing the schedule of the
+// bundle in the environment it was recorded in, with its triggers
+func (b *Bundle) fuzzerConfig(checker Checker) *FuzzerConfig {
+	triggers := make([]Trigger, 0, len(b.Triggers))
+	for _, rule := range b.Triggers {
+		// Rules are validated when loading the bundle
+		if trigger, err := ParseTrigger(rule); err == nil {
+			triggers = append(triggers, trigger)
+		}
+	}
+	return &FuzzerConfig{
+		Iterations:            1,
+		Steps:                 b.Steps,
+		Checker:               checker,
+		RaftEnvironmentConfig: b.RaftEnvironmentConfig,
+		Network:               b.Network,
+		Triggers:              triggers,
+	}
+}
+
+// Replay runs the schedule of the bundle and returns true if the checker holds
+// at the end of the execution. Divergences from the recorded events are
+// tolerated, see ReplayWithOptions.
+func Replay(bundle *Bundle, checker Checker) bool {
+	return ReplayWithOptions(bundle, checker, ReplayOptions{BestEffort: true}).Holds
+}
+
+type ReplayOptions struct {
+	// BestEffort continues the replay past a divergence instead of stopping at it
+	BestEffort bool
+}
+
+// Divergence is the first point where a replay observed different events than
+// the recorded trace, hinting at nondeterminism in the system under test
+type Divergence struct {
+	Step     int
+	Index    int
+	Expected *Event
+	Observed *Event
+}
+
+func (d *Divergence) String() string {
+	expected, observed := "<none>", "<none>"
+	if d.Expected != nil {
+		expected = d.Expected.Name
+	}
+	if d.Observed != nil {
+		observed = d.Observed.Name
+	}
+	return fmt.Sprintf("replay diverged at step %d, event %d: expected %s, observed %s", d.Step, d.Index, expected, observed)
+}
+
+type ReplayResult struct {
+	Holds bool
+	// Divergence is nil if the replay matched the recorded events
+	Divergence *Divergence
+}
+
+func ReplayWithOptions(bundle *Bundle, checker Checker, opts ReplayOptions) ReplayResult {
+	fuzzer := NewFuzzer(bundle.fuzzerConfig(checker))
+	if bundle.Events != nil {
+		fuzzer.replay = &replayState{
+			expected:   bundle.Events,
+			bestEffort: opts.BestEffort,
+		}
+	}
+	fuzzer.RunIteration("replay", bundle.Schedule)
+	result := ReplayResult{
+		Holds: checker == nil || checker(fuzzer.raftEnvironment),
+	}
+	if fuzzer.replay != nil {
+		result.Divergence = fuzzer.replay.divergence
+	}
+	return result
+}
+
+// replayState compares the events of a replay with the recorded ones
+type replayState struct {
+	expected   *List[*Event]
+	bestEffort bool
+	checked    int
+	divergence *Divergence
+}
+
+// check compares the events observed so far with the expected ones and returns
+// false if the replay should stop
+func (r *replayState) check(step int, observed *List[*Event]) bool {
+	if r.divergence != nil {
+		return r.bestEffort
+	}
+	for ; r.checked < observed.Size(); r.checked++ {
+		o, _ := observed.Get(r.checked)
+		e, ok := r.expected.Get(r.checked)
+		if !ok || !sameEvent(e, o) {
+			r.divergence = &Divergence{Step: step, Index: r.checked, Observed: o}
+			if ok {
+				r.divergence.Expected = e
+			}
+			return r.bestEffort
+		}
+	}
+	return true
+}
+
+// sameEvent compares events by their JSON encoding, as recorded events went
+// through a JSON round trip
+func sameEvent(a, b *Event) bool {
+	return bytes.Equal(normalizedJSON(a), normalizedJSON(b))
+}
+
+func normalizedJSON(e *Event) []byte {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	data, _ = json.Marshal(v)
+	return data
+}
+
+// saveViolations stores a bundle for every schedule violating the checker
+func (f *Fuzzer) saveViolations(e *CampaignEvent) {
+	if bundle, ok := f.bundleFromEvent(e); ok {
+		SaveBundle(artifactPath(path.Join(f.config.BundlePath, e.Iteration+".json"), f.config.Compress), bundle)
+	}
+}
+
+// bundleFromEvent builds a bundle from the schedule and events carried by e
+func (f *Fuzzer) bundleFromEvent(e *CampaignEvent) (*Bundle, bool) {
+	schedule, ok := e.Params["schedule"].(*List[*SchedulingChoice])
+	if !ok {
+		return nil, false
+	}
+	bundle := &Bundle{
+		Iteration:             e.Iteration,
+		Steps:                 f.config.Steps,
+		RaftEnvironmentConfig: f.config.RaftEnvironmentConfig,
+		Network:               f.config.Network,
+		Schedule:              schedule,
+	}
+	if events, ok := e.Params["events"].(*List[*Event]); ok {
+		bundle.Events = events
+	}
+	if states, ok := e.Params["states"].([]string); ok {
+		bundle.States = states
+	}
+	for _, trigger := range f.config.Triggers {
+		// Only triggers given as rules can be recorded
+		if _, err := ParseTrigger(trigger.Name); err == nil {
+			bundle.Triggers = append(bundle.Triggers, trigger.Name)
+		}
+	}
+	return bundle, true
+}
+
+type RegressionResult struct {
+	Bundle string
+	Passed bool
+	Error  string `json:",omitempty"`
+}
+
+// RunRegressions replays every bundle in dir. A bundle passes when the checker
+// holds at the end of its replay, i.e. the bug it reproduced stays fixed.
+func RunRegressions(dir string, checker Checker) ([]RegressionResult, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing bundles: %s", err)
+	}
+	compressed, _ := filepath.Glob(filepath.Join(dir, "*.json"+compressedExt))
+	files = append(files, compressed...)
+	sort.Strings(files)
+	results := make([]RegressionResult, 0, len(files))
+	for _, file := range files {
+		result := RegressionResult{Bundle: file}
+		bundle, err := LoadBundle(file)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Passed, result.Error = replaySafely(bundle, checker)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func replaySafely(bundle *Bundle, checker Checker) (passed bool, errMsg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			passed = false
+			errMsg = fmt.Sprintf("replay panicked: %v", r)
+		}
+	}()
+	if !Replay(bundle, checker) {
+		return false, "checker violated"
+	}
+	return true, ""
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	pb "github.com/ds-testing-user/etcd-fuzzing/raft/raftpb"
+)
+
+// delivered is a message delivered earlier in the iteration, kept as a candidate
+// for replay attacks
+type delivered struct {
+	message     pb.Message
+	sentAt      int
+	deliveredAt int
+}
+
+// IsReplay returns the message to re-inject at step, if the schedule replays one.
+// The index of the replayed message is resolved against the messages delivered
+// so far and recorded as such, so the schedule reproduces.
+func (t *traceCtx) IsReplay(step int) (*delivered, bool) {
+	idx, ok := t.replays[step]
+	if !ok || len(t.delivered) == 0 {
+		return nil, false
+	}
+	idx = idx % len(t.delivered)
+	t.trace.Append(&SchedulingChoice{
+		Type:          ReplayMessage,
+		Step:          step,
+		IntegerChoice: idx,
+	})
+	return t.delivered[idx], true
+}
+
+// replayAnnotations describes the replay of d at step, distinguishing it from a
+// broker redelivery
+func (d *delivered) replayAnnotations(step int) map[string]interface{} {
+	return map[string]interface{}{
+		"delay":    step - d.sentAt,
+		"decision": step,
+		"replayed": d.deliveredAt,
+	}
+}
+
+// ReplayMessageMutator changes which earlier message the replay attacks of a
+// schedule re-inject
+type ReplayMessageMutator struct {
+	NumChanges int
+	r          *rand.Rand
+}
+
+var _ Mutator = &ReplayMessageMutator{}
+
+func NewReplayMessageMutator(changes int) *ReplayMessageMutator {
+	return &ReplayMessageMutator{
+		NumChanges: changes,
+		r:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (m *ReplayMessageMutator) Mutate(trace *List[*SchedulingChoice], eventTrace *List[*Event]) (*List[*SchedulingChoice], bool) {
+	replays := make([]int, 0)
+	for i, ch := range trace.Iter() {
+		if ch.Type == ReplayMessage {
+			replays = append(replays, i)
+		}
+	}
+	if len(replays) == 0 {
+		return nil, false
+	}
+
+	deliveries := 0
+	for _, e := range eventTrace.Iter() {
+		if e.Name == "DeliverMessage" {
+			deliveries++
+		}
+	}
+	if deliveries == 0 {
+		return nil, false
+	}
+
+	newTrace := copyTrace(trace, defaultCopyFilter())
+	for _, i := range sample(replays, m.NumChanges, m.r) {
+		ch, _ := newTrace.Get(i)
+		chNew := ch.Copy()
+		chNew.IntegerChoice = m.r.Intn(deliveries)
+		newTrace.Set(i, chNew)
+	}
+	return newTrace, true
+}
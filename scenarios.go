@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ScenarioParams parameterizes a scenario template
+type ScenarioParams struct {
+	// Replicas is the number of raft nodes, numbered 1..Replicas
+	Replicas int
+	Steps    int
+	// Target is the node the scenario acts upon
+	Target uint64
+	// Start and Duration delimit the steps of the interesting phase
+	Start       int
+	Duration    int
+	MaxMessages int
+}
+
+func DefaultScenarioParams(replicas, steps int) ScenarioParams {
+	return ScenarioParams{
+		Replicas:    replicas,
+		Steps:       steps,
+		Target:      1,
+		Start:       steps / 3,
+		Duration:    steps / 3,
+		MaxMessages: 5,
+	}
+}
+
+// ScenarioTemplate builds a schedule reproducing a common distributed systems bug
+// pattern. Such schedules are used as seeds of a campaign, the fuzzer explores
+// around them through mutation.
+type ScenarioTemplate func(ScenarioParams) *List[*SchedulingChoice]
+
+var ScenarioTemplates = map[string]ScenarioTemplate{
+	"leader-isolation":           LeaderIsolationScenario,
+	"duplicate-vote":             DuplicateVoteScenario,
+	"stale-snapshot":             StaleSnapshotScenario,
+	"partitioned-minority-write": PartitionedMinorityWriteScenario,
+	"quorum-loss":                QuorumLossScenario,
+}
+
+func ScenarioNames() []string {
+	names := make([]string, 0, len(ScenarioTemplates))
+	for name := range ScenarioTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func InstantiateScenario(name string, params ScenarioParams) (*List[*SchedulingChoice], error) {
+	template, ok := ScenarioTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scenario %s, available: %v", name, ScenarioNames())
+	}
+	if params.Target < 1 || params.Target > uint64(params.Replicas) {
+		return nil, fmt.Errorf("scenario target %d is not a node", params.Target)
+	}
+	return template(params), nil
+}
+
+// LeaderIsolationScenario lets the cluster elect a leader, then isolates the
+// target right after it received a client request and sent out its appends
+func LeaderIsolationScenario(p ScenarioParams) *List[*SchedulingChoice] {
+	b := newScenarioBuilder(p)
+	b.deliver(0, p.Start, allLinks)
+	b.request(p.Start, 1)
+	b.deliver(p.Start, p.Start+1, func(from, to uint64) bool {
+		return from == p.Target
+	})
+	b.deliver(p.Start+1, p.Start+p.Duration, b.isolated(p.Target))
+	b.deliver(p.Start+p.Duration, p.Steps, allLinks)
+	return b.trace
+}
+
+// DuplicateVoteScenario restarts the target during an election so that it
+// campaigns and votes again with the messages of its previous incarnation still
+// in flight
+func DuplicateVoteScenario(p ScenarioParams) *List[*SchedulingChoice] {
+	b := newScenarioBuilder(p)
+	b.deliver(0, p.Start, allLinks)
+	b.crash(p.Start, p.Target)
+	b.restart(p.Start+1, p.Target)
+	b.deliver(p.Start, p.Start+p.Duration, func(from, to uint64) bool {
+		return from == p.Target || to == p.Target
+	})
+	b.deliver(p.Start+p.Duration, p.Steps, allLinks)
+	return b.trace
+}
+
+// StaleSnapshotScenario crashes the target while the cluster keeps committing
+// requests, so that on restart it has to catch up from a stale state
+func StaleSnapshotScenario(p ScenarioParams) *List[*SchedulingChoice] {
+	b := newScenarioBuilder(p)
+	b.deliver(0, p.Steps, allLinks)
+	b.crash(p.Start, p.Target)
+	for i := 1; i < p.Duration; i += 2 {
+		b.request(p.Start+i, i/2+1)
+	}
+	b.restart(p.Start+p.Duration, p.Target)
+	return b.trace
+}
+
+// PartitionedMinorityWriteScenario partitions the target away from the majority
+// while client requests keep arriving, then heals the partition
+func PartitionedMinorityWriteScenario(p ScenarioParams) *List[*SchedulingChoice] {
+	b := newScenarioBuilder(p)
+	b.deliver(0, p.Start, allLinks)
+	b.deliver(p.Start, p.Start+p.Duration, b.isolated(p.Target))
+	for i := 0; i < p.Duration; i += 4 {
+		b.request(p.Start+i, i/4+1)
+	}
+	b.deliver(p.Start+p.Duration, p.Steps, allLinks)
+	return b.trace
+}
+
+// QuorumLossScenario breaks the quorum of the cluster while client requests keep
+// arriving, then restores it
+func QuorumLossScenario(p ScenarioParams) *List[*SchedulingChoice] {
+	b := newScenarioBuilder(p)
+	b.deliver(0, p.Steps, allLinks)
+	b.loseQuorum(p.Start, p.Duration)
+	for i := 0; i < p.Duration; i += 4 {
+		b.request(p.Start+i, i/4+1)
+	}
+	return b.trace
+}
+
+type scenarioBuilder struct {
+	params ScenarioParams
+	trace  *List[*SchedulingChoice]
+}
+
+func newScenarioBuilder(p ScenarioParams) *scenarioBuilder {
+	return &scenarioBuilder{
+		params: p,
+		trace:  NewList[*SchedulingChoice](),
+	}
+}
+
+func allLinks(from, to uint64) bool {
+	return true
+}
+
+func (b *scenarioBuilder) isolated(node uint64) func(uint64, uint64) bool {
+	return func(from, to uint64) bool {
+		return from != node && to != node
+	}
+}
+
+// deliver schedules the steps [start, end) cycling over the links allowed by the filter
+func (b *scenarioBuilder) deliver(start, end int, allowed func(from, to uint64) bool) {
+	links := make([][2]uint64, 0)
+	for from := 1; from <= b.params.Replicas; from++ {
+		for to := 1; to <= b.params.Replicas; to++ {
+			if from != to && allowed(uint64(from), uint64(to)) {
+				links = append(links, [2]uint64{uint64(from), uint64(to)})
+			}
+		}
+	}
+	if len(links) == 0 {
+		return
+	}
+	for step := start; step < end && step < b.params.Steps; step++ {
+		link := links[step%len(links)]
+		b.trace.Append(&SchedulingChoice{
+			Type:        Node,
+			From:        link[0],
+			To:          link[1],
+			MaxMessages: b.params.MaxMessages,
+		})
+	}
+}
+
+func (b *scenarioBuilder) crash(step int, node uint64) {
+	b.trace.Append(&SchedulingChoice{
+		Type: StopNode,
+		Node: node,
+		Step: step,
+	})
+}
+
+func (b *scenarioBuilder) restart(step int, node uint64) {
+	b.trace.Append(&SchedulingChoice{
+		Type: StartNode,
+		Node: node,
+		Step: step,
+	})
+}
+
+// loseQuorum crashes the voters needed to break quorum at step, chosen from the
+// membership at that point, and restarts them after duration steps
+func (b *scenarioBuilder) loseQuorum(step, duration int) {
+	b.trace.Append(&SchedulingChoice{
+		Type:          LoseQuorum,
+		Step:          step,
+		IntegerChoice: duration,
+	})
+}
+
+func (b *scenarioBuilder) request(step int, request int) {
+	b.trace.Append(&SchedulingChoice{
+		Type:    ClientRequest,
+		Request: request,
+		Step:    step,
+	})
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ScheduleEditor edits the schedule of a bundle for manual hypothesis testing:
+// deliveries and faults can be reordered, deleted and added. Every edit is
+// validated against the environment of the bundle.
+type ScheduleEditor struct {
+	bundle  *Bundle
+	choices []*SchedulingChoice
+}
+
+func NewScheduleEditor(bundle *Bundle) *ScheduleEditor {
+	choices := make([]*SchedulingChoice, 0, bundle.Schedule.Size())
+	for _, ch := range bundle.Schedule.Iter() {
+		choices = append(choices, ch.Copy())
+	}
+	return &ScheduleEditor{bundle: bundle, choices: choices}
+}
+
+// Bundle returns the edited bundle. The recorded events are dropped, they do
+// not match the edited schedule.
+func (e *ScheduleEditor) Bundle() *Bundle {
+	schedule := NewList[*SchedulingChoice]()
+	for _, ch := range e.choices {
+		schedule.Append(ch.Copy())
+	}
+	bundle := *e.bundle
+	bundle.Schedule = schedule
+	bundle.Events = nil
+	return &bundle
+}
+
+func (e *ScheduleEditor) Delete(i int) error {
+	if i < 0 || i >= len(e.choices) {
+		return fmt.Errorf("no choice %d", i)
+	}
+	e.choices = append(e.choices[:i], e.choices[i+1:]...)
+	return nil
+}
+
+// Move moves the choice at i to position j
+func (e *ScheduleEditor) Move(i, j int) error {
+	if i < 0 || i >= len(e.choices) || j < 0 || j >= len(e.choices) {
+		return fmt.Errorf("positions must be between 0 and %d", len(e.choices)-1)
+	}
+	ch := e.choices[i]
+	e.choices = append(e.choices[:i], e.choices[i+1:]...)
+	e.choices = append(e.choices[:j], append([]*SchedulingChoice{ch}, e.choices[j:]...)...)
+	return nil
+}
+
+// Insert adds ch at position i, rejecting it if it does not fit the environment
+func (e *ScheduleEditor) Insert(i int, ch *SchedulingChoice) error {
+	if i < 0 || i > len(e.choices) {
+		return fmt.Errorf("position must be between 0 and %d", len(e.choices))
+	}
+	if err := e.validateChoice(ch); err != nil {
+		return err
+	}
+	e.choices = append(e.choices[:i], append([]*SchedulingChoice{ch}, e.choices[i:]...)...)
+	return nil
+}
+
+func (e *ScheduleEditor) validNode(node uint64) bool {
+	return node >= 1 && node <= uint64(e.bundle.RaftEnvironmentConfig.Replicas)
+}
+
+func (e *ScheduleEditor) validateChoice(ch *SchedulingChoice) error {
+	switch ch.Type {
+	case Node:
+		if !e.validNode(ch.From) || !e.validNode(ch.To) {
+			return fmt.Errorf("nodes must be between 1 and %d", e.bundle.RaftEnvironmentConfig.Replicas)
+		}
+		if ch.MaxMessages < 0 {
+			return fmt.Errorf("max messages must not be negative")
+		}
+		return nil
+	case StopNode, StartNode:
+		if !e.validNode(ch.Node) {
+			return fmt.Errorf("nodes must be between 1 and %d", e.bundle.RaftEnvironmentConfig.Replicas)
+		}
+	case LoseQuorum:
+		if ch.IntegerChoice <= 0 {
+			return fmt.Errorf("quorum loss duration must be positive")
+		}
+	case ClientRequest, ReplayMessage:
+	default:
+		return nil
+	}
+	if ch.Step < 0 || ch.Step >= e.bundle.Steps {
+		return fmt.Errorf("step must be between 0 and %d", e.bundle.Steps-1)
+	}
+	return nil
+}
+
+// Validate checks the whole schedule and returns the problems found. Besides
+// invalid choices, it reports node choices beyond the steps of the bundle, which
+// are never executed, and restarts of nodes that are not crashed, which have no
+// effect.
+func (e *ScheduleEditor) Validate() []string {
+	problems := make([]string, 0)
+	deliveries := 0
+	for i, ch := range e.choices {
+		if err := e.validateChoice(ch); err != nil {
+			problems = append(problems, fmt.Sprintf("%d: %s", i, err))
+		}
+		if ch.Type == Node {
+			deliveries++
+		}
+	}
+	if deliveries > e.bundle.Steps {
+		problems = append(problems, fmt.Sprintf("%d deliveries for %d steps, the last %d are not executed", deliveries, e.bundle.Steps, deliveries-e.bundle.Steps))
+	}
+	normalized := Normalize(&List[*SchedulingChoice]{l: e.choices})
+	restarts := 0
+	for _, ch := range e.choices {
+		if ch.Type == StartNode {
+			restarts++
+		}
+	}
+	for _, ch := range normalized.Iter() {
+		if ch.Type == StartNode {
+			restarts--
+		}
+	}
+	if restarts > 0 {
+		problems = append(problems, fmt.Sprintf("%d restarts of nodes that are not crashed have no effect", restarts))
+	}
+	return problems
+}
+
+// FormatChoice renders a choice in the syntax accepted by ParseChoice
+func FormatChoice(ch *SchedulingChoice) string {
+	switch ch.Type {
+	case Node:
+		return fmt.Sprintf("deliver %d->%d max %d", ch.From, ch.To, ch.MaxMessages)
+	case StopNode:
+		return fmt.Sprintf("crash %d@%d", ch.Node, ch.Step)
+	case StartNode:
+		return fmt.Sprintf("restart %d@%d", ch.Node, ch.Step)
+	case ClientRequest:
+		return fmt.Sprintf("request %d@%d", ch.Request, ch.Step)
+	case ReplayMessage:
+		return fmt.Sprintf("replay %d@%d", ch.IntegerChoice, ch.Step)
+	case LoseQuorum:
+		return fmt.Sprintf("lose-quorum %d@%d", ch.IntegerChoice, ch.Step)
+	case RandomBoolean:
+		return fmt.Sprintf("boolean %t", ch.BooleanChoice)
+	case RandomInteger:
+		return fmt.Sprintf("integer %d", ch.IntegerChoice)
+	}
+	return string(ch.Type)
+}
+
+// ParseChoice parses a choice such as "deliver 1->2 max 3", "crash 2@10",
+// "restart 2@20", "request 1@5", "replay 0@12" or "lose-quorum 5@8"
+func ParseChoice(s string) (*SchedulingChoice, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid choice %q", s)
+	}
+	if fields[0] == "deliver" {
+		if len(fields) != 4 || fields[2] != "max" {
+			return nil, fmt.Errorf("invalid delivery %q, expected \"deliver <from>-><to> max <messages>\"", s)
+		}
+		link := strings.SplitN(fields[1], "->", 2)
+		if len(link) != 2 {
+			return nil, fmt.Errorf("invalid link %q, expected <from>-><to>", fields[1])
+		}
+		from, err1 := strconv.ParseUint(link[0], 10, 64)
+		to, err2 := strconv.ParseUint(link[1], 10, 64)
+		max, err3 := strconv.Atoi(fields[3])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("invalid delivery %q", s)
+		}
+		return &SchedulingChoice{Type: Node, From: from, To: to, MaxMessages: max}, nil
+	}
+
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid choice %q", s)
+	}
+	at := strings.SplitN(fields[1], "@", 2)
+	if len(at) != 2 {
+		return nil, fmt.Errorf("invalid choice %q, expected <value>@<step>", s)
+	}
+	value, err := strconv.Atoi(at[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value in %q: %s", s, err)
+	}
+	step, err := strconv.Atoi(at[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid step in %q: %s", s, err)
+	}
+	switch fields[0] {
+	case "crash":
+		return &SchedulingChoice{Type: StopNode, Node: uint64(value), Step: step}, nil
+	case "restart":
+		return &SchedulingChoice{Type: StartNode, Node: uint64(value), Step: step}, nil
+	case "request":
+		return &SchedulingChoice{Type: ClientRequest, Request: value, Step: step}, nil
+	case "replay":
+		return &SchedulingChoice{Type: ReplayMessage, IntegerChoice: value, Step: step}, nil
+	case "lose-quorum":
+		return &SchedulingChoice{Type: LoseQuorum, IntegerChoice: value, Step: step}, nil
+	}
+	return nil, fmt.Errorf("unknown choice %s", fields[0])
+}
+
+const scheditHelp = `commands:
+  list                     show the schedule
+  del <i>                  delete choice i
+  move <i> <j>             move choice i to position j
+  add <i> <choice>         insert a choice at position i, one of
+                             deliver <from>-><to> max <messages>
+                             crash <node>@<step>, restart <node>@<step>
+                             request <n>@<step>, replay <index>@<step>
+                             lose-quorum <duration>@<step>
+  validate                 check the schedule
+  write                    save the schedule, refused if a choice is invalid
+  quit                     leave without saving
+`
+
+// Run reads editing commands from r until quit, reporting to w. save is called
+// with the edited bundle on write.
+func (e *ScheduleEditor) Run(r io.Reader, w io.Writer, save func(*Bundle) error) error {
+	scanner := bufio.NewScanner(r)
+	fmt.Fprint(w, "> ")
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 {
+			if fields[0] == "quit" {
+				return nil
+			}
+			if err := e.command(fields, w, save); err != nil {
+				fmt.Fprintf(w, "error: %s\n", err)
+			}
+		}
+		fmt.Fprint(w, "> ")
+	}
+	return scanner.Err()
+}
+
+func (e *ScheduleEditor) command(fields []string, w io.Writer, save func(*Bundle) error) error {
+	ints := func(args []string) ([]int, error) {
+		values := make([]int, len(args))
+		for i, a := range args {
+			v, err := strconv.Atoi(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid position %q", a)
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	switch fields[0] {
+	case "list":
+		for i, ch := range e.choices {
+			fmt.Fprintf(w, "%4d  %s\n", i, FormatChoice(ch))
+		}
+	case "del":
+		if len(fields) != 2 {
+			return fmt.Errorf("usage: del <i>")
+		}
+		pos, err := ints(fields[1:])
+		if err != nil {
+			return err
+		}
+		return e.Delete(pos[0])
+	case "move":
+		if len(fields) != 3 {
+			return fmt.Errorf("usage: move <i> <j>")
+		}
+		pos, err := ints(fields[1:])
+		if err != nil {
+			return err
+		}
+		return e.Move(pos[0], pos[1])
+	case "add":
+		if len(fields) < 3 {
+			return fmt.Errorf("usage: add <i> <choice>")
+		}
+		pos, err := ints(fields[1:2])
+		if err != nil {
+			return err
+		}
+		ch, err := ParseChoice(strings.Join(fields[2:], " "))
+		if err != nil {
+			return err
+		}
+		return e.Insert(pos[0], ch)
+	case "validate":
+		problems := e.Validate()
+		for _, p := range problems {
+			fmt.Fprintln(w, p)
+		}
+		if len(problems) == 0 {
+			fmt.Fprintln(w, "schedule is valid")
+		}
+	case "write":
+		for i, ch := range e.choices {
+			if err := e.validateChoice(ch); err != nil {
+				return fmt.Errorf("choice %d is invalid: %s", i, err)
+			}
+		}
+		if err := save(e.Bundle()); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "saved")
+	case "help":
+		fmt.Fprint(w, scheditHelp)
+	default:
+		return fmt.Errorf("unknown command %s, try help", fields[0])
+	}
+	return nil
+}
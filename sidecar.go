@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SidecarClient talks to an external guidance policy (e.g. a PyTorch model served
+// from Python) that picks the scheduling actions of an iteration step by step.
+//
+// The sidecar serves two HTTP endpoints taking and returning JSON:
+//
+//	POST /next     {"version", "iteration", "step", "state", "events", "available"} -> {"from", "to", "max_messages"}
+//	POST /feedback {"version", "iteration", "new_states", "event_trace"}            -> {}
+//
+// "version" is sidecarProtocolVersion. "state" is the abstract state of the
+// cluster after the previous step, and "events" contains the events observed
+// since the previous /next call of the iteration. "available" lists the ids of
+// the nodes the policy can choose from.
+type SidecarClient struct {
+	Addr string
+}
+
+type SidecarAction struct {
+	From        uint64 `json:"from"`
+	To          uint64 `json:"to"`
+	MaxMessages int    `json:"max_messages"`
+}
+
+// sidecarProtocolVersion is bumped on incompatible changes to the sidecar requests
+// or responses
+const sidecarProtocolVersion = 1
+
+func NewSidecarClient(addr string) *SidecarClient {
+	return &SidecarClient{
+		Addr: addr,
+	}
+}
+
+func (c *SidecarClient) post(endpoint string, body interface{}, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshalling json: %s", err)
+	}
+	res, err := http.Post("http://"+c.Addr+endpoint, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("error sending request to sidecar: %s", err)
+	}
+	defer res.Body.Close()
+	resData, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response from sidecar: %s", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("sidecar returned %s: %s", res.Status, string(resData))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resData, result); err != nil {
+		return fmt.Errorf("error parsing sidecar response: %s", err)
+	}
+	return nil
+}
+
+// NextAction asks the policy for the next node choice of an iteration
+func (c *SidecarClient) NextAction(iteration string, step int, state string, events []*Event, available []uint64) (SidecarAction, error) {
+	action := SidecarAction{}
+	err := c.post("/next", map[string]interface{}{
+		"version":   sidecarProtocolVersion,
+		"iteration": iteration,
+		"step":      step,
+		"state":     state,
+		"events":    events,
+		"available": available,
+	}, &action)
+	return action, err
+}
+
+// Feedback reports the outcome of an iteration to the policy
+func (c *SidecarClient) Feedback(iteration string, newStates int, eventTrace *List[*Event]) error {
+	return c.post("/feedback", map[string]interface{}{
+		"version":     sidecarProtocolVersion,
+		"iteration":   iteration,
+		"new_states":  newStates,
+		"event_trace": eventTrace,
+	}, nil)
+}
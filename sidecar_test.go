@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSidecarNextAction(t *testing.T) {
+	requests := make([]map[string]interface{}, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path == "/next" {
+			requests = append(requests, req)
+		}
+		json.NewEncoder(w).Encode(map[string]int{"from": 1, "to": 2, "max_messages": 1})
+	}))
+	defer server.Close()
+
+	fuzzer := NewFuzzer(&FuzzerConfig{
+		Iterations: 1,
+		Steps:      3,
+		RaftEnvironmentConfig: RaftEnvironmentConfig{
+			Replicas:      3,
+			ElectionTick:  20,
+			HeartbeatTick: 2,
+			TicksPerStep:  2,
+		},
+		MaxMessages: 5,
+		Sidecar:     NewSidecarClient(strings.TrimPrefix(server.URL, "http://")),
+	})
+	fuzzer.RunIteration("sidecar", nil)
+
+	if len(requests) != 3 {
+		t.Fatalf("Expected a request per step, got %d", len(requests))
+	}
+	for _, req := range requests {
+		if req["version"] != float64(sidecarProtocolVersion) {
+			t.Errorf("Expected protocol version %d, got %v", sidecarProtocolVersion, req["version"])
+		}
+		if state, _ := req["state"].(string); state == "" {
+			t.Errorf("Expected the abstract state in the request")
+		}
+		available, _ := req["available"].([]interface{})
+		if len(available) != 3 || available[0] != float64(1) {
+			t.Errorf("Expected nodes 1 to 3 to be available, got %v", req["available"])
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// compressedExt marks artifacts (traces, bundles, corpus entries) stored gzip
+// compressed. gzip comes from the standard library; zstd would need a new
+// module dependency and is not supported. Readers detect compression from the
+// content, so compressed and plain artifacts can be mixed.
+const compressedExt = ".gz"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// artifactPath returns filePath with the compressed extension when compress is set
+func artifactPath(filePath string, compress bool) string {
+	if compress && !strings.HasSuffix(filePath, compressedExt) {
+		return filePath + compressedExt
+	}
+	return filePath
+}
+
+// artifactExists checks for filePath, plain or compressed
+func artifactExists(filePath string) bool {
+	for _, p := range []string{filePath, filePath + compressedExt} {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// createArtifact creates filePath for writing, compressing the written data when
+// filePath has the compressed extension
+func createArtifact(filePath string) (io.WriteCloser, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(filePath, compressedExt) {
+		return file, nil
+	}
+	return &compressedWriter{Writer: gzip.NewWriter(file), file: file}, nil
+}
+
+type compressedWriter struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (w *compressedWriter) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// writeArtifact writes data to filePath, see createArtifact
+func writeArtifact(filePath string, data []byte) error {
+	w, err := createArtifact(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// openArtifact opens filePath for streaming reads, decompressing it if needed.
+// The compressed variant of filePath is opened when filePath does not exist.
+func openArtifact(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) && !strings.HasSuffix(filePath, compressedExt) {
+		if compressed, gzErr := os.Open(filePath + compressedExt); gzErr == nil {
+			file, err = compressed, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(len(gzipMagic))
+	if !bytes.Equal(magic, gzipMagic) {
+		return &artifactReader{Reader: reader, file: file}, nil
+	}
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error decompressing %s: %s", filePath, err)
+	}
+	return &artifactReader{Reader: gz, file: file}, nil
+}
+
+type artifactReader struct {
+	io.Reader
+	file *os.File
+}
+
+func (r *artifactReader) Close() error {
+	return r.file.Close()
+}
+
+// readArtifact reads the whole of filePath, see openArtifact
+func readArtifact(filePath string) ([]byte, error) {
+	r, err := openArtifact(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenArtifactMissing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope")
+	_, err := openArtifact(missing)
+	if !os.IsNotExist(err) {
+		t.Fatalf("Expected a not exist error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), missing+":") {
+		t.Errorf("Expected the error to name %s, got %s", missing, err)
+	}
+}
+
+func TestOpenArtifactCompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "artifact")
+	if err := writeArtifact(artifactPath(path, true), []byte("data")); err != nil {
+		t.Fatalf("Failed to write artifact: %s", err)
+	}
+	r, err := openArtifact(path)
+	if err != nil {
+		t.Fatalf("Failed to open artifact: %s", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "data" {
+		t.Errorf("Expected to read back data, got %q, %v", data, err)
+	}
+}
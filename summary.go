@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// summaryFormatVersion is bumped whenever a field of the summary changes meaning
+// or is removed. Added fields do not bump it.
+const summaryFormatVersion = 1
+
+// defaultCoverageSamples is the number of points the coverage curve is sampled at
+const defaultCoverageSamples = 100
+
+// CampaignSummary is the machine-readable outcome of a campaign, written as
+// summary.json for downstream tooling
+type CampaignSummary struct {
+	FormatVersion int
+	SUTVersion    string
+	Iterations    int
+	Strategy      string
+	Guider        string
+	// Coverage samples the coverage curve of the campaign
+	Coverage []CoverageSample
+	// Bugs are the distinct violations found, by fingerprint
+	Bugs []*BugSummary
+	// Stats are the campaign statistics, e.g. random_executions and buggy_executions
+	Stats map[string]interface{}
+	Usage ResourceUsage
+}
+
+// CoverageSample is the coverage after an iteration
+type CoverageSample struct {
+	Iteration int
+	CoverageStats
+}
+
+// BugSummary groups the violations sharing a fingerprint
+type BugSummary struct {
+	// Fingerprint identifies the bug across campaigns, it is derived from what
+	// was violated and not from the schedule
+	Fingerprint string
+	// CheckerFailed and Invariants are what was violated
+	CheckerFailed bool     `json:",omitempty"`
+	Invariants    []string `json:",omitempty"`
+	// FirstIteration is the first iteration violating it
+	FirstIteration string
+	Occurrences    int
+	// Bundle is the reproducing bundle of the first iteration, if saved
+	Bundle string `json:",omitempty"`
+	// Bundles are the reproducing bundles of every occurrence, if saved
+	Bundles []string `json:",omitempty"`
+}
+
+// bugFingerprint derives the fingerprint of a violation from the checker outcome
+// and the names of the violated invariants
+func bugFingerprint(checkerFailed bool, invariants []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "checker=%t;invariants=%s", checkerFailed, strings.Join(invariants, ","))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// recordBugs groups the violations reported by InvariantViolated by fingerprint
+func (f *Fuzzer) recordBugs(e *CampaignEvent) {
+	checkerFailed, _ := e.Params["checker_failed"].(bool)
+	names := make(map[string]bool)
+	if violations, ok := e.Params["violations"].([]InvariantViolation); ok {
+		for _, v := range violations {
+			names[v.Invariant] = true
+		}
+	}
+	invariants := make([]string, 0, len(names))
+	for name := range names {
+		invariants = append(invariants, name)
+	}
+	sort.Strings(invariants)
+
+	var bundle string
+	if f.config.BundlePath != "" {
+		bundle = artifactPath(path.Join(f.config.BundlePath, e.Iteration+".json"), f.config.Compress)
+	}
+	fingerprint := bugFingerprint(checkerFailed, invariants)
+	if bug, ok := f.bugs[fingerprint]; ok {
+		bug.Occurrences++
+		if bundle != "" {
+			bug.Bundles = append(bug.Bundles, bundle)
+		}
+		return
+	}
+	bug := &BugSummary{
+		Fingerprint:    fingerprint,
+		CheckerFailed:  checkerFailed,
+		Invariants:     invariants,
+		FirstIteration: e.Iteration,
+		Occurrences:    1,
+		Bundle:         bundle,
+	}
+	if bundle != "" {
+		bug.Bundles = []string{bundle}
+	}
+	f.bugs[fingerprint] = bug
+	f.bugOrder = append(f.bugOrder, fingerprint)
+}
+
+// Summary summarizes the campaign from the coverages returned by Run, sampling the
+// coverage curve at up to samples points, the last iteration always included
+func (f *Fuzzer) Summary(coverages []CoverageStats, samples int, sutVersion string) *CampaignSummary {
+	if samples <= 0 {
+		samples = defaultCoverageSamples
+	}
+	s := &CampaignSummary{
+		FormatVersion: summaryFormatVersion,
+		SUTVersion:    sutVersion,
+		Iterations:    len(coverages),
+		Strategy:      fmt.Sprintf("%T", f.config.Strategy),
+		Guider:        fmt.Sprintf("%T", f.config.Guider),
+		Coverage:      make([]CoverageSample, 0, samples),
+		Bugs:          make([]*BugSummary, 0, len(f.bugOrder)),
+		Stats:         make(map[string]interface{}, len(f.stats)),
+		Usage:         f.totalUsage,
+	}
+	stride := (len(coverages) + samples - 1) / samples
+	if stride == 0 {
+		stride = 1
+	}
+	for i := stride - 1; i < len(coverages); i += stride {
+		s.Coverage = append(s.Coverage, CoverageSample{Iteration: i, CoverageStats: coverages[i]})
+	}
+	if last := len(coverages) - 1; last >= 0 && (len(s.Coverage) == 0 || s.Coverage[len(s.Coverage)-1].Iteration != last) {
+		s.Coverage = append(s.Coverage, CoverageSample{Iteration: last, CoverageStats: coverages[last]})
+	}
+	for _, fingerprint := range f.bugOrder {
+		s.Bugs = append(s.Bugs, f.bugs[fingerprint])
+	}
+	for k, v := range f.stats {
+		s.Stats[k] = v
+	}
+	return s
+}
+
+func SaveSummary(filePath string, s *CampaignSummary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling summary: %s", err)
+	}
+	if err := writeArtifact(filePath, data); err != nil {
+		return fmt.Errorf("error writing summary: %s", err)
+	}
+	return nil
+}
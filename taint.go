@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+var mutationCounter uint64
+
+// newMutationID returns a campaign-unique identifier for a payload mutation
+func newMutationID(kind string) string {
+	return fmt.Sprintf("%s-%d", kind, atomic.AddUint64(&mutationCounter, 1))
+}
+
+// tagMutation records that the choice was changed by the mutation with the given id
+func tagMutation(c *SchedulingChoice, id string) {
+	mutations := make([]string, len(c.Mutations), len(c.Mutations)+1)
+	copy(mutations, c.Mutations)
+	c.Mutations = append(mutations, id)
+}
+
+// taintedMutations returns the payload mutations of all client requests that made
+// it into the log of some node, i.e. the mutations that causally precede the
+// current state of the environment
+func taintedMutations(re *RaftEnvironment, t *traceCtx) []string {
+	requestMutations := make(map[int][]string)
+	for step, req := range t.clientRequests {
+		if mutations, ok := t.mutations[step]; ok {
+			requestMutations[req] = mutations
+		}
+	}
+	if len(requestMutations) == 0 {
+		return []string{}
+	}
+
+	tainted := make(map[string]bool)
+	for _, storage := range re.storages {
+		first, _ := storage.FirstIndex()
+		last, _ := storage.LastIndex()
+		entries, err := storage.Entries(first, last+1, 1<<30)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if len(e.Data) == 0 {
+				continue
+			}
+			req, _ := decodeRequest(e.Data)
+			for _, id := range requestMutations[req] {
+				tainted[id] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(tainted))
+	for id := range tainted {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/ds-testing-user/etcd-fuzzing/raft/raftpb"
+)
+
+// Trigger injects a fault when an event of the live event stream matches, e.g.
+// "when node 2 becomes candidate, drop its next RequestVote". Each trigger fires
+// at most once per iteration. Bundles record the triggers created by ParseTrigger
+// by their rule, so that replays inject the same faults.
+type Trigger struct {
+	Name string
+	When func(*Event) bool
+	Fire func(*Network, *Event)
+}
+
+// OnEvent matches the events with the given name emitted by node
+func OnEvent(name string, node uint64) func(*Event) bool {
+	return func(e *Event) bool {
+		return e.Name == name && e.Node == node
+	}
+}
+
+// DropNext returns a fault dropping the next message of the given type sent by node
+func DropNext(node uint64, msgType pb.MessageType) func(*Network, *Event) {
+	return func(n *Network, _ *Event) {
+		n.DropNext(node, msgType)
+	}
+}
+
+// ParseTrigger parses a trigger of the form "<event>@<node> drop <message type>",
+// for instance "Timeout@2 drop MsgVote" drops the next vote request of node 2
+// after it times out and becomes candidate
+func ParseTrigger(rule string) (Trigger, error) {
+	fields := strings.Fields(rule)
+	if len(fields) != 3 || fields[1] != "drop" {
+		return Trigger{}, fmt.Errorf("invalid trigger %q, expected \"<event>@<node> drop <message type>\"", rule)
+	}
+	on := strings.SplitN(fields[0], "@", 2)
+	if len(on) != 2 {
+		return Trigger{}, fmt.Errorf("invalid trigger event %q, expected <event>@<node>", fields[0])
+	}
+	node, err := strconv.ParseUint(on[1], 10, 64)
+	if err != nil {
+		return Trigger{}, fmt.Errorf("invalid node in trigger %q: %s", rule, err)
+	}
+	msgType, ok := pb.MessageType_value[fields[2]]
+	if !ok {
+		return Trigger{}, fmt.Errorf("unknown message type %s in trigger %q", fields[2], rule)
+	}
+	return Trigger{
+		Name: rule,
+		When: OnEvent(on[0], node),
+		Fire: DropNext(node, pb.MessageType(msgType)),
+	}, nil
+}
+
+// triggerSet evaluates the triggers of an iteration over the event stream
+type triggerSet struct {
+	triggers []Trigger
+	fired    map[int]bool
+	seen     int
+}
+
+func newTriggerSet(triggers []Trigger) *triggerSet {
+	return &triggerSet{
+		triggers: triggers,
+		fired:    make(map[int]bool),
+	}
+}
+
+// Evaluate matches the events appended since the last call and returns the
+// names of the triggers that fired
+func (t *triggerSet) Evaluate(events *List[*Event], network *Network) []string {
+	fired := make([]string, 0)
+	if len(t.triggers) == 0 {
+		return fired
+	}
+	for _, e := range events.Iter()[t.seen:] {
+		for i, trigger := range t.triggers {
+			if t.fired[i] || !trigger.When(e) {
+				continue
+			}
+			t.fired[i] = true
+			trigger.Fire(network, e)
+			fired = append(fired, trigger.Name)
+		}
+	}
+	t.seen = events.Size()
+	return fired
+}
@@ -19,6 +19,8 @@ var (
 	StartNode     SchedulingChoiceType = "StartNode"
 	StopNode      SchedulingChoiceType = "StopNode"
 	ClientRequest SchedulingChoiceType = "ClientRequest"
+	ReplayMessage SchedulingChoiceType = "ReplayMessage"
+	LoseQuorum    SchedulingChoiceType = "LoseQuorum"
 )
 
 type SchedulingChoiceType string
@@ -29,10 +31,13 @@ type SchedulingChoice struct {
 	From          uint64
 	To            uint64
 	MaxMessages   int
-	BooleanChoice bool `json:",omitempty"`
-	IntegerChoice int  `json:",omitempty"`
-	Step          int  `json:",omitempty"`
-	Request       int  `json:",omitempty"`
+	BooleanChoice bool   `json:",omitempty"`
+	IntegerChoice int    `json:",omitempty"`
+	Step          int    `json:",omitempty"`
+	Request       int    `json:",omitempty"`
+	Payload       []byte `json:",omitempty"`
+	// Mutations lists the payload mutations applied to this choice
+	Mutations []string `json:",omitempty"`
 }
 
 func (s *SchedulingChoice) Copy() *SchedulingChoice {
@@ -46,6 +51,8 @@ func (s *SchedulingChoice) Copy() *SchedulingChoice {
 		IntegerChoice: s.IntegerChoice,
 		Step:          s.Step,
 		Request:       s.Request,
+		Payload:       append([]byte(nil), s.Payload...),
+		Mutations:     append([]string(nil), s.Mutations...),
 	}
 }
 
@@ -78,6 +85,14 @@ func (q *Queue[T]) Pop() (elem T, ok bool) {
 	return
 }
 
+func (q *Queue[T]) Peek() (elem T, ok bool) {
+	if len(q.q) < 1 {
+		ok = false
+		return
+	}
+	return q.q[0], true
+}
+
 func (q *Queue[T]) Size() int {
 	return len(q.q)
 }
@@ -134,6 +149,15 @@ func (l *List[T]) MarshalJSON() ([]byte, error) {
 	return json.Marshal(l.l)
 }
 
+func (l *List[T]) UnmarshalJSON(data []byte) error {
+	elems := make([]T, 0)
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	l.l = elems
+	return nil
+}
+
 type State struct {
 	Repr string
 	Key  int64
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestSchedulingChoiceCopy(t *testing.T) {
+	choice := &SchedulingChoice{
+		Type:      ClientRequest,
+		Payload:   []byte("SET key 1"),
+		Mutations: []string{"flip"},
+	}
+	copied := choice.Copy()
+	copied.Payload[0] = 'G'
+	copied.Mutations[0] = "truncate"
+	copied.Mutations = append(copied.Mutations, "duplicate")
+
+	if string(choice.Payload) != "SET key 1" {
+		t.Errorf("Expected the payload of the original to be unchanged, got %q", choice.Payload)
+	}
+	if len(choice.Mutations) != 1 || choice.Mutations[0] != "flip" {
+		t.Errorf("Expected the mutations of the original to be unchanged, got %v", choice.Mutations)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// ResourceUsage is the cost of executing a schedule
+type ResourceUsage struct {
+	WallTime time.Duration
+	// CPUTime is the user and system time of the harness process, SUT included
+	CPUTime time.Duration
+	// PeakHeapBytes is the largest heap in use sampled at the end of a step
+	PeakHeapBytes     uint64
+	MessagesSent      int
+	MessagesDelivered int
+}
+
+// usageMeter measures the ResourceUsage of one iteration
+type usageMeter struct {
+	usage    ResourceUsage
+	start    time.Time
+	startCPU time.Duration
+}
+
+func newUsageMeter() *usageMeter {
+	return &usageMeter{
+		start:    time.Now(),
+		startCPU: processCPUTime(),
+	}
+}
+
+// sample records the heap in use at the end of a step
+func (m *usageMeter) sample() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapInuse > m.usage.PeakHeapBytes {
+		m.usage.PeakHeapBytes = stats.HeapInuse
+	}
+}
+
+func (m *usageMeter) stop() ResourceUsage {
+	m.usage.WallTime = time.Since(m.start)
+	m.usage.CPUTime = processCPUTime() - m.startCPU
+	return m.usage
+}
+
+func processCPUTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
+
+// recordUsage accumulates the usage reported by ScheduleCompleted into the stats
+func (f *Fuzzer) recordUsage(e *CampaignEvent) {
+	usage, ok := e.Params["usage"].(ResourceUsage)
+	if !ok {
+		return
+	}
+	f.lastUsage = usage
+	f.executions++
+	f.totalUsage.WallTime += usage.WallTime
+	f.totalUsage.CPUTime += usage.CPUTime
+	f.totalUsage.MessagesSent += usage.MessagesSent
+	f.totalUsage.MessagesDelivered += usage.MessagesDelivered
+	if usage.PeakHeapBytes > f.totalUsage.PeakHeapBytes {
+		f.totalUsage.PeakHeapBytes = usage.PeakHeapBytes
+	}
+	f.stats["usage"] = f.totalUsage
+}
+
+// costAwareMutations scales the mutations of the last schedule by how cheap it
+// was compared to the average schedule so far, by a factor between 0.5 and 2
+func (f *Fuzzer) costAwareMutations(mutations int) int {
+	if f.executions == 0 || f.lastUsage.WallTime <= 0 {
+		return mutations
+	}
+	average := f.totalUsage.WallTime / time.Duration(f.executions)
+	factor := math.Max(0.5, math.Min(2, float64(average)/float64(f.lastUsage.WallTime)))
+	return int(math.Round(float64(mutations) * factor))
+}
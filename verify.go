@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// abstractState summarizes the raft state of every node, the state sequence of a
+// deterministic schedule is the same on every run
+func abstractState(r *RaftEnvironment) string {
+	ids := make([]uint64, 0, len(r.curStates))
+	for id := range r.curStates {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		s := r.curStates[id]
+		parts[i] = fmt.Sprintf("%d:%s/%d/%d/%d/%d", id, s.RaftState, s.Term, s.Vote, s.Commit, s.Lead)
+	}
+	return strings.Join(parts, ";")
+}
+
+// DeterminismResult is the outcome of re-running one corpus schedule
+type DeterminismResult struct {
+	Schedule   int
+	Reproduced bool
+	// Step is the first step whose abstract state differs, -1 when reproduced
+	Step     int
+	Expected string `json:",omitempty"`
+	Observed string `json:",omitempty"`
+}
+
+// CheckDeterminism re-runs k randomly chosen corpus schedules with recorded states
+// and checks that they reproduce the recorded abstract-state sequence
+func CheckDeterminism(corpus *Corpus, k int, seed int64) []DeterminismResult {
+	candidates := make([]int, 0)
+	for i := range corpus.Schedules {
+		if i < len(corpus.States) && len(corpus.States[i]) > 0 {
+			candidates = append(candidates, i)
+		}
+	}
+	r := rand.New(rand.NewSource(seed))
+	r.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if k < len(candidates) {
+		candidates = candidates[:k]
+	}
+	sort.Ints(candidates)
+
+	results := make([]DeterminismResult, 0, len(candidates))
+	for _, i := range candidates {
+		fuzzer := NewFuzzer(&FuzzerConfig{
+			Iterations:            1,
+			Steps:                 corpus.Manifest.Steps,
+			RaftEnvironmentConfig: corpus.Manifest.Environment,
+			Network:               corpus.Manifest.Network,
+		})
+		fuzzer.RunIteration(fmt.Sprintf("determinism_%d", i), corpus.Schedules[i])
+		results = append(results, compareStates(i, corpus.States[i], fuzzer.abstractStates))
+	}
+	return results
+}
+
+func compareStates(schedule int, expected, observed []string) DeterminismResult {
+	result := DeterminismResult{Schedule: schedule, Reproduced: true, Step: -1}
+	for step := 0; step < len(expected) || step < len(observed); step++ {
+		var e, o string
+		if step < len(expected) {
+			e = expected[step]
+		}
+		if step < len(observed) {
+			o = observed[step]
+		}
+		if e != o {
+			result.Reproduced = false
+			result.Step = step
+			result.Expected = e
+			result.Observed = o
+			break
+		}
+	}
+	return result
+}
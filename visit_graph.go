@@ -2,18 +2,29 @@ package main
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 )
 
 type VisitGraph struct {
 	Nodes map[int64]*VisitGraphNode
+	// schedules holds the schedules that first reached a state, by hash. They
+	// are only written out for rare states, see Export.
+	schedules map[string]*List[*SchedulingChoice]
 }
 
 func NewVisitGraph() *VisitGraph {
 	return &VisitGraph{
-		Nodes: make(map[int64]*VisitGraphNode),
+		Nodes:     make(map[int64]*VisitGraphNode),
+		schedules: make(map[string]*List[*SchedulingChoice]),
 	}
 }
 
@@ -21,44 +32,66 @@ func (v *VisitGraph) IsEmpty() bool {
 	return len(v.Nodes) == 0
 }
 
-func (v *VisitGraph) Update(trace []State) {
-	for i := 0; i < len(trace)-1; i++ {
-		cur := trace[i]
-		next := trace[i+1]
-		if _, ok := v.Nodes[cur.Key]; !ok {
-			v.Nodes[cur.Key] = &VisitGraphNode{
-				Key:    cur.Key,
-				State:  cur.Repr,
-				Visits: 0,
-				Next:   make(map[int64]bool),
-				Prev:   make(map[int64]bool),
-			}
-		}
-		if _, ok := v.Nodes[next.Key]; !ok {
-			v.Nodes[next.Key] = &VisitGraphNode{
-				Key:    next.Key,
-				State:  next.Repr,
-				Visits: 0,
-				Next:   make(map[int64]bool),
-				Prev:   make(map[int64]bool),
+// Update records the visits of a state trace. The schedule is kept once for the
+// states reached for the first time, so that rare states can be reproduced.
+func (v *VisitGraph) Update(trace []State, schedule *List[*SchedulingChoice]) {
+	if len(trace) == 0 {
+		return
+	}
+	hash := ""
+	node := func(s State) *VisitGraphNode {
+		n, ok := v.Nodes[s.Key]
+		if !ok {
+			if hash == "" {
+				hash = v.addSchedule(schedule)
 			}
+			n = v.node(s, hash)
 		}
+		return n
+	}
+	for i := 0; i < len(trace)-1; i++ {
+		cur := node(trace[i])
+		next := node(trace[i+1])
 
-		v.Nodes[cur.Key].Visits += 1
-		v.Nodes[cur.Key].AddNext(next.Key)
-		v.Nodes[next.Key].AddPrev(cur.Key)
-	}
-	last := trace[len(trace)-1]
-	if _, ok := v.Nodes[last.Key]; !ok {
-		v.Nodes[last.Key] = &VisitGraphNode{
-			Key:    last.Key,
-			State:  last.Repr,
-			Visits: 0,
-			Next:   make(map[int64]bool),
-			Prev:   make(map[int64]bool),
-		}
+		cur.Visits += 1
+		cur.AddNext(next.Key)
+		next.AddPrev(cur.Key)
+	}
+	node(trace[len(trace)-1]).Visits += 1
+}
+
+// addSchedule stores a copy of schedule and returns its hash
+func (v *VisitGraph) addSchedule(schedule *List[*SchedulingChoice]) string {
+	bs, _ := json.Marshal(schedule)
+	sum := sha256.Sum256(bs)
+	hash := hex.EncodeToString(sum[:])
+	if v.schedules == nil {
+		v.schedules = make(map[string]*List[*SchedulingChoice])
+	}
+	if _, ok := v.schedules[hash]; !ok {
+		v.schedules[hash] = copyTrace(schedule, defaultCopyFilter())
 	}
-	v.Nodes[last.Key].Visits += 1
+	return hash
+}
+
+func (v *VisitGraph) node(s State, scheduleHash string) *VisitGraphNode {
+	n := &VisitGraphNode{
+		Key:          s.Key,
+		State:        s.Repr,
+		Visits:       0,
+		Next:         make(map[int64]bool),
+		Prev:         make(map[int64]bool),
+		Transitions:  make(map[int64]int),
+		ScheduleHash: scheduleHash,
+	}
+	v.Nodes[s.Key] = n
+	return n
+}
+
+// Schedule returns the first schedule that reached n, nil when it is not kept,
+// e.g. for states imported from another graph
+func (v *VisitGraph) Schedule(n *VisitGraphNode) *List[*SchedulingChoice] {
+	return v.schedules[n.ScheduleHash]
 }
 
 func (v *VisitGraph) record(recordPath string, key string) {
@@ -83,12 +116,17 @@ type VisitGraphNode struct {
 	Visits int
 	Next   map[int64]bool `json:",omitempty"`
 	Prev   map[int64]bool `json:",omitempty"`
+	// Transitions counts how often each outgoing transition was taken
+	Transitions map[int64]int `json:",omitempty"`
+	// ScheduleHash identifies the first schedule that reached the state
+	ScheduleHash string `json:",omitempty"`
 }
 
 func (n *VisitGraphNode) AddNext(next int64) {
 	if next == n.Key {
 		return
 	}
+	n.Transitions[next] += 1
 	if _, ok := n.Next[next]; !ok {
 		n.Next[next] = true
 	}
@@ -102,3 +140,116 @@ func (n *VisitGraphNode) AddPrev(prev int64) {
 		n.Prev[prev] = true
 	}
 }
+
+// RareStates returns the states visited at most maxVisits times
+func (v *VisitGraph) RareStates(maxVisits int) []*VisitGraphNode {
+	rare := make([]*VisitGraphNode, 0)
+	for _, n := range v.sortedNodes() {
+		if n.Visits <= maxVisits {
+			rare = append(rare, n)
+		}
+	}
+	return rare
+}
+
+func (v *VisitGraph) sortedNodes() []*VisitGraphNode {
+	nodes := make([]*VisitGraphNode, 0, len(v.Nodes))
+	for _, n := range v.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Key < nodes[j].Key
+	})
+	return nodes
+}
+
+func sortedKeys(m map[int64]int) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Nodes are labelled with their
+// visit counts and edges with the number of times the transition was taken.
+func (v *VisitGraph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph states {")
+	for _, n := range v.sortedNodes() {
+		fmt.Fprintf(b, "\t\"%d\" [label=\"visits=%d\", tooltip=%s];\n", n.Key, n.Visits, strconv.Quote(n.State))
+	}
+	for _, n := range v.sortedNodes() {
+		for _, next := range sortedKeys(n.Transitions) {
+			fmt.Fprintf(b, "\t\"%d\" -> \"%d\" [label=\"%d\"];\n", n.Key, next, n.Transitions[next])
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// WriteGraphML writes the graph in GraphML format with the state, visit and
+// transition counts as data attributes
+func (v *VisitGraph) WriteGraphML(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(b, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(b, `  <key id="state" for="node" attr.name="state" attr.type="string"/>`)
+	fmt.Fprintln(b, `  <key id="visits" for="node" attr.name="visits" attr.type="int"/>`)
+	fmt.Fprintln(b, `  <key id="count" for="edge" attr.name="count" attr.type="int"/>`)
+	fmt.Fprintln(b, `  <graph id="states" edgedefault="directed">`)
+	for _, n := range v.sortedNodes() {
+		fmt.Fprintf(b, "    <node id=\"%d\">\n      <data key=\"state\">", n.Key)
+		if err := xml.EscapeText(b, []byte(n.State)); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "</data>\n      <data key=\"visits\">%d</data>\n    </node>\n", n.Visits)
+	}
+	for _, n := range v.sortedNodes() {
+		for _, next := range sortedKeys(n.Transitions) {
+			fmt.Fprintf(b, "    <edge source=\"%d\" target=\"%d\">\n      <data key=\"count\">%d</data>\n    </edge>\n", n.Key, next, n.Transitions[next])
+		}
+	}
+	fmt.Fprintln(b, "  </graph>")
+	fmt.Fprintln(b, "</graphml>")
+	return b.Flush()
+}
+
+// Export writes the graph as JSON, DOT and GraphML to recordPath along with the
+// schedules reaching states visited at most rareVisits times
+func (v *VisitGraph) Export(recordPath, key string, rareVisits int) error {
+	v.record(recordPath, key)
+
+	writers := map[string]func(io.Writer) error{
+		"visit_graph_" + key + ".dot":     v.WriteDOT,
+		"visit_graph_" + key + ".graphml": v.WriteGraphML,
+	}
+	for name, write := range writers {
+		file, err := os.Create(path.Join(recordPath, name))
+		if err != nil {
+			return fmt.Errorf("error creating %s: %s", name, err)
+		}
+		err = write(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("error writing %s: %s", name, err)
+		}
+	}
+
+	rare := make(map[string]interface{})
+	for _, n := range v.RareStates(rareVisits) {
+		rare[strconv.FormatInt(n.Key, 10)] = map[string]interface{}{
+			"state":    n.State,
+			"visits":   n.Visits,
+			"schedule": v.Schedule(n),
+		}
+	}
+	bs, err := json.MarshalIndent(rare, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error marshalling rare states: %s", err)
+	}
+	return os.WriteFile(path.Join(recordPath, "rare_states_"+key+".json"), bs, 0644)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+type StallKind string
+
+var (
+	// StallHarness: messages could be delivered but the schedule did not deliver
+	// any, pointing at the scheduler or the network model
+	StallHarness StallKind = "harness"
+	// StallDeadlock: nothing is in flight and live nodes stay silent
+	StallDeadlock StallKind = "deadlock"
+	// StallQuiescence: nothing is in flight and no node is alive, as intended by
+	// the injected crashes
+	StallQuiescence StallKind = "quiescence"
+)
+
+// stallWatchdog detects iterations that make no progress, i.e. where no event is
+// emitted for a number of consecutive steps
+type stallWatchdog struct {
+	limit     int
+	idleSteps int
+	seen      int
+	fired     bool
+}
+
+func newStallWatchdog(limit int) *stallWatchdog {
+	return &stallWatchdog{
+		limit: limit,
+	}
+}
+
+// Observe records the events after a step and returns true once when the
+// iteration has been idle for the configured number of steps
+func (w *stallWatchdog) Observe(events *List[*Event]) bool {
+	if w.limit <= 0 || w.fired {
+		return false
+	}
+	if events.Size() > w.seen {
+		w.seen = events.Size()
+		w.idleSteps = 0
+		return false
+	}
+	w.idleSteps++
+	if w.idleSteps >= w.limit {
+		w.fired = true
+		return true
+	}
+	return false
+}
+
+// classifyStall tells apart the reasons for which an iteration stopped progressing
+func (f *Fuzzer) classifyStall(crashed map[uint64]bool) StallKind {
+	inFlight := false
+	for key, q := range f.messageQueues {
+		m, ok := q.Peek()
+		if !ok {
+			continue
+		}
+		inFlight = true
+		var from, to uint64
+		if _, err := fmt.Sscanf(key, "%d_%d", &from, &to); err == nil && f.network.Deliverable(from, to, m) && !f.network.Blocked(from, to) {
+			if _, down := crashed[to]; !down {
+				return StallHarness
+			}
+		}
+	}
+	if inFlight {
+		// Messages are held back by the network model or addressed to crashed nodes
+		return StallDeadlock
+	}
+	for _, id := range f.nodes {
+		if id == 0 {
+			continue
+		}
+		if _, down := crashed[id]; !down {
+			return StallDeadlock
+		}
+	}
+	return StallQuiescence
+}
+
+func (f *Fuzzer) recordStallStats(e *CampaignEvent) {
+	key := fmt.Sprintf("stalled_%s_executions", e.Params["kind"])
+	count, _ := f.stats[key].(int)
+	f.stats[key] = count + 1
+}
+
+// saveStalls stores a bundle for every stall that is not intended quiescence
+func (f *Fuzzer) saveStalls(e *CampaignEvent) {
+	if kind, _ := e.Params["kind"].(StallKind); kind == StallQuiescence {
+		return
+	}
+	if bundle, ok := f.bundleFromEvent(e); ok {
+		SaveBundle(artifactPath(path.Join(f.config.BundlePath, "stall_"+e.Iteration+".json"), f.config.Compress), bundle)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	pb "github.com/ds-testing-user/etcd-fuzzing/raft/raftpb"
+)
+
+// Operation is a client request of an iteration
+type Operation struct {
+	Request int
+	Step    int
+	Payload []byte `json:",omitempty"`
+}
+
+// Outcome is what the system under test answered to an operation
+type Outcome struct {
+	Result []byte `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+// HistoryEntry is an executed operation along with its outcome
+type HistoryEntry struct {
+	Operation
+	Outcome Outcome
+}
+
+// WorkloadAdapter drives the client workload of iterations, so the scheduling
+// guidance and checking can be reused against services other than the raft
+// environment. The fuzzer keeps scheduling the raft environment; an adapter
+// for another service submits the operations to it instead.
+type WorkloadAdapter interface {
+	// Generate returns the payload of the request-th client request of a random
+	// iteration, nil for none. Mutated iterations reuse the recorded payloads.
+	Generate(request int) []byte
+	// Execute submits op at its step of the iteration
+	Execute(ctx *FuzzContext, env *RaftEnvironment, op *Operation) Outcome
+	// Record is given the history of an iteration once it completed
+	Record(iteration string, history []*HistoryEntry)
+}
+
+// RaftWorkload proposes client requests to the raft environment, with payloads
+// drawn from an optional generator. It is the default workload.
+type RaftWorkload struct {
+	Payload *Generator
+}
+
+var _ WorkloadAdapter = (*RaftWorkload)(nil)
+
+func NewRaftWorkload(payload *Generator) *RaftWorkload {
+	return &RaftWorkload{Payload: payload}
+}
+
+func (w *RaftWorkload) Generate(request int) []byte {
+	if w.Payload == nil {
+		return nil
+	}
+	return w.Payload.Next()
+}
+
+// Execute proposes op. Proposals are not answered, whether they commit is
+// checked on the logs by the checker.
+func (w *RaftWorkload) Execute(ctx *FuzzContext, env *RaftEnvironment, op *Operation) Outcome {
+	env.Step(ctx, pb.Message{
+		Type: pb.MsgProp,
+		From: uint64(0),
+		Entries: []pb.Entry{
+			{Data: encodeRequest(op.Request, op.Payload)},
+		},
+	})
+	return Outcome{}
+}
+
+func (w *RaftWorkload) Record(iteration string, history []*HistoryEntry) {}
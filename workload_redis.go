@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisTimeout is the default bound of every command sent to the Redis server
+const redisTimeout = 5 * time.Second
+
+// RedisWorkload is a reference WorkloadAdapter for services speaking the Redis
+// protocol. Operations are SET and GET commands on a small keyspace, executed
+// against the server at Addr. The history of every iteration is appended as a
+// JSON line to HistoryPath, if set, to be checked e.g. for linearizability.
+// Every command is bounded by Timeout.
+type RedisWorkload struct {
+	Addr        string
+	Keys        int
+	HistoryPath string
+	Timeout     time.Duration
+
+	rand   *rand.Rand
+	mutex  sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+var _ WorkloadAdapter = (*RedisWorkload)(nil)
+
+func NewRedisWorkload(addr string, keys int, seed int64) *RedisWorkload {
+	return &RedisWorkload{
+		Addr:    addr,
+		Keys:    keys,
+		Timeout: redisTimeout,
+		rand:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Generate returns a SET of the request number or a GET on a random key
+func (w *RedisWorkload) Generate(request int) []byte {
+	key := fmt.Sprintf("key%d", w.rand.Intn(w.Keys))
+	if w.rand.Intn(2) == 0 {
+		return []byte("GET " + key)
+	}
+	return []byte(fmt.Sprintf("SET %s %d", key, request))
+}
+
+// Execute sends the command of op, reconnecting after a failed command
+func (w *RedisWorkload) Execute(ctx *FuzzContext, env *RaftEnvironment, op *Operation) Outcome {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	args := strings.Fields(string(op.Payload))
+	if len(args) == 0 {
+		return Outcome{Error: "empty command"}
+	}
+	reply, err := w.do(args)
+	if err != nil {
+		if w.conn != nil {
+			w.conn.Close()
+			w.conn = nil
+		}
+		return Outcome{Error: err.Error()}
+	}
+	return reply
+}
+
+// RoundTrip measures the time of a PING to the server
+func (w *RedisWorkload) RoundTrip() (time.Duration, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	start := time.Now()
+	reply, err := w.do([]string{"PING"})
+	if err != nil {
+		if w.conn != nil {
+			w.conn.Close()
+			w.conn = nil
+		}
+		return 0, err
+	}
+	if reply.Error != "" {
+		return 0, fmt.Errorf("error pinging redis: %s", reply.Error)
+	}
+	return time.Since(start), nil
+}
+
+func (w *RedisWorkload) do(args []string) (Outcome, error) {
+	if w.conn == nil {
+		conn, err := net.DialTimeout("tcp", w.Addr, w.Timeout)
+		if err != nil {
+			return Outcome{}, fmt.Errorf("error connecting to redis: %s", err)
+		}
+		w.conn = conn
+		w.reader = bufio.NewReader(conn)
+	}
+	w.conn.SetDeadline(time.Now().Add(w.Timeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := w.conn.Write([]byte(b.String())); err != nil {
+		return Outcome{}, fmt.Errorf("error sending command: %s", err)
+	}
+	return readRESP(w.reader)
+}
+
+// readRESP reads a reply that is not an array
+func readRESP(r *bufio.Reader) (Outcome, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return Outcome{}, fmt.Errorf("error reading reply: %s", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return Outcome{}, fmt.Errorf("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return Outcome{Result: []byte(line[1:])}, nil
+	case '-':
+		return Outcome{Error: line[1:]}, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return Outcome{}, fmt.Errorf("malformed bulk length %q", line)
+		}
+		if n < 0 {
+			return Outcome{}, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return Outcome{}, fmt.Errorf("error reading reply: %s", err)
+		}
+		return Outcome{Result: data[:n]}, nil
+	default:
+		return Outcome{}, fmt.Errorf("unsupported reply %q", line)
+	}
+}
+
+func (w *RedisWorkload) Record(iteration string, history []*HistoryEntry) {
+	if w.HistoryPath == "" {
+		return
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"iteration": iteration,
+		"history":   history,
+	})
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(w.HistoryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// Close closes the connection to the server
+func (w *RedisWorkload) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}